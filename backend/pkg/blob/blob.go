@@ -0,0 +1,21 @@
+// Package blob keeps uploaded files on a local directory or on
+// S3-compatible object storage. It is shared by the services that store
+// user media, each of them under its own key prefix.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Get for a missing key
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps files by key. Get returns ErrNotFound for a missing key;
+// deleting a missing key is not an error.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http"
+
+	"auth_service/api/http/apierrors"
+	"auth_service/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePassword requires the current password and returns new tokens
+func (h *AuthHandler) ChangePassword(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	tokens, err := h.svc.ChangePassword(c.Request.Context(), userID, req, clientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, tokens)
+	return nil
+}
+
+// ChangeEmail mails a confirmation link to the new address
+func (h *AuthHandler) ChangeEmail(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	if err := h.svc.ChangeEmail(c.Request.Context(), userID, req); err != nil {
+		return err
+	}
+
+	c.Status(http.StatusAccepted)
+	return nil
+}
+
+// ConfirmEmailChange is the link mailed by ChangeEmail
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) error {
+	token := c.Query("token")
+	if token == "" {
+		return apierrors.NewBadRequest("missing token query parameter", nil)
+	}
+
+	if err := h.svc.ConfirmEmailChange(c.Request.Context(), token); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "email changed"})
+	return nil
+}
+
+// ChangeUsername renames the caller
+func (h *AuthHandler) ChangeUsername(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.ChangeUsernameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.ChangeUsername(c.Request.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, out)
+	return nil
+}
@@ -20,20 +20,63 @@ func NewAuthHandler(svc usecases.AuthService) *AuthHandler {
 	return &AuthHandler{svc: svc}
 }
 
-func (h *AuthHandler) RegisterRoutes(r *gin.Engine, token string) {
+func (h *AuthHandler) RegisterRoutes(r *gin.Engine, token, gatewayToken, adminToken string) {
 	grp := r.Group("/auth")
 	{
 		grp.GET("/health", middleware.ErrorHandlerMiddleware(h.Health))
+		grp.GET("/.well-known/jwks.json", middleware.ErrorHandlerMiddleware(h.JWKS))
 		grp.POST("/register", middleware.ErrorHandlerMiddleware(h.Register))
 		grp.POST("/login", middleware.ErrorHandlerMiddleware(h.Login))
+		grp.POST("/login/mfa", middleware.ErrorHandlerMiddleware(h.LoginMFA))
+		grp.POST("/refresh", middleware.ErrorHandlerMiddleware(h.Refresh))
+		grp.POST("/logout", middleware.ErrorHandlerMiddleware(h.Logout))
+		grp.POST("/password/forgot", middleware.ErrorHandlerMiddleware(h.ForgotPassword))
+		grp.POST("/password/reset", middleware.ErrorHandlerMiddleware(h.ResetPassword))
+		grp.GET("/verify", middleware.ErrorHandlerMiddleware(h.VerifyEmail))
+		grp.POST("/verify/resend", middleware.ErrorHandlerMiddleware(h.ResendVerification))
+		grp.GET("/email/confirm", middleware.ErrorHandlerMiddleware(h.ConfirmEmailChange))
 		grp.GET("/user/:id", middleware.ErrorHandlerMiddleware(h.GetUserByID))
+
+		// account settings, caller identified by the gateway's X-User-ID
+		grp.PUT("/me/password", middleware.ErrorHandlerMiddleware(h.ChangePassword))
+		grp.PUT("/me/email", middleware.ErrorHandlerMiddleware(h.ChangeEmail))
+		grp.PUT("/me/username", middleware.ErrorHandlerMiddleware(h.ChangeUsername))
+		grp.GET("/sessions", middleware.ErrorHandlerMiddleware(h.ListSessions))
+		grp.DELETE("/sessions/:id", middleware.ErrorHandlerMiddleware(h.RevokeSession))
+
+		// two-factor management, caller identified by the gateway's X-User-ID
+		grp.POST("/mfa/totp/enroll", middleware.ErrorHandlerMiddleware(h.EnrollTOTP))
+		grp.POST("/mfa/totp/confirm", middleware.ErrorHandlerMiddleware(h.ConfirmTOTP))
+		grp.POST("/mfa/totp/disable", middleware.ErrorHandlerMiddleware(h.DisableTOTP))
+		grp.POST("/mfa/recovery-codes", middleware.ErrorHandlerMiddleware(h.RegenerateRecoveryCodes))
 		grp.DELETE("/user/:id",
 			middleware.ServiceAuthMiddleware(token),
 			middleware.ErrorHandlerMiddleware(h.DeleteUser),
 		)
+		grp.GET("/internal/users/:id",
+			middleware.ServiceAuthMiddleware(gatewayToken),
+			middleware.ErrorHandlerMiddleware(h.GetUserInternal),
+		)
+		grp.GET("/internal/sessions/:id",
+			middleware.ServiceAuthMiddleware(gatewayToken),
+			middleware.ErrorHandlerMiddleware(h.CheckSession),
+		)
+
+		// administration: admin users via the gateway or operators with the admin token
+		admin := grp.Group("/admin", middleware.AdminMiddleware(adminToken))
+		{
+			admin.POST("/unlock", middleware.ErrorHandlerMiddleware(h.UnlockAccount))
+			admin.GET("/users/:id", middleware.ErrorHandlerMiddleware(h.GetUserInternal))
+			admin.PUT("/users/:id/role", middleware.ErrorHandlerMiddleware(h.SetRole))
+		}
 	}
 }
 
+// clientInfo describes the device behind the request for session tracking
+func clientInfo(c *gin.Context) domain.ClientInfo {
+	return domain.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
 // Health
 func (h *AuthHandler) Health(c *gin.Context) error {
 	if healthSvc, ok := h.svc.(interface {
@@ -57,12 +100,12 @@ func (h *AuthHandler) Register(c *gin.Context) error {
 		return apierrors.NewBadRequest(err.Error(), err)
 	}
 
-	token, err := h.svc.Register(c.Request.Context(), creds)
+	tokens, err := h.svc.Register(c.Request.Context(), creds, clientInfo(c))
 	if err != nil {
 		return err //
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"token": token})
+	c.JSON(http.StatusCreated, tokens)
 	return nil
 }
 
@@ -75,23 +118,135 @@ func (h *AuthHandler) Login(c *gin.Context) error {
 		return apierrors.NewBadRequest(err.Error(), err)
 	}
 
-	token, err := h.svc.Login(c.Request.Context(), creds)
+	tokens, err := h.svc.Login(c.Request.Context(), creds, clientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, tokens)
+	return nil
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) error {
+	var req domain.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	tokens, err := h.svc.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
 	if err != nil {
 		return err
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, tokens)
 	return nil
 }
 
-// GetUserByID:
+// Logout revokes the refresh token family
+func (h *AuthHandler) Logout(c *gin.Context) error {
+	var req domain.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	if err := h.svc.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		return err
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// ForgotPassword mails a reset link; always 202 to avoid leaking accounts
+func (h *AuthHandler) ForgotPassword(c *gin.Context) error {
+	var req domain.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	if err := h.svc.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "if the email is registered, a reset link has been sent"})
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token
+func (h *AuthHandler) ResetPassword(c *gin.Context) error {
+	var req domain.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	if err := h.svc.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		return err
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// VerifyEmail handles the link from the verification email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) error {
+	token := c.Query("token")
+	if token == "" {
+		return apierrors.NewBadRequest("missing token query parameter", nil)
+	}
+
+	if err := h.svc.VerifyEmail(c.Request.Context(), token); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "email verified"})
+	return nil
+}
+
+// ResendVerification mails a new verification link
+func (h *AuthHandler) ResendVerification(c *gin.Context) error {
+	var req domain.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	if err := h.svc.ResendVerification(c.Request.Context(), req.Email); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "if the email is registered and unverified, a new link has been sent"})
+	return nil
+}
+
+// GetUserByID: full record for the caller's own id, public fields otherwise
 func (h *AuthHandler) GetUserByID(c *gin.Context) error {
+	callerID := c.GetHeader("X-User-ID")
+	if callerID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
 	id := c.Param("id")
 	user, err := h.svc.FindByID(c.Request.Context(), id)
 	if err != nil {
 		return err // <- ErrUserNotFound
 	}
-	c.JSON(http.StatusOK, user)
+	if user.ID == callerID {
+		c.JSON(http.StatusOK, domain.NewAccountResponse(user))
+	} else {
+		c.JSON(http.StatusOK, domain.NewUserResponse(user))
+	}
+	return nil
+}
+
+// GetUserInternal: full account lookup for other services and admins
+func (h *AuthHandler) GetUserInternal(c *gin.Context) error {
+	user, err := h.svc.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		return err // <- ErrUserNotFound
+	}
+	c.JSON(http.StatusOK, domain.NewAccountResponse(user))
 	return nil
 }
 
@@ -104,3 +259,47 @@ func (h *AuthHandler) DeleteUser(c *gin.Context) error {
 	c.Status(http.StatusNoContent)
 	return nil
 }
+
+// JWKS publishes the token verification keys
+func (h *AuthHandler) JWKS(c *gin.Context) error {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.svc.JWKS(c.Request.Context()))
+	return nil
+}
+
+// UnlockAccount clears failed-login counters for a username and/or IP
+func (h *AuthHandler) UnlockAccount(c *gin.Context) error {
+	var req domain.UnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	if err := h.svc.UnlockAccount(c.Request.Context(), req); err != nil {
+		return err
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// SetRole promotes or demotes a user
+func (h *AuthHandler) SetRole(c *gin.Context) error {
+	var req domain.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.SetRole(c.Request.Context(), c.Param("id"), req.Role)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, out)
+	return nil
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"net/http"
+
+	"auth_service/api/http/apierrors"
+	"auth_service/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginMFA completes a two-step login
+func (h *AuthHandler) LoginMFA(c *gin.Context) error {
+	var req domain.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	tokens, err := h.svc.LoginMFA(c.Request.Context(), req, clientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, tokens)
+	return nil
+}
+
+// EnrollTOTP returns a new secret and otpauth:// URI for the QR code
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+
+	out, err := h.svc.EnrollTOTP(c.Request.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// ConfirmTOTP enables two-factor auth and returns recovery codes once
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	out, err := h.svc.ConfirmTOTP(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// DisableTOTP turns two-factor auth off
+func (h *AuthHandler) DisableTOTP(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	if err := h.svc.DisableTOTP(c.Request.Context(), userID, req.Code); err != nil {
+		return err
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest("invalid JSON", err)
+	}
+
+	out, err := h.svc.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, out)
+	return nil
+}
@@ -2,10 +2,14 @@ package middleware
 
 import (
 	"auth_service/api/http/apierrors"
+	"auth_service/domain"
 	"auth_service/usecases"
 	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,10 +24,37 @@ func ErrorHandlerMiddleware(h HandlerFunc) gin.HandlerFunc {
 				c.JSON(http.StatusConflict, gin.H{"error": "email or username already in use"})
 			case errors.Is(err, usecases.ErrInvalidCredentials):
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			case errors.Is(err, usecases.ErrInvalidRefreshToken),
+				errors.Is(err, usecases.ErrRefreshTokenReused):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			case errors.Is(err, usecases.ErrSessionNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
 			case errors.Is(err, usecases.ErrUserNotFound):
 				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			case errors.Is(err, usecases.ErrProfileServiceDown):
 				c.JSON(http.StatusBadGateway, gin.H{"error": "profile service unavailable"})
+			case errors.Is(err, usecases.ErrInvalidToken):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+			case errors.Is(err, usecases.ErrMailerDown):
+				c.JSON(http.StatusBadGateway, gin.H{"error": "mail service unavailable"})
+			case errors.Is(err, usecases.ErrInvalidMFACode):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid two-factor code"})
+			case errors.Is(err, usecases.ErrMFAAlreadyEnabled):
+				c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+			case errors.Is(err, usecases.ErrMFANotEnrolled):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is not enabled"})
+			case errors.Is(err, usecases.ErrWrongPassword):
+				c.JSON(http.StatusForbidden, gin.H{"error": "current password is incorrect"})
+			case errors.Is(err, usecases.ErrFeedServiceDown):
+				c.JSON(http.StatusBadGateway, gin.H{"error": "feed service unavailable"})
+			case errors.Is(err, usecases.ErrEmailNotVerified):
+				c.JSON(http.StatusForbidden, gin.H{"error": "email address is not verified"})
+			case errors.Is(err, usecases.ErrTooManyRequests):
+				var retry *usecases.RetryAfterError
+				if errors.As(err, &retry) {
+					c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry.Wait.Seconds()))))
+				}
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
 			default:
 				var apiErr apierrors.APIError
 				if errors.As(err, &apiErr) {
@@ -38,9 +69,11 @@ func ErrorHandlerMiddleware(h HandlerFunc) gin.HandlerFunc {
 	}
 }
 
+// ServiceAuthMiddleware only lets through callers presenting the shared
+// X-Service-Token. An unset token disables the route instead of opening it.
 func ServiceAuthMiddleware(expectedToken string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if c.GetHeader("X-Service-Token") != expectedToken {
+		if expectedToken == "" || c.GetHeader("X-Service-Token") != expectedToken {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "service unauthorized"})
 			c.Abort()
 			return
@@ -48,3 +81,22 @@ func ServiceAuthMiddleware(expectedToken string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminMiddleware lets through operators presenting the admin service token
+// and users the gateway marked as admin in X-User-Roles
+func AdminMiddleware(serviceToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if serviceToken != "" && c.GetHeader("X-Service-Token") == serviceToken {
+			c.Next()
+			return
+		}
+		for _, role := range strings.Split(c.GetHeader("X-User-Roles"), ",") {
+			if strings.TrimSpace(role) == domain.RoleAdmin {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin role required"})
+		c.Abort()
+	}
+}
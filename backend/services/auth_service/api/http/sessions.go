@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+
+	"auth_service/api/http/apierrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions shows the caller's signed-in devices; the gateway passes
+// the session of the current token in X-Session-ID
+func (h *AuthHandler) ListSessions(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+
+	out, err := h.svc.ListSessions(c.Request.Context(), userID, c.GetHeader("X-Session-ID"))
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// RevokeSession signs one of the caller's devices out
+func (h *AuthHandler) RevokeSession(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+
+	if err := h.svc.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		return err
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// CheckSession: internal, lets the gateway reject tokens of revoked sessions
+func (h *AuthHandler) CheckSession(c *gin.Context) error {
+	out, err := h.svc.CheckSession(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		return err // <- ErrSessionNotFound
+	}
+
+	c.JSON(http.StatusOK, out)
+	return nil
+}
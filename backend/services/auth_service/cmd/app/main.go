@@ -12,7 +12,10 @@ import (
 	httpHandler "auth_service/api/http"
 	"auth_service/cmd/config"
 	"auth_service/jwt"
+	"auth_service/mailer"
+	"auth_service/repository"
 	"auth_service/repository/db"
+	"auth_service/repository/memory"
 	authService "auth_service/usecases/service"
 
 	"github.com/gin-gonic/gin"
@@ -22,9 +25,13 @@ func main() {
 	// Load configuration
 	dbCfg := config.LoadDBConfig()
 	svcCfg := config.LoadServiceConfig()
+	mailCfg := config.LoadMailConfig()
+	lockCfg := config.LoadLockoutConfig()
 
-	// Initialize JWT secret
-	jwt.Init(svcCfg.Jwt_secret)
+	// Load JWT signing keys
+	if err := jwt.Init(svcCfg.JwtKeysDir, svcCfg.JwtActiveKeyID, svcCfg.AccessTokenTTL); err != nil {
+		log.Fatalf("failed to load JWT keys: %v", err)
+	}
 
 	// Initialize GORM DB
 	gormDB, err := db.InitDB(dbCfg)
@@ -53,12 +60,59 @@ func main() {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Logger(), gin.Recovery())
+	// ClientIP feeds per-IP login throttling, so only trust X-Forwarded-For from our own network
+	if err := router.SetTrustedProxies(svcCfg.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Mailer
+	var mail mailer.Mailer
+	switch mailCfg.Driver {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(mailCfg.SMTPHost, mailCfg.SMTPPort, mailCfg.SMTPUser, mailCfg.SMTPPass, mailCfg.From)
+	case "file":
+		mail, err = mailer.NewFileMailer(mailCfg.OutboxPath)
+		if err != nil {
+			log.Fatalf("failed to init mailer: %v", err)
+		}
+	default:
+		mail = mailer.NewLogMailer()
+	}
 
 	// Wire up repository, service, and handler
 	userRepo := db.NewUserRepo(gormDB)
-	svc := authService.NewAuthService(userRepo, svcCfg.Profile_service_utl)
+	tokenRepo := db.NewRefreshTokenRepo(gormDB)
+	actionRepo := db.NewActionTokenRepo(gormDB)
+	mfaRepo := db.NewMFARepo(gormDB)
+	sessionRepo := db.NewSessionRepo(gormDB)
+	var attempts repository.LoginAttemptStore
+	if lockCfg.Store == "memory" {
+		attempts = memory.NewLoginAttemptStore()
+	} else {
+		attempts = db.NewLoginAttemptStore(gormDB)
+	}
+	lockout := authService.LockoutPolicy{
+		FreeAttempts:    lockCfg.FreeAttempts,
+		BaseDelay:       lockCfg.BaseDelay,
+		MaxDelay:        lockCfg.MaxDelay,
+		UserMaxFailures: lockCfg.UserMaxFailures,
+		IPMaxFailures:   lockCfg.IPMaxFailures,
+		LockoutDuration: lockCfg.LockoutDuration,
+		Window:          lockCfg.Window,
+	}
+	svc := authService.NewAuthService(userRepo, tokenRepo, actionRepo, mfaRepo, sessionRepo, attempts, lockout, mail, svcCfg.Profile_service_utl, authService.Config{
+		RefreshTTL:           svcCfg.RefreshTokenTTL,
+		ResetTTL:             svcCfg.PasswordResetTTL,
+		VerifyTTL:            svcCfg.EmailVerifyTTL,
+		VerifyResendInterval: svcCfg.EmailVerifyResend,
+		RequireVerifiedLogin: svcCfg.RequireVerifiedLogin,
+		AppURL:               svcCfg.AppURL,
+		APIURL:               svcCfg.PublicAPIURL,
+		FeedURL:              svcCfg.FeedServiceURL,
+		ServiceToken:         svcCfg.AuthServiceAuthToken,
+	})
 	handler := httpHandler.NewAuthHandler(svc)
-	handler.RegisterRoutes(router, svcCfg.ProfileServiceAuthToken)
+	handler.RegisterRoutes(router, svcCfg.ProfileServiceAuthToken, svcCfg.GatewayServiceAuthToken, svcCfg.AdminServiceAuthToken)
 
 	// Start HTTP server
 	srv := &http.Server{
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,18 +20,103 @@ type DBConfig struct {
 
 type ServiceConfig struct {
 	Profile_service_utl     string
-	Jwt_secret              string
+	JwtKeysDir              string
+	JwtActiveKeyID          string
 	ProfileServiceAuthToken string
+	AccessTokenTTL          time.Duration
+	RefreshTokenTTL         time.Duration
+	PasswordResetTTL        time.Duration
+	EmailVerifyTTL          time.Duration
+	EmailVerifyResend       time.Duration
+	RequireVerifiedLogin    bool
+	AppURL                  string
+	PublicAPIURL            string
+	GatewayServiceAuthToken string
+	FeedServiceURL          string
+	AuthServiceAuthToken    string
+	AdminServiceAuthToken   string
+	TrustedProxies          []string
+}
+
+// LockoutConfig tunes brute-force protection of /auth/login.
+// Store is "postgres" (default) or "memory" (single instance only).
+type LockoutConfig struct {
+	Store           string
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	UserMaxFailures int
+	IPMaxFailures   int
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+// MailConfig selects and configures the mailer.
+// Driver is "smtp", "file" (JSON lines outbox) or "log" (default).
+type MailConfig struct {
+	Driver     string
+	From       string
+	SMTPHost   string
+	SMTPPort   string
+	SMTPUser   string
+	SMTPPass   string
+	OutboxPath string
 }
 
 func LoadServiceConfig() *ServiceConfig {
 	return &ServiceConfig{
 		Profile_service_utl:     os.Getenv("PROFILE_SERVICE_URL"),
-		Jwt_secret:              os.Getenv("JWT_SECRET"),
+		JwtKeysDir:              os.Getenv("JWT_KEYS_DIR"),
+		JwtActiveKeyID:          os.Getenv("JWT_ACTIVE_KID"),
 		ProfileServiceAuthToken: os.Getenv("PROFILE_SERVICE_AUTH_TOKEN"),
+		AccessTokenTTL:          getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:         getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		PasswordResetTTL:        getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+		EmailVerifyTTL:          getEnvAsDuration("EMAIL_VERIFY_TTL", 48*time.Hour),
+		EmailVerifyResend:       getEnvAsDuration("EMAIL_VERIFY_RESEND_INTERVAL", time.Minute),
+		RequireVerifiedLogin:    getEnvAsBool("REQUIRE_VERIFIED_EMAIL_FOR_LOGIN", false),
+		AppURL:                  os.Getenv("APP_URL"),
+		PublicAPIURL:            os.Getenv("PUBLIC_API_URL"),
+		GatewayServiceAuthToken: os.Getenv("GATEWAY_SERVICE_AUTH_TOKEN"),
+		FeedServiceURL:          os.Getenv("FEED_SERVICE_URL"),
+		AuthServiceAuthToken:    os.Getenv("AUTH_SERVICE_AUTH_TOKEN"),
+		AdminServiceAuthToken:   os.Getenv("ADMIN_SERVICE_AUTH_TOKEN"),
+		TrustedProxies:          getEnvAsList("TRUSTED_PROXIES", []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.1", "::1"}),
 	}
 }
 
+func LoadLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		Store:           getEnv("LOGIN_ATTEMPT_STORE", "postgres"),
+		FreeAttempts:    getEnvAsInt("LOGIN_FREE_ATTEMPTS", 3),
+		BaseDelay:       getEnvAsDuration("LOGIN_BACKOFF_BASE", time.Second),
+		MaxDelay:        getEnvAsDuration("LOGIN_BACKOFF_MAX", 15*time.Minute),
+		UserMaxFailures: getEnvAsInt("LOGIN_USER_MAX_FAILURES", 10),
+		IPMaxFailures:   getEnvAsInt("LOGIN_IP_MAX_FAILURES", 50),
+		LockoutDuration: getEnvAsDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		Window:          getEnvAsDuration("LOGIN_FAILURE_WINDOW", 24*time.Hour),
+	}
+}
+
+func LoadMailConfig() MailConfig {
+	return MailConfig{
+		Driver:     getEnv("MAIL_DRIVER", "log"),
+		From:       getEnv("MAIL_FROM", "HealthBuddy <no-reply@healthbuddy.app>"),
+		SMTPHost:   os.Getenv("SMTP_HOST"),
+		SMTPPort:   getEnv("SMTP_PORT", "587"),
+		SMTPUser:   os.Getenv("SMTP_USER"),
+		SMTPPass:   os.Getenv("SMTP_PASSWORD"),
+		OutboxPath: getEnv("MAIL_OUTBOX_PATH", "outbox.jsonl"),
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
 func LoadDBConfig() DBConfig {
 	return DBConfig{
 		Host:            os.Getenv("DB_HOST"),
@@ -71,3 +157,33 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 
 	return value
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsList splits a comma separated variable, dropping empty entries
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, v := range strings.Split(valueStr, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -24,18 +24,303 @@ type LoginCredentials struct {
 type User struct {
 	ID        string    `gorm:"type:uuid;primaryKey"`
 	Username  string    `gorm:"size:30;uniqueIndex;not null"`
-	Password  string    `gorm:"not null"`
+	Password  string    `gorm:"not null" json:"-"`
 	Email     string    `gorm:"size:100;uniqueIndex;not null"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	EmailVerified   bool `gorm:"not null;default:false"`
+	EmailVerifiedAt *time.Time
+
+	Role string `gorm:"size:20;not null;default:user"`
+}
+
+// Roles, each one includes the permissions of those below it
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleOrder = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	for _, r := range roleOrder {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ImpliedRoles expands role into every role it grants, so that
+// downstream services only need membership checks
+func ImpliedRoles(role string) []string {
+	for i, r := range roleOrder {
+		if r == role {
+			return append([]string(nil), roleOrder[:i+1]...)
+		}
+	}
+	return []string{RoleUser}
+}
+
+// UserResponse is what any authenticated user may see about another user
+type UserResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// AccountResponse is the full account record, only shown to its owner
+// and to internal callers
+type AccountResponse struct {
+	ID              string     `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Role            string     `json:"role"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func NewUserResponse(u *User) UserResponse {
+	return UserResponse{ID: u.ID, Username: u.Username}
+}
+
+func NewAccountResponse(u *User) AccountResponse {
+	return AccountResponse{
+		ID:              u.ID,
+		Username:        u.Username,
+		Email:           u.Email,
+		EmailVerified:   u.EmailVerified,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		Role:            u.Role,
+		CreatedAt:       u.CreatedAt,
+	}
+}
+
+// RefreshToken is a persisted, single-use refresh token.
+// Tokens issued from the same login share a FamilyID so that
+// reuse of a rotated token can revoke the whole chain.
+type RefreshToken struct {
+	ID         string     `gorm:"type:uuid;primaryKey"`
+	UserID     string     `gorm:"type:uuid;index;not null"`
+	FamilyID   string     `gorm:"type:uuid;index;not null"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time `gorm:"index"`
+	ReplacedBy string     `gorm:"type:uuid"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+}
+
+// Session is one signed-in device. Its ID is the refresh token FamilyID
+// and is carried in access tokens as the "sid" claim.
+type Session struct {
+	ID         string     `gorm:"type:uuid;primaryKey"`
+	UserID     string     `gorm:"type:uuid;index;not null"`
+	UserAgent  string     `gorm:"size:255"`
+	IP         string     `gorm:"size:64"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	LastSeenAt time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time `gorm:"index"`
+}
+
+// SessionResponse is a session as listed to its owner
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// SessionStatus answers the gateway's "is this session still valid" check
+type SessionStatus struct {
+	ID            string   `json:"id"`
+	UserID        string   `json:"user_id"`
+	EmailVerified bool     `json:"email_verified"`
+	Roles         []string `json:"roles"`
 }
 
-// TokenResponse is returned after successful authentication
+func (s *Session) ToResponse(currentID string) SessionResponse {
+	return SessionResponse{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		Current:    s.ID == currentID,
+	}
+}
+
+// ClientInfo describes where a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// LoginAttempt counts recent failed logins for a key such as
+// "user:<name>" or "ip:<addr>".
+type LoginAttempt struct {
+	Key           string    `gorm:"size:100;primaryKey"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+}
+
+// UnlockRequest clears failed-login state for a username and/or IP
+type UnlockRequest struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
+
+// Purposes of one-time action tokens
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeVerifyEmail   = "verify_email"
+	PurposeMFAChallenge  = "mfa_challenge"
+	PurposeChangeEmail   = "change_email"
+)
+
+// ActionToken is a hashed, single-use, expiring token mailed to the
+// user to confirm an action such as a password reset.
+type ActionToken struct {
+	ID        string    `gorm:"type:uuid;primaryKey"`
+	UserID    string    `gorm:"type:uuid;index;not null"`
+	Purpose   string    `gorm:"size:30;not null"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+	Payload   string    `gorm:"size:100"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ForgotPasswordRequest starts the password reset flow
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ResendVerificationRequest asks for a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ResetPasswordRequest sets a new password with a mailed reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ChangePasswordRequest replaces the password of the signed-in user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangeEmailRequest starts moving the account to a new address;
+// it only takes effect once the link mailed there is opened
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// SetRoleRequest is used by admins to promote or demote a user
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (r *SetRoleRequest) Validate() error {
+	if !ValidRole(r.Role) {
+		return errors.New("role must be one of user, moderator, admin")
+	}
+	return nil
+}
+
+// ChangeUsernameRequest renames the signed-in user
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// TOTPCredential is a user's authenticator secret; it only counts as a
+// second factor once Enabled is set by confirming a code.
+type TOTPCredential struct {
+	UserID       string `gorm:"type:uuid;primaryKey"`
+	Secret       string `gorm:"size:64;not null"`
+	Enabled      bool   `gorm:"not null;default:false"`
+	LastUsedStep int64  `gorm:"not null;default:0"`
+	EnabledAt    *time.Time
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// RecoveryCode is a hashed one-time backup code for a lost authenticator
+type RecoveryCode struct {
+	ID        string `gorm:"type:uuid;primaryKey"`
+	UserID    string `gorm:"type:uuid;index;not null"`
+	CodeHash  string `gorm:"size:64;uniqueIndex;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TOTPCodeRequest carries a code from the authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFALoginRequest completes a login that returned mfa_required.
+// Code is either a TOTP code or a recovery code.
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TOTPEnrollResponse is shown once while enrolling an authenticator
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse is shown once after enabling two-factor auth
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// RefreshRequest carries a refresh token for /auth/refresh and /auth/logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse is returned after successful authentication.
+// Tokens are omitted when login requires a verified email first or
+// a second factor, in which case MFAToken must be sent to /auth/login/mfa.
 type TokenResponse struct {
-	AccessToken string `json:"access_token"`
+	AccessToken          string `json:"token,omitempty"`
+	RefreshToken         string `json:"refresh_token,omitempty"`
+	ExpiresIn            int64  `json:"expires_in,omitempty"`
+	VerificationRequired bool   `json:"verification_required,omitempty"`
+	MFARequired          bool   `json:"mfa_required,omitempty"`
+	MFAToken             string `json:"mfa_token,omitempty"`
+}
+
+// JWK is the public part of a token signing key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is served on /auth/.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
 }
 
 var (
-	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	emailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-\.]+$`)
 )
 
 // Validate Credentials
@@ -62,6 +347,45 @@ func (c *LoginCredentials) Validate() error {
 	return nil
 }
 
+func (r *UnlockRequest) Validate() error {
+	if r.Username == "" && r.IP == "" {
+		return errors.New("username or ip is required")
+	}
+	return nil
+}
+
+func (r *ChangePasswordRequest) Validate() error {
+	if len(r.NewPassword) < 8 {
+		return errors.New("password must be at least 8 characters")
+	}
+	return nil
+}
+
+func (r *ChangeEmailRequest) Validate() error {
+	if !emailRegex.MatchString(r.Email) {
+		return errors.New("invalid email format")
+	}
+	return nil
+}
+
+// Validate uses the profile service's name rules, the username is copied there
+func (r *ChangeUsernameRequest) Validate() error {
+	if len(r.Username) < 3 || len(r.Username) > 30 {
+		return errors.New("username must be between 3 and 30 characters")
+	}
+	if !usernameRegex.MatchString(r.Username) {
+		return errors.New("username may only contain letters, digits, '_', '-' and '.'")
+	}
+	return nil
+}
+
+func (r *ResetPasswordRequest) Validate() error {
+	if len(r.Password) < 8 {
+		return errors.New("password must be at least 8 characters")
+	}
+	return nil
+}
+
 // Validate User
 func (u *User) Validate() error {
 	if u.ID == "" {
@@ -1,40 +1,215 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"auth_service/domain"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var secret []byte
+// Claims carried by access tokens
+type Claims struct {
+	jwt.RegisteredClaims
+	EmailVerified bool     `json:"email_verified"`
+	SessionID     string   `json:"sid"`
+	Roles         []string `json:"roles"`
+}
+
+// key is a verification key; signer is nil for retired keys that
+// are only published so already issued tokens keep validating.
+type key struct {
+	kid    string
+	method jwt.SigningMethod
+	signer crypto.Signer
+	public crypto.PublicKey
+}
+
+var (
+	keys      = map[string]*key{}
+	active    *key
+	accessTTL = 15 * time.Minute
+)
+
+// Init loads signing keys from keysDir. Every *.pem file is one key and
+// its file name (without .pem / .pub.pem) is the key ID. Private keys
+// (PKCS#8 RSA/Ed25519 or PKCS#1 RSA) can sign; public keys (PKIX) are
+// only published in the JWKS. activeKID selects the signing key and may
+// be empty when the directory holds exactly one private key. With no
+// keysDir an ephemeral Ed25519 key is generated.
+func Init(keysDir, activeKID string, ttl time.Duration) error {
+	if ttl > 0 {
+		accessTTL = ttl
+	}
+	keys = map[string]*key{}
+	active = nil
+
+	if keysDir == "" {
+		return initEphemeral()
+	}
+
+	files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		return fmt.Errorf("list keys: %w", err)
+	}
+	var signers []string
+	for _, f := range files {
+		k, err := loadKey(f)
+		if err != nil {
+			return fmt.Errorf("load key %s: %w", f, err)
+		}
+		if _, dup := keys[k.kid]; dup {
+			return fmt.Errorf("duplicate key id %q", k.kid)
+		}
+		keys[k.kid] = k
+		if k.signer != nil {
+			signers = append(signers, k.kid)
+		}
+	}
+	if len(keys) == 0 {
+		return initEphemeral()
+	}
 
-// Init
-func Init(key string) {
-	secret = []byte(key)
+	switch {
+	case activeKID != "":
+		k, ok := keys[activeKID]
+		if !ok || k.signer == nil {
+			return fmt.Errorf("active key %q has no private key in %s", activeKID, keysDir)
+		}
+		active = k
+	case len(signers) == 1:
+		active = keys[signers[0]]
+	default:
+		return fmt.Errorf("found %d private keys in %s, set the active key id", len(signers), keysDir)
+	}
+	log.Printf("jwt: signing with key %q (%s), %d key(s) published", active.kid, active.method.Alg(), len(keys))
+	return nil
 }
 
-// GenerateToken
-func GenerateToken(userID string) (string, error) {
-	claims := &jwt.RegisteredClaims{
-		Subject:   userID,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+func initEphemeral() error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generate key: %w", err)
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("generate key id: %w", err)
+	}
+	active = &key{
+		kid:    "ephemeral-" + hex.EncodeToString(buf),
+		method: jwt.SigningMethodEdDSA,
+		signer: priv,
+		public: pub,
+	}
+	keys[active.kid] = active
+	log.Printf("jwt: no signing keys configured, using ephemeral key %q; tokens will not survive a restart", active.kid)
+	return nil
+}
+
+func loadKey(path string) (*key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	kid := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".pem"), ".pub")
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &key{kid: kid, method: jwt.SigningMethodRS256, signer: k, public: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &key{kid: kid, method: jwt.SigningMethodEdDSA, signer: k, public: k.Public()}, nil
+	case *rsa.PublicKey:
+		return &key{kid: kid, method: jwt.SigningMethodRS256, public: k}, nil
+	case ed25519.PublicKey:
+		return &key{kid: kid, method: jwt.SigningMethodEdDSA, public: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// AccessTTL returns the lifetime of issued access tokens
+func AccessTTL() time.Duration {
+	return accessTTL
+}
+
+// GenerateToken signs an access token for user bound to sessionID
+func GenerateToken(user *domain.User, sessionID string) (string, error) {
+	if active == nil {
+		return "", errors.New("jwt: no signing key")
+	}
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		EmailVerified: user.EmailVerified,
+		SessionID:     sessionID,
+		Roles:         domain.ImpliedRoles(user.Role),
+	}
+	token := jwt.NewWithClaims(active.method, claims)
+	token.Header["kid"] = active.kid
+	return token.SignedString(active.signer)
 }
 
 // ValidateToken
-func ValidateToken(tkn string) (*jwt.RegisteredClaims, error) {
-	token, err := jwt.ParseWithClaims(tkn, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
+func ValidateToken(tkn string) (*Claims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}))
+	token, err := parser.ParseWithClaims(tkn, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, errors.New("algorithm does not match key")
+		}
+		return k.public, nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
 		return nil, errors.New("invalid token")
 	}
@@ -45,3 +220,34 @@ func ValidateToken(tkn string) (*jwt.RegisteredClaims, error) {
 
 	return claims, nil
 }
+
+// JWKS returns the public part of every loaded key, active key first
+func JWKS() domain.JWKSet {
+	set := domain.JWKSet{Keys: make([]domain.JWK, 0, len(keys))}
+	kids := make([]string, 0, len(keys))
+	for kid := range keys {
+		kids = append(kids, kid)
+	}
+	sort.Slice(kids, func(i, j int) bool {
+		if active != nil && (kids[i] == active.kid) != (kids[j] == active.kid) {
+			return kids[i] == active.kid
+		}
+		return kids[i] < kids[j]
+	})
+	for _, kid := range kids {
+		k := keys[kid]
+		jwk := domain.JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type outboxEntry struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+type outboxMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewOutboxMailer appends every message as one JSON line to w.
+// Meant for local development and tests, nothing is delivered.
+func NewOutboxMailer(w io.Writer) Mailer {
+	return &outboxMailer{w: w}
+}
+
+// NewFileMailer is an outbox mailer appending to the file at path
+func NewFileMailer(path string) (Mailer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open outbox: %w", err)
+	}
+	return NewOutboxMailer(f), nil
+}
+
+// NewLogMailer is an outbox mailer writing to the standard logger
+func NewLogMailer() Mailer {
+	return NewOutboxMailer(log.Writer())
+}
+
+func (m *outboxMailer) Send(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(outboxEntry{
+		To:      msg.To,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+		SentAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = m.w.Write(append(line, '\n'))
+	return err
+}
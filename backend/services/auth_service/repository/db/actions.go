@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+
+	"gorm.io/gorm"
+)
+
+type actionTokenRepo struct {
+	db *gorm.DB
+}
+
+// NewActionTokenRepo -> ActionTokenRepo
+func NewActionTokenRepo(db *gorm.DB) repository.ActionTokenRepo {
+	return &actionTokenRepo{db: db}
+}
+
+func (r *actionTokenRepo) Create(ctx context.Context, t *domain.ActionToken) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *actionTokenRepo) FindByHash(ctx context.Context, purpose, hash string) (*domain.ActionToken, error) {
+	var t domain.ActionToken
+	err := r.db.WithContext(ctx).
+		Where("purpose = ? AND token_hash = ?", purpose, hash).
+		First(&t).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *actionTokenRepo) Consume(ctx context.Context, id string, at time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.ActionToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrTokenRevoked
+	}
+	return nil
+}
+
+func (r *actionTokenRepo) LatestCreatedAt(ctx context.Context, userID, purpose string) (time.Time, error) {
+	var t domain.ActionToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND purpose = ?", userID, purpose).
+		Order("created_at DESC").
+		First(&t).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, repository.ErrNotFound
+		}
+		return time.Time{}, err
+	}
+	return t.CreatedAt, nil
+}
+
+func (r *actionTokenRepo) DeleteUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&domain.ActionToken{}).
+		Error
+}
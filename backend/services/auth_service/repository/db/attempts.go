@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+
+	"gorm.io/gorm"
+)
+
+type loginAttemptStore struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptStore -> LoginAttemptStore backed by Postgres, shared by all replicas
+func NewLoginAttemptStore(db *gorm.DB) repository.LoginAttemptStore {
+	return &loginAttemptStore{db: db}
+}
+
+func (s *loginAttemptStore) Get(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	var a domain.LoginAttempt
+	err := s.db.WithContext(ctx).
+		Where("key = ?", key).
+		First(&a).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// RecordFailure is a single upsert so concurrent failures are all counted
+func (s *loginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*domain.LoginAttempt, error) {
+	var a domain.LoginAttempt
+	err := s.db.WithContext(ctx).Raw(`
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < ? THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, locked_until`,
+		key, at, at.Add(-window),
+	).Scan(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *loginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.db.WithContext(ctx).
+		Model(&domain.LoginAttempt{}).
+		Where("key = ?", key).
+		Update("locked_until", until).
+		Error
+}
+
+func (s *loginAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).
+		Where("key = ?", key).
+		Delete(&domain.LoginAttempt{}).
+		Error
+}
@@ -83,6 +83,91 @@ func (r *userRepo) FindByUserID(ctx context.Context, userID string) (*domain.Use
 	return &u, nil
 }
 
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var u domain.User
+	err := r.db.WithContext(ctx).
+		Where("email = ?", email).
+		First(&u).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *userRepo) UpdatePassword(ctx context.Context, userID, hash string) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("password", hash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) MarkEmailVerified(ctx context.Context, userID string, at time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"email_verified": true, "email_verified_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) UpdateEmail(ctx context.Context, userID, email string, verifiedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"email": email, "email_verified": true, "email_verified_at": verifiedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) UpdateUsername(ctx context.Context, userID, username string) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("username", username)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) UpdateRole(ctx context.Context, userID, role string) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
 // InitDB
 func InitDB(dbConfig config.DBConfig) (*gorm.DB, error) {
 	// DSN
@@ -117,7 +202,15 @@ func InitDB(dbConfig config.DBConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("ping failed: %w", err)
 	}
 
-	if err := db.AutoMigrate(&domain.User{}); err != nil {
+	if err := db.AutoMigrate(
+		&domain.User{},
+		&domain.RefreshToken{},
+		&domain.ActionToken{},
+		&domain.TOTPCredential{},
+		&domain.RecoveryCode{},
+		&domain.LoginAttempt{},
+		&domain.Session{},
+	); err != nil {
 		return nil, fmt.Errorf("migration failed: %w", err)
 	}
 
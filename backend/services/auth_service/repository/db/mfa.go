@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mfaRepo struct {
+	db *gorm.DB
+}
+
+// NewMFARepo -> MFARepo
+func NewMFARepo(db *gorm.DB) repository.MFARepo {
+	return &mfaRepo{db: db}
+}
+
+func (r *mfaRepo) GetTOTP(ctx context.Context, userID string) (*domain.TOTPCredential, error) {
+	var c domain.TOTPCredential
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		First(&c).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SaveTOTP inserts or overwrites the user's credential
+func (r *mfaRepo) SaveTOTP(ctx context.Context, c *domain.TOTPCredential) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(c).
+		Error
+}
+
+func (r *mfaRepo) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.TOTPCredential{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrTokenRevoked
+	}
+	return nil
+}
+
+func (r *mfaRepo) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []domain.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *mfaRepo) ConsumeRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *mfaRepo) DeleteUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&domain.TOTPCredential{}).Error
+	})
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+
+	"gorm.io/gorm"
+)
+
+type sessionRepo struct {
+	db *gorm.DB
+}
+
+// NewSessionRepo -> SessionRepo
+func NewSessionRepo(db *gorm.DB) repository.SessionRepo {
+	return &sessionRepo{db: db}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, s *domain.Session) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r *sessionRepo) Get(ctx context.Context, id string) (*domain.Session, error) {
+	var s domain.Session
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&s).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepo) ListActive(ctx context.Context, userID string, activeSince time.Time) ([]domain.Session, error) {
+	var sessions []domain.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, activeSince).
+		Order("last_seen_at DESC").
+		Find(&sessions).
+		Error
+	return sessions, err
+}
+
+func (r *sessionRepo) Touch(ctx context.Context, id string, at time.Time, ip string) error {
+	updates := map[string]interface{}{"last_seen_at": at}
+	if ip != "" {
+		updates["ip"] = ip
+	}
+	return r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("id = ?", id).
+		Updates(updates).
+		Error
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepo) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).
+		Error
+}
+
+func (r *sessionRepo) DeleteUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&domain.Session{}).
+		Error
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepo struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepo -> RefreshTokenRepo
+func NewRefreshTokenRepo(db *gorm.DB) repository.RefreshTokenRepo {
+	return &refreshTokenRepo{db: db}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *refreshTokenRepo) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var t domain.RefreshToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", hash).
+		First(&t).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Revoke uses a conditional update so that two concurrent refreshes
+// with the same token cannot both succeed.
+func (r *refreshTokenRepo) Revoke(ctx context.Context, id, replacedBy string, at time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": at, "replaced_by": replacedBy})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrTokenRevoked
+	}
+	return nil
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).
+		Error
+}
+
+func (r *refreshTokenRepo) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).
+		Error
+}
+
+func (r *refreshTokenRepo) DeleteUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&domain.RefreshToken{}).
+		Error
+}
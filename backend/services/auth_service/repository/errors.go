@@ -4,6 +4,7 @@ import "errors"
 
 var (
 	ErrNotFound     = errors.New("record not found")
+	ErrTokenRevoked = errors.New("token already revoked")
 	ErrDBConnection = errors.New("DB connection failed")
 	ErrDBMigration  = errors.New("DB migrations failed")
 )
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"auth_service/domain"
 )
@@ -13,4 +14,71 @@ type UserRepo interface {
 	Delete(ctx context.Context, UserID string) error
 	FindByUserName(ctx context.Context, username string) (*domain.User, error)
 	FindByUserID(ctx context.Context, userID string) (*domain.User, error)
+	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	UpdatePassword(ctx context.Context, userID, hash string) error
+	MarkEmailVerified(ctx context.Context, userID string, at time.Time) error
+	// UpdateEmail sets a new, already verified address
+	UpdateEmail(ctx context.Context, userID, email string, verifiedAt time.Time) error
+	UpdateUsername(ctx context.Context, userID, username string) error
+	UpdateRole(ctx context.Context, userID, role string) error
+}
+
+// RefreshTokenRepo stores hashed refresh tokens grouped into families
+type RefreshTokenRepo interface {
+	Create(ctx context.Context, t *domain.RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error)
+	// Revoke marks a live token as used; returns ErrTokenRevoked if it was already revoked
+	Revoke(ctx context.Context, id, replacedBy string, at time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// SessionRepo stores signed-in devices
+type SessionRepo interface {
+	Create(ctx context.Context, s *domain.Session) error
+	// Get returns ErrNotFound for unknown sessions, revoked ones are returned as is
+	Get(ctx context.Context, id string) (*domain.Session, error)
+	// ListActive returns the user's unrevoked sessions seen since activeSince, most recent first
+	ListActive(ctx context.Context, userID string, activeSince time.Time) ([]domain.Session, error)
+	// Touch records activity; an empty ip keeps the stored one
+	Touch(ctx context.Context, id string, at time.Time, ip string) error
+	// Revoke ends a live session of userID; returns ErrNotFound if there is none
+	Revoke(ctx context.Context, userID, id string, at time.Time) error
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// ActionTokenRepo stores hashed one-time tokens such as password resets
+type ActionTokenRepo interface {
+	Create(ctx context.Context, t *domain.ActionToken) error
+	FindByHash(ctx context.Context, purpose, hash string) (*domain.ActionToken, error)
+	// Consume marks a token as used; returns ErrTokenRevoked if it was already used
+	Consume(ctx context.Context, id string, at time.Time) error
+	// LatestCreatedAt returns when the newest token of purpose was issued, or ErrNotFound
+	LatestCreatedAt(ctx context.Context, userID, purpose string) (time.Time, error)
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// MFARepo stores TOTP credentials and recovery codes
+type MFARepo interface {
+	GetTOTP(ctx context.Context, userID string) (*domain.TOTPCredential, error)
+	SaveTOTP(ctx context.Context, c *domain.TOTPCredential) error
+	// UseTOTPStep records step as used; returns ErrTokenRevoked if it (or a later one) was used already
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codes []domain.RecoveryCode) error
+	// ConsumeRecoveryCode burns an unused code; returns ErrNotFound if there is none
+	ConsumeRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// LoginAttemptStore tracks failed logins for brute-force protection
+type LoginAttemptStore interface {
+	// Get returns ErrNotFound when key has no recorded failures
+	Get(ctx context.Context, key string) (*domain.LoginAttempt, error)
+	// RecordFailure increments the counter, restarting it when the
+	// previous failure is older than window, and returns the new state
+	RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*domain.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
 }
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+)
+
+type loginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempt
+}
+
+// NewLoginAttemptStore -> LoginAttemptStore kept in process memory.
+// Suitable for a single instance and for tests; state is lost on restart.
+func NewLoginAttemptStore() repository.LoginAttemptStore {
+	return &loginAttemptStore{attempts: map[string]domain.LoginAttempt{}}
+}
+
+func (s *loginAttemptStore) Get(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[key]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &a, nil
+}
+
+func (s *loginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*domain.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[key]
+	if !ok || a.LastFailureAt.Before(at.Add(-window)) {
+		a = domain.LoginAttempt{Key: key, LockedUntil: a.LockedUntil}
+	}
+	a.Failures++
+	a.LastFailureAt = at
+	s.attempts[key] = a
+	return &a, nil
+}
+
+func (s *loginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.attempts[key]; ok {
+		a.LockedUntil = &until
+		s.attempts[key] = a
+	}
+	return nil
+}
+
+func (s *loginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
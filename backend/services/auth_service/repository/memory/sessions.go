@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+)
+
+type sessionRepo struct {
+	mu       sync.Mutex
+	sessions map[string]domain.Session
+}
+
+// NewSessionRepo -> SessionRepo kept in process memory, for tests
+func NewSessionRepo() repository.SessionRepo {
+	return &sessionRepo{sessions: map[string]domain.Session{}}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, s *domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.CreatedAt = time.Now()
+	r.sessions[s.ID] = *s
+	return nil
+}
+
+func (r *sessionRepo) Get(ctx context.Context, id string) (*domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *sessionRepo) ListActive(ctx context.Context, userID string, activeSince time.Time) ([]domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.Session
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.LastSeenAt.After(activeSince) {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeenAt.After(out[j].LastSeenAt) })
+	return out, nil
+}
+
+func (r *sessionRepo) Touch(ctx context.Context, id string, at time.Time, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		s.LastSeenAt = at
+		if ip != "" {
+			s.IP = ip
+		}
+		r.sessions[id] = s
+	}
+	return nil
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return repository.ErrNotFound
+	}
+	s.RevokedAt = &at
+	r.sessions[id] = s
+	return nil
+}
+
+func (r *sessionRepo) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &at
+			r.sessions[id] = s
+		}
+	}
+	return nil
+}
+
+func (r *sessionRepo) DeleteUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.sessions {
+		if s.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
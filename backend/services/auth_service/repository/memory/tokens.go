@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+)
+
+type refreshTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]domain.RefreshToken
+}
+
+// NewRefreshTokenRepo -> RefreshTokenRepo kept in process memory, for tests
+func NewRefreshTokenRepo() repository.RefreshTokenRepo {
+	return &refreshTokenRepo{tokens: map[string]domain.RefreshToken{}}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.CreatedAt = time.Now()
+	r.tokens[t.ID] = *t
+	return nil
+}
+
+func (r *refreshTokenRepo) FindByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *refreshTokenRepo) Revoke(ctx context.Context, id, replacedBy string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return repository.ErrTokenRevoked
+	}
+	t.RevokedAt, t.ReplacedBy = &at, replacedBy
+	r.tokens[id] = t
+	return nil
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	r.revokeWhere(func(t domain.RefreshToken) bool { return t.FamilyID == familyID }, at)
+	return nil
+}
+
+func (r *refreshTokenRepo) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	r.revokeWhere(func(t domain.RefreshToken) bool { return t.UserID == userID }, at)
+	return nil
+}
+
+func (r *refreshTokenRepo) revokeWhere(match func(domain.RefreshToken) bool, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if match(t) && t.RevokedAt == nil {
+			t.RevokedAt = &at
+			r.tokens[id] = t
+		}
+	}
+}
+
+func (r *refreshTokenRepo) DeleteUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if t.UserID == userID {
+			delete(r.tokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of RFC 6238 appendix B, "12345678901234567890"
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// rfcVectors are the SHA1 rows of RFC 6238 appendix B; the RFC lists 8
+// digits, a 6 digit code is their last six
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		want := v.code[len(v.code)-Digits:]
+		got, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("T=%d: code %s, want %s", v.unix, got, want)
+		}
+	}
+	// secrets are accepted in lower case too
+	if got, _ := Code(strings.ToLower(rfcSecret), Step(time.Unix(59, 0))); got != "287082" {
+		t.Errorf("lower-case secret: code %s", got)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	now := Step(at)
+	for offset := int64(-3); offset <= 3; offset++ {
+		code, err := Code(rfcSecret, now+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Validate(rfcSecret, code, at)
+		if want := offset >= -Skew && offset <= Skew; ok != want {
+			t.Errorf("code from %+d steps: accepted %v, want %v", offset, ok, want)
+			continue
+		}
+		// the matched step is what callers record against replays
+		if ok && step != now+offset {
+			t.Errorf("code from %+d steps matched step %d, want %d", offset, step, now+offset)
+		}
+	}
+	for _, code := range []string{"", "05047", "0050471", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, at); ok {
+			t.Errorf("%q accepted", code)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if a == b || len(a) != 32 {
+		t.Errorf("secrets %q and %q", a, b)
+	}
+	if _, err := Code(a, 1); err != nil {
+		t.Errorf("generated secret does not decode: %v", err)
+	}
+}
@@ -1,10 +1,40 @@
 package usecases
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
-	ErrUserNotFound       = errors.New("user not found")
-	ErrInvalidCredentials = errors.New("invalid username or password")
-	ErrEmailTaken         = errors.New("email/username already in use")
-	ErrProfileServiceDown = errors.New("cannot create profile")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	ErrEmailTaken          = errors.New("email/username already in use")
+	ErrProfileServiceDown  = errors.New("cannot create profile")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidToken        = errors.New("invalid or expired token")
+	ErrMailerDown          = errors.New("cannot send email")
+	ErrEmailNotVerified    = errors.New("email address is not verified")
+	ErrTooManyRequests     = errors.New("too many requests")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled      = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrWrongPassword       = errors.New("current password is incorrect")
+	ErrFeedServiceDown     = errors.New("cannot update feed")
+	ErrSessionNotFound     = errors.New("session not found")
 )
+
+// RetryAfterError is a throttling error telling when to try again.
+// errors.Is(err, ErrTooManyRequests) holds for it.
+type RetryAfterError struct {
+	Wait time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", ErrTooManyRequests, e.Wait.Round(time.Second))
+}
+
+func (e *RetryAfterError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
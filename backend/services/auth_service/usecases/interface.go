@@ -7,9 +7,34 @@ import (
 
 // AuthService
 type AuthService interface {
-	Register(ctx context.Context, creds domain.RegistrCredentials) (string, error)
-	Login(ctx context.Context, creds domain.LoginCredentials) (string, error)
+	Register(ctx context.Context, creds domain.RegistrCredentials, client domain.ClientInfo) (domain.TokenResponse, error)
+	Login(ctx context.Context, creds domain.LoginCredentials, client domain.ClientInfo) (domain.TokenResponse, error)
+	Refresh(ctx context.Context, refreshToken string, client domain.ClientInfo) (domain.TokenResponse, error)
+	Logout(ctx context.Context, refreshToken string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	LoginMFA(ctx context.Context, req domain.MFALoginRequest, client domain.ClientInfo) (domain.TokenResponse, error)
+	EnrollTOTP(ctx context.Context, userID string) (domain.TOTPEnrollResponse, error)
+	ConfirmTOTP(ctx context.Context, userID, code string) (domain.RecoveryCodesResponse, error)
+	DisableTOTP(ctx context.Context, userID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) (domain.RecoveryCodesResponse, error)
+	UnlockAccount(ctx context.Context, req domain.UnlockRequest) error
+	SetRole(ctx context.Context, userID, role string) (domain.AccountResponse, error)
+
+	// Account settings of the signed-in user
+	ChangePassword(ctx context.Context, userID string, req domain.ChangePasswordRequest, client domain.ClientInfo) (domain.TokenResponse, error)
+	ChangeEmail(ctx context.Context, userID string, req domain.ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	ChangeUsername(ctx context.Context, userID string, req domain.ChangeUsernameRequest) (domain.AccountResponse, error)
 	Health(ctx context.Context) error
 	DeleteUser(ctx context.Context, userID string) error
 	FindByID(ctx context.Context, userID string) (*domain.User, error)
+	JWKS(ctx context.Context) domain.JWKSet
+
+	// Sessions
+	ListSessions(ctx context.Context, userID, currentID string) ([]domain.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	CheckSession(ctx context.Context, sessionID string) (domain.SessionStatus, error)
 }
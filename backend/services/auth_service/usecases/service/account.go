@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"auth_service/domain"
+	"auth_service/mailer"
+	"auth_service/repository"
+	"auth_service/usecases"
+)
+
+// checkPassword loads the user and verifies the password they typed again
+func (s *authService) checkPassword(ctx context.Context, userID, password string) (*domain.User, error) {
+	user, err := s.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, usecases.ErrWrongPassword
+	}
+	return user, nil
+}
+
+// ChangePassword sets a new password, signs out every other session
+// and returns a fresh token pair for the caller
+func (s *authService) ChangePassword(ctx context.Context, userID string, req domain.ChangePasswordRequest, client domain.ClientInfo) (domain.TokenResponse, error) {
+	user, err := s.checkPassword(ctx, userID, req.CurrentPassword)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return domain.TokenResponse{}, fmt.Errorf("hash password: %w", err)
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, string(hashed)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.TokenResponse{}, usecases.ErrUserNotFound
+		}
+		return domain.TokenResponse{}, err
+	}
+	if err := s.endAllSessions(ctx, user.ID, time.Now()); err != nil {
+		return domain.TokenResponse{}, err
+	}
+	return s.startSession(ctx, user, client)
+}
+
+// ChangeEmail mails a confirmation link to the new address. The account
+// keeps its current email until the link is opened.
+func (s *authService) ChangeEmail(ctx context.Context, userID string, req domain.ChangeEmailRequest) error {
+	user, err := s.checkPassword(ctx, userID, req.Password)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Email, req.Email) {
+		return nil
+	}
+	if _, err := s.repo.FindByEmail(ctx, req.Email); err == nil {
+		return usecases.ErrEmailTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	raw, err := s.createActionToken(ctx, user.ID, domain.PurposeChangeEmail, req.Email, s.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/email/confirm?token=%s", s.cfg.APIURL, url.QueryEscape(raw))
+	msg := mailer.Message{
+		To:      req.Email,
+		Subject: "Confirm your new HealthBuddy email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nopen the link below within %s to use this address for your HealthBuddy account:\n\n%s\n\n"+
+				"If you did not ask for this, just ignore this email.\n",
+			user.Username, s.cfg.VerifyTTL, link,
+		),
+	}
+	if err := s.mail.Send(ctx, msg); err != nil {
+		return fmt.Errorf("%w: %v", usecases.ErrMailerDown, err)
+	}
+
+	// heads-up to the old address; the change is harmless until confirmed
+	notice := mailer.Message{
+		To:      user.Email,
+		Subject: "Your HealthBuddy email is being changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nsomeone asked to move your HealthBuddy account to %s.\n"+
+				"If it wasn't you, change your password right away.\n",
+			user.Username, req.Email,
+		),
+	}
+	if err := s.mail.Send(ctx, notice); err != nil {
+		log.Printf("ChangeEmail: notice to old address of user %s failed: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches the account to the address the token was mailed to
+func (s *authService) ConfirmEmailChange(ctx context.Context, token string) error {
+	t, err := s.consumeActionToken(ctx, domain.PurposeChangeEmail, token)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateEmail(ctx, t.UserID, t.Payload, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return usecases.ErrInvalidToken
+		}
+		if strings.Contains(err.Error(), "SQLSTATE 23505") {
+			return usecases.ErrEmailTaken
+		}
+		return err
+	}
+	return nil
+}
+
+// ChangeUsername renames the user and copies the name to profile_service
+// and to the author names stored in feed_service. If either cannot be
+// updated the rename is rolled back everywhere.
+func (s *authService) ChangeUsername(ctx context.Context, userID string, req domain.ChangeUsernameRequest) (domain.AccountResponse, error) {
+	user, err := s.FindByID(ctx, userID)
+	if err != nil {
+		return domain.AccountResponse{}, err
+	}
+	old := user.Username
+	if old == req.Username {
+		return domain.NewAccountResponse(user), nil
+	}
+
+	if err := s.repo.UpdateUsername(ctx, user.ID, req.Username); err != nil {
+		if strings.Contains(err.Error(), "SQLSTATE 23505") {
+			return domain.AccountResponse{}, usecases.ErrEmailTaken
+		}
+		return domain.AccountResponse{}, err
+	}
+	if err := s.propagateUsername(ctx, user.ID, req.Username); err != nil {
+		if rbErr := s.repo.UpdateUsername(ctx, user.ID, old); rbErr != nil {
+			log.Printf("ChangeUsername: rollback of user %s failed: %v", user.ID, rbErr)
+		}
+		if rbErr := s.propagateUsername(ctx, user.ID, old); rbErr != nil {
+			log.Printf("ChangeUsername: rollback of user %s in other services failed: %v", user.ID, rbErr)
+		}
+		return domain.AccountResponse{}, err
+	}
+
+	user.Username = req.Username
+	return domain.NewAccountResponse(user), nil
+}
+
+// propagateUsername updates the profile name and the feed author names
+func (s *authService) propagateUsername(ctx context.Context, userID, username string) error {
+	body, _ := json.Marshal(map[string]string{"name": username})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.profileSvcURL+"/profile", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new profile request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", userID)
+	if err := doExpect(req, http.StatusOK); err != nil {
+		log.Printf("propagateUsername: profile: %v", err)
+		return usecases.ErrProfileServiceDown
+	}
+
+	feedURL := fmt.Sprintf("%s/feed/internal/users/%s/name", s.cfg.FeedURL, url.PathEscape(userID))
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, feedURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new feed request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", s.cfg.ServiceToken)
+	if err := doExpect(req, http.StatusNoContent); err != nil {
+		log.Printf("propagateUsername: feed: %v", err)
+		return usecases.ErrFeedServiceDown
+	}
+	return nil
+}
+
+func doExpect(req *http.Request, status int) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != status {
+		return fmt.Errorf("%s %s returned %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"auth_service/domain"
+	"auth_service/repository"
+	"auth_service/usecases"
+)
+
+// SetRole changes a user's role; it applies to new tokens right away and
+// to the gateway's cached session checks within their TTL
+func (s *authService) SetRole(ctx context.Context, userID, role string) (domain.AccountResponse, error) {
+	if err := s.repo.UpdateRole(ctx, userID, role); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.AccountResponse{}, usecases.ErrUserNotFound
+		}
+		return domain.AccountResponse{}, err
+	}
+	user, err := s.FindByID(ctx, userID)
+	if err != nil {
+		return domain.AccountResponse{}, err
+	}
+	return domain.NewAccountResponse(user), nil
+}
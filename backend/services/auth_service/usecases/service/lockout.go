@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+	"auth_service/usecases"
+)
+
+// LockoutPolicy controls brute-force protection of Login.
+// After FreeAttempts failures every further attempt has to wait
+// BaseDelay, doubling per failure up to MaxDelay; at MaxFailures the
+// key is locked for LockoutDuration. Failures older than Window are
+// forgotten.
+type LockoutPolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	UserMaxFailures int
+	IPMaxFailures   int
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginKeys are the counters a login attempt is checked against
+func loginKeys(username string, client domain.ClientInfo) []string {
+	keys := []string{userKey(username)}
+	if client.IP != "" {
+		keys = append(keys, ipKey(client.IP))
+	}
+	return keys
+}
+
+// checkLockout returns a RetryAfterError if any key is locked or still backing off
+func (s *authService) checkLockout(ctx context.Context, keys []string) error {
+	now := s.now()
+	var wait time.Duration
+	for _, key := range keys {
+		a, err := s.attempts.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		if a.LockedUntil != nil && a.LockedUntil.After(now) {
+			wait = max(wait, a.LockedUntil.Sub(now))
+			continue
+		}
+		if d := s.lockout.backoff(a.Failures); d > 0 {
+			wait = max(wait, a.LastFailureAt.Add(d).Sub(now))
+		}
+	}
+	if wait > 0 {
+		return &usecases.RetryAfterError{Wait: wait}
+	}
+	return nil
+}
+
+// backoff is the delay required after the given number of failures
+func (p LockoutPolicy) backoff(failures int) time.Duration {
+	over := failures - p.FreeAttempts
+	if over <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < over && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
+
+// recordLoginFailure bumps every counter and locks those over their limit.
+// Storage errors are logged only: they must not turn into a login bypass
+// nor hide the real "invalid credentials" answer.
+func (s *authService) recordLoginFailure(ctx context.Context, username string, client domain.ClientInfo) {
+	now := s.now()
+	for _, key := range loginKeys(username, client) {
+		a, err := s.attempts.RecordFailure(ctx, key, now, s.lockout.Window)
+		if err != nil {
+			log.Printf("Login: record failure for %s: %v", key, err)
+			continue
+		}
+		limit := s.lockout.UserMaxFailures
+		if strings.HasPrefix(key, "ip:") {
+			limit = s.lockout.IPMaxFailures
+		}
+		if limit > 0 && a.Failures >= limit {
+			if err := s.attempts.Lock(ctx, key, now.Add(s.lockout.LockoutDuration)); err != nil {
+				log.Printf("Login: lock %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// resetLoginFailures forgives the user's failures after a complete login
+func (s *authService) resetLoginFailures(ctx context.Context, user *domain.User) {
+	if err := s.attempts.Reset(ctx, userKey(user.Username)); err != nil {
+		log.Printf("Login: reset failures for user %s: %v", user.ID, err)
+	}
+}
+
+// UnlockAccount clears failed-login state for a username and/or an IP
+func (s *authService) UnlockAccount(ctx context.Context, req domain.UnlockRequest) error {
+	if req.Username != "" {
+		if err := s.attempts.Reset(ctx, userKey(req.Username)); err != nil {
+			return err
+		}
+	}
+	if req.IP != "" {
+		if err := s.attempts.Reset(ctx, ipKey(req.IP)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
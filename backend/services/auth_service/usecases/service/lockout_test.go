@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"auth_service/domain"
+	"auth_service/repository"
+	"auth_service/repository/memory"
+	"auth_service/usecases"
+)
+
+var testPolicy = LockoutPolicy{
+	FreeAttempts:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        4 * time.Second,
+	UserMaxFailures: 7,
+	IPMaxFailures:   100,
+	LockoutDuration: 15 * time.Minute,
+	Window:          time.Hour,
+}
+
+func TestBackoff(t *testing.T) {
+	for failures, want := range []time.Duration{0, 0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if got := testPolicy.backoff(failures); got != want {
+			t.Errorf("backoff after %d failures = %s, want %s", failures, got, want)
+		}
+	}
+	if got := (LockoutPolicy{FreeAttempts: 3}).backoff(10); got != 0 {
+		t.Errorf("backoff without BaseDelay = %s", got)
+	}
+}
+
+// lockoutService signs in user "ann" (password "secret") against the
+// in-memory attempt store, with a clock the test moves by hand
+func lockoutService(t *testing.T) (*authService, *time.Time) {
+	t.Helper()
+	s := newTokenService(t, time.Hour)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.repo.(*fakeUsers).users["u1"].Password = string(hash)
+	s.mfa = newFakeMFA()
+	s.attempts = memory.NewLoginAttemptStore()
+	s.lockout = testPolicy
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+// login returns how long the caller is told to wait, or the error
+func login(s *authService, password string) (time.Duration, error) {
+	_, err := s.Login(context.Background(), domain.LoginCredentials{Username: "ann", Password: password}, domain.ClientInfo{IP: "10.0.0.1"})
+	var retry *usecases.RetryAfterError
+	if errors.As(err, &retry) {
+		return retry.Wait, nil
+	}
+	return 0, err
+}
+
+func TestLoginBackoffAndLockout(t *testing.T) {
+	s, now := lockoutService(t)
+
+	// the free attempts answer right away
+	for i := 0; i < testPolicy.FreeAttempts; i++ {
+		if _, err := login(s, "wrong"); !errors.Is(err, usecases.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	// then every failure doubles the wait, up to MaxDelay
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if _, err := login(s, "wrong"); !errors.Is(err, usecases.ErrInvalidCredentials) {
+			t.Fatal(err)
+		}
+		*now = now.Add(want / 2)
+		// even the right password has to wait
+		if wait, err := login(s, "secret"); err != nil || wait != want/2 {
+			t.Fatalf("during backoff: wait %s, err %v; want %s", wait, err, want/2)
+		}
+		*now = now.Add(want / 2)
+	}
+
+	// the UserMaxFailures-th failure locks the account
+	if _, err := login(s, "wrong"); !errors.Is(err, usecases.ErrInvalidCredentials) {
+		t.Fatal(err)
+	}
+	*now = now.Add(time.Minute)
+	if wait, err := login(s, "secret"); err != nil || wait != testPolicy.LockoutDuration-time.Minute {
+		t.Fatalf("locked: wait %s, err %v", wait, err)
+	}
+	*now = now.Add(testPolicy.LockoutDuration)
+	// past the lock the backoff of the last failure has long run out
+	if _, err := login(s, "secret"); err != nil {
+		t.Fatalf("after the lockout: %v", err)
+	}
+
+	// success forgets the account's failures
+	if _, err := s.attempts.Get(context.Background(), userKey("ann")); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("failures kept after a successful login: %v", err)
+	}
+	if _, err := login(s, "wrong"); !errors.Is(err, usecases.ErrInvalidCredentials) {
+		t.Errorf("first failure after a reset: %v", err)
+	}
+}
+
+func TestLoginFailuresExpire(t *testing.T) {
+	s, now := lockoutService(t)
+	for i := 0; i <= testPolicy.FreeAttempts; i++ {
+		login(s, "wrong")
+	}
+	if wait, _ := login(s, "wrong"); wait == 0 {
+		t.Fatal("no backoff after the free attempts")
+	}
+
+	// failures older than Window no longer count
+	*now = now.Add(testPolicy.Window + time.Second)
+	if _, err := login(s, "wrong"); !errors.Is(err, usecases.ErrInvalidCredentials) {
+		t.Fatal(err)
+	}
+	a, err := s.attempts.Get(context.Background(), userKey("ann"))
+	if err != nil || a.Failures != 1 {
+		t.Errorf("attempts after the window = %+v, %v", a, err)
+	}
+}
+
+func TestUnlockAccount(t *testing.T) {
+	s, _ := lockoutService(t)
+	for i := 0; i < testPolicy.UserMaxFailures; i++ {
+		login(s, "wrong")
+	}
+	if wait, _ := login(s, "secret"); wait == 0 {
+		t.Fatal("account not locked")
+	}
+	if err := s.UnlockAccount(context.Background(), domain.UnlockRequest{Username: "Ann", IP: "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := login(s, "secret"); err != nil {
+		t.Errorf("after unlock: %v", err)
+	}
+}
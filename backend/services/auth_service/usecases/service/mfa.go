@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+	"auth_service/totp"
+	"auth_service/usecases"
+)
+
+const (
+	totpIssuer        = "HealthBuddy"
+	recoveryCodeCount = 10
+	mfaChallengeTTL   = 5 * time.Minute
+)
+
+// EnrollTOTP creates a pending authenticator secret for the user.
+// It only protects the account once confirmed with ConfirmTOTP.
+func (s *authService) EnrollTOTP(ctx context.Context, userID string) (domain.TOTPEnrollResponse, error) {
+	user, err := s.FindByID(ctx, userID)
+	if err != nil {
+		return domain.TOTPEnrollResponse{}, err
+	}
+	existing, err := s.mfa.GetTOTP(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return domain.TOTPEnrollResponse{}, err
+	}
+	if existing != nil && existing.Enabled {
+		return domain.TOTPEnrollResponse{}, usecases.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TOTPEnrollResponse{}, err
+	}
+	cred := &domain.TOTPCredential{UserID: userID, Secret: secret}
+	if err := s.mfa.SaveTOTP(ctx, cred); err != nil {
+		return domain.TOTPEnrollResponse{}, err
+	}
+	return domain.TOTPEnrollResponse{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor auth and returns fresh recovery codes
+func (s *authService) ConfirmTOTP(ctx context.Context, userID, code string) (domain.RecoveryCodesResponse, error) {
+	cred, err := s.mfa.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.RecoveryCodesResponse{}, usecases.ErrMFANotEnrolled
+		}
+		return domain.RecoveryCodesResponse{}, err
+	}
+	if cred.Enabled {
+		return domain.RecoveryCodesResponse{}, usecases.ErrMFAAlreadyEnabled
+	}
+	now := time.Now()
+	step, ok := totp.Validate(cred.Secret, strings.TrimSpace(code), now)
+	if !ok {
+		return domain.RecoveryCodesResponse{}, usecases.ErrInvalidMFACode
+	}
+
+	cred.Enabled = true
+	cred.EnabledAt = &now
+	cred.LastUsedStep = step
+	if err := s.mfa.SaveTOTP(ctx, cred); err != nil {
+		return domain.RecoveryCodesResponse{}, err
+	}
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+// DisableTOTP turns two-factor auth off after checking a code
+func (s *authService) DisableTOTP(ctx context.Context, userID, code string) error {
+	if err := s.checkSecondFactor(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.mfa.DeleteUser(ctx, userID)
+}
+
+// RegenerateRecoveryCodes invalidates the old recovery codes
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (domain.RecoveryCodesResponse, error) {
+	if err := s.checkSecondFactor(ctx, userID, code); err != nil {
+		return domain.RecoveryCodesResponse{}, err
+	}
+	return s.newRecoveryCodes(ctx, userID)
+}
+
+// LoginMFA exchanges the challenge from Login plus a second factor for
+// tokens. The challenge is burned on the first attempt, a wrong code
+// means starting over with the password. Wrong codes count as failed
+// logins of the account, like wrong passwords.
+func (s *authService) LoginMFA(ctx context.Context, req domain.MFALoginRequest, client domain.ClientInfo) (domain.TokenResponse, error) {
+	t, err := s.consumeActionToken(ctx, domain.PurposeMFAChallenge, req.MFAToken)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	user, err := s.FindByID(ctx, t.UserID)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	if err := s.checkLockout(ctx, loginKeys(user.Username, client)); err != nil {
+		return domain.TokenResponse{}, err
+	}
+	if err := s.checkSecondFactor(ctx, user.ID, req.Code); err != nil {
+		if errors.Is(err, usecases.ErrInvalidMFACode) {
+			s.recordLoginFailure(ctx, user.Username, client)
+		}
+		return domain.TokenResponse{}, err
+	}
+	s.resetLoginFailures(ctx, user)
+	return s.startSession(ctx, user, client)
+}
+
+// mfaChallenge returns a challenge if the user has two-factor auth enabled
+func (s *authService) mfaChallenge(ctx context.Context, userID string) (string, bool, error) {
+	cred, err := s.mfa.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if !cred.Enabled {
+		return "", false, nil
+	}
+	raw, err := s.createActionToken(ctx, userID, domain.PurposeMFAChallenge, "", mfaChallengeTTL)
+	if err != nil {
+		return "", false, err
+	}
+	return raw, true, nil
+}
+
+// checkSecondFactor accepts a TOTP code or an unused recovery code
+func (s *authService) checkSecondFactor(ctx context.Context, userID, code string) error {
+	cred, err := s.mfa.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return usecases.ErrMFANotEnrolled
+		}
+		return err
+	}
+	if !cred.Enabled {
+		return usecases.ErrMFANotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return s.checkTOTP(ctx, cred, code)
+	}
+	err = s.mfa.ConsumeRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)), time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return usecases.ErrInvalidMFACode
+	}
+	return err
+}
+
+// checkTOTP validates a code and refuses to accept the same step twice
+func (s *authService) checkTOTP(ctx context.Context, cred *domain.TOTPCredential, code string) error {
+	step, ok := totp.Validate(cred.Secret, code, time.Now())
+	if !ok || step <= cred.LastUsedStep {
+		return usecases.ErrInvalidMFACode
+	}
+	if err := s.mfa.UseTOTPStep(ctx, cred.UserID, step); err != nil {
+		if errors.Is(err, repository.ErrTokenRevoked) {
+			return usecases.ErrInvalidMFACode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *authService) newRecoveryCodes(ctx context.Context, userID string) (domain.RecoveryCodesResponse, error) {
+	plain := make([]string, recoveryCodeCount)
+	codes := make([]domain.RecoveryCode, recoveryCodeCount)
+	for i := range plain {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return domain.RecoveryCodesResponse{}, err
+		}
+		plain[i] = code
+		codes[i] = domain.RecoveryCode{
+			ID:       domain.NewUUID(),
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		}
+	}
+	if err := s.mfa.ReplaceRecoveryCodes(ctx, userID, codes); err != nil {
+		return domain.RecoveryCodesResponse{}, err
+	}
+	return domain.RecoveryCodesResponse{RecoveryCodes: plain}, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCode returns a code like "k3q7m-x2ptz" (50 random bits)
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("read random: %w", err)
+	}
+	s := strings.ToLower(recoveryEncoding.EncodeToString(buf))[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+	"auth_service/totp"
+	"auth_service/usecases"
+)
+
+// fakeMFA behaves like the database: steps only move forward and a
+// recovery code burns once
+type fakeMFA struct {
+	repository.MFARepo
+	creds map[string]domain.TOTPCredential
+	codes []domain.RecoveryCode
+}
+
+func newFakeMFA() *fakeMFA {
+	return &fakeMFA{creds: map[string]domain.TOTPCredential{}}
+}
+
+func (r *fakeMFA) GetTOTP(ctx context.Context, userID string) (*domain.TOTPCredential, error) {
+	c, ok := r.creds[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *fakeMFA) SaveTOTP(ctx context.Context, c *domain.TOTPCredential) error {
+	r.creds[c.UserID] = *c
+	return nil
+}
+
+func (r *fakeMFA) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	c, ok := r.creds[userID]
+	if !ok || c.LastUsedStep >= step {
+		return repository.ErrTokenRevoked
+	}
+	c.LastUsedStep = step
+	r.creds[userID] = c
+	return nil
+}
+
+func (r *fakeMFA) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []domain.RecoveryCode) error {
+	kept := codes
+	for _, c := range r.codes {
+		if c.UserID != userID {
+			kept = append(kept, c)
+		}
+	}
+	r.codes = kept
+	return nil
+}
+
+func (r *fakeMFA) ConsumeRecoveryCode(ctx context.Context, userID, hash string, at time.Time) error {
+	for i, c := range r.codes {
+		if c.UserID == userID && c.CodeHash == hash && c.UsedAt == nil {
+			r.codes[i].UsedAt = &at
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// enrolledService returns a service whose user u1 confirmed an
+// authenticator, together with the secret and the recovery codes
+func enrolledService(t *testing.T) (*authService, string, []string) {
+	t.Helper()
+	users := newFakeUsers(&domain.User{ID: "u1", Username: "ann", Email: "ann@example.com"})
+	s := &authService{repo: users, mfa: newFakeMFA()}
+	ctx := context.Background()
+	enroll, err := s.EnrollTOTP(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// confirm with the code of the previous step, so the current one is still fresh
+	code, err := totp.Code(enroll.Secret, totp.Step(time.Now())-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery, err := s.ConfirmTOTP(ctx, "u1", code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovery.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("%d recovery codes", len(recovery.RecoveryCodes))
+	}
+	return s, enroll.Secret, recovery.RecoveryCodes
+}
+
+func TestTOTPReplay(t *testing.T) {
+	s, secret, _ := enrolledService(t)
+	ctx := context.Background()
+	// steps are taken relative to the confirmed one, so a test that
+	// straddles a period boundary stays inside the skew window
+	confirmed := s.mfa.(*fakeMFA).creds["u1"].LastUsedStep
+	code := func(step int64) string {
+		c, err := totp.Code(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// the code used to confirm is spent already
+	if err := s.checkSecondFactor(ctx, "u1", code(confirmed)); !errors.Is(err, usecases.ErrInvalidMFACode) {
+		t.Errorf("confirmation code reused: %v", err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", code(confirmed+1)); err != nil {
+		t.Fatalf("fresh code: %v", err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", code(confirmed+1)); !errors.Is(err, usecases.ErrInvalidMFACode) {
+		t.Errorf("replayed code: %v", err)
+	}
+	// once the next step is used, the window's earlier codes are dead too
+	if err := s.checkSecondFactor(ctx, "u1", code(confirmed+2)); err != nil {
+		t.Fatalf("next step: %v", err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", code(confirmed+1)); !errors.Is(err, usecases.ErrInvalidMFACode) {
+		t.Errorf("earlier step after a later one: %v", err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", code(confirmed+5)); !errors.Is(err, usecases.ErrInvalidMFACode) {
+		t.Errorf("code outside the skew window: %v", err)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	s, secret, codes := enrolledService(t)
+	ctx := context.Background()
+
+	// codes are accepted however the user types them, but only once
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	if err := s.checkSecondFactor(ctx, "u1", typed); err != nil {
+		t.Fatalf("recovery code %q: %v", typed, err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", codes[0]); !errors.Is(err, usecases.ErrInvalidMFACode) {
+		t.Errorf("used recovery code: %v", err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", "aaaaa-bbbbb"); !errors.Is(err, usecases.ErrInvalidMFACode) {
+		t.Errorf("unknown recovery code: %v", err)
+	}
+
+	// regenerating voids the old codes
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	fresh, err := s.RegenerateRecoveryCodes(ctx, "u1", code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", codes[1]); !errors.Is(err, usecases.ErrInvalidMFACode) {
+		t.Errorf("old recovery code after regenerating: %v", err)
+	}
+	if err := s.checkSecondFactor(ctx, "u1", fresh.RecoveryCodes[1]); err != nil {
+		t.Errorf("new recovery code: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"auth_service/domain"
+	"auth_service/mailer"
+	"auth_service/repository"
+	"auth_service/usecases"
+)
+
+// ForgotPassword mails a reset link if the email belongs to an account.
+// It reports success either way so it cannot be used to probe for users.
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	raw, err := s.createActionToken(ctx, user.ID, domain.PurposePasswordReset, "", s.cfg.ResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.cfg.AppURL, url.QueryEscape(raw))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your HealthBuddy password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nsomeone asked to reset the password of your HealthBuddy account.\n"+
+				"Open the link below within %s to choose a new one:\n\n%s\n\n"+
+				"If it wasn't you, just ignore this email.\n",
+			user.Username, s.cfg.ResetTTL, link,
+		),
+	}
+	// a mailer error is not reported either: a 502 only for registered
+	// emails would give them away
+	if err := s.mail.Send(ctx, msg); err != nil {
+		log.Printf("ForgotPassword: send mail to user %s failed: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password and signs the user out everywhere
+func (s *authService) ResetPassword(ctx context.Context, token, password string) error {
+	t, err := s.consumeActionToken(ctx, domain.PurposePasswordReset, token)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := s.repo.UpdatePassword(ctx, t.UserID, string(hashed)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return usecases.ErrInvalidToken
+		}
+		return err
+	}
+	return s.endAllSessions(ctx, t.UserID, time.Now())
+}
@@ -6,37 +6,80 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"auth_service/domain"
 	"auth_service/jwt"
+	"auth_service/mailer"
 	"auth_service/repository"
 	"auth_service/usecases"
 )
 
+// Config holds tunables of the auth use cases
+type Config struct {
+	RefreshTTL time.Duration
+	ResetTTL   time.Duration
+	VerifyTTL  time.Duration
+	// VerifyResendInterval throttles verification emails per user
+	VerifyResendInterval time.Duration
+	// RequireVerifiedLogin refuses tokens until the email is verified
+	RequireVerifiedLogin bool
+	// AppURL is the front-end base URL used in mailed links
+	AppURL string
+	// APIURL is the public gateway URL used in mailed API links
+	APIURL string
+	// FeedURL and ServiceToken are used to push username changes to feed_service
+	FeedURL      string
+	ServiceToken string
+}
+
 type authService struct {
 	repo          repository.UserRepo
+	tokens        repository.RefreshTokenRepo
+	actions       repository.ActionTokenRepo
+	mfa           repository.MFARepo
+	sessions      repository.SessionRepo
+	attempts      repository.LoginAttemptStore
+	lockout       LockoutPolicy
+	mail          mailer.Mailer
 	profileSvcURL string
+	cfg           Config
+	// now is the clock of the login lockout, replaced in tests
+	now func() time.Time
 }
 
-func NewAuthService(r repository.UserRepo, profileSvcURL string) usecases.AuthService {
+func NewAuthService(r repository.UserRepo, tokens repository.RefreshTokenRepo, actions repository.ActionTokenRepo, mfa repository.MFARepo, sessions repository.SessionRepo, attempts repository.LoginAttemptStore, lockout LockoutPolicy, m mailer.Mailer, profileSvcURL string, cfg Config) usecases.AuthService {
 	return &authService{
 		repo:          r,
+		tokens:        tokens,
+		actions:       actions,
+		mfa:           mfa,
+		sessions:      sessions,
+		attempts:      attempts,
+		lockout:       lockout,
+		mail:          m,
 		profileSvcURL: profileSvcURL,
+		cfg:           cfg,
+		now:           time.Now,
 	}
 }
 
-func (s *authService) Register(ctx context.Context, creds domain.RegistrCredentials) (string, error) {
+// dummyHash keeps unknown-username logins as slow as wrong-password ones
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("healthbuddy-timing-guard"), bcrypt.DefaultCost)
+
+func (s *authService) Register(ctx context.Context, creds domain.RegistrCredentials, client domain.ClientInfo) (domain.TokenResponse, error) {
 	if err := creds.Validate(); err != nil {
-		return "", err
+		return domain.TokenResponse{}, err
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", fmt.Errorf("hash password: %w", err)
+		return domain.TokenResponse{}, fmt.Errorf("hash password: %w", err)
 	}
 
 	user := &domain.User{
@@ -44,13 +87,14 @@ func (s *authService) Register(ctx context.Context, creds domain.RegistrCredenti
 		Username: creds.Username,
 		Password: string(hashed),
 		Email:    creds.Email,
+		Role:     domain.RoleUser,
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
 		if strings.Contains(err.Error(), "SQLSTATE 23505") {
-			return "", usecases.ErrEmailTaken
+			return domain.TokenResponse{}, usecases.ErrEmailTaken
 		}
-		return "", err
+		return domain.TokenResponse{}, err
 	}
 
 	userID := user.ID
@@ -65,7 +109,7 @@ func (s *authService) Register(ctx context.Context, creds domain.RegistrCredenti
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.profileSvcURL+"/profile", bytes.NewReader(body))
 	if err != nil {
 		_ = s.repo.Delete(ctx, userID)
-		return "", fmt.Errorf("new profile request: %w", err)
+		return domain.TokenResponse{}, fmt.Errorf("new profile request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-User-ID", userID)
@@ -73,31 +117,58 @@ func (s *authService) Register(ctx context.Context, creds domain.RegistrCredenti
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		_ = s.repo.Delete(ctx, userID)
-		return "", usecases.ErrProfileServiceDown
+		return domain.TokenResponse{}, usecases.ErrProfileServiceDown
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusCreated {
 		_ = s.repo.Delete(ctx, userID)
-		return "", usecases.ErrProfileServiceDown
+		return domain.TokenResponse{}, usecases.ErrProfileServiceDown
 	}
 
-	// 5) генерируем JWT
-	token, err := jwt.GenerateToken(userID)
-	if err != nil {
-		return "", fmt.Errorf("generate token: %w", err)
+	// 5) письмо для подтверждения email
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("Register: verification mail for user %s failed: %v", userID, err)
+	}
+	if s.cfg.RequireVerifiedLogin {
+		return domain.TokenResponse{VerificationRequired: true}, nil
 	}
-	return token, nil
+
+	// 6) генерируем JWT + refresh token
+	return s.startSession(ctx, user, client)
 }
 
-func (s *authService) Login(ctx context.Context, creds domain.LoginCredentials) (string, error) {
+func (s *authService) Login(ctx context.Context, creds domain.LoginCredentials, client domain.ClientInfo) (domain.TokenResponse, error) {
+	if err := s.checkLockout(ctx, loginKeys(creds.Username, client)); err != nil {
+		return domain.TokenResponse{}, err
+	}
+
 	user, err := s.repo.FindByUserName(ctx, creds.Username)
 	if err != nil {
-		return "", usecases.ErrInvalidCredentials
+		if !errors.Is(err, repository.ErrNotFound) {
+			return domain.TokenResponse{}, err
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(creds.Password))
+		s.recordLoginFailure(ctx, creds.Username, client)
+		return domain.TokenResponse{}, usecases.ErrInvalidCredentials
 	}
 	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(creds.Password)) != nil {
-		return "", usecases.ErrInvalidCredentials
+		s.recordLoginFailure(ctx, creds.Username, client)
+		return domain.TokenResponse{}, usecases.ErrInvalidCredentials
 	}
-	return jwt.GenerateToken(user.ID)
+	if s.cfg.RequireVerifiedLogin && !user.EmailVerified {
+		return domain.TokenResponse{}, usecases.ErrEmailNotVerified
+	}
+	// with two-factor auth the failures are only forgiven once LoginMFA
+	// accepts the code, so wrong codes keep counting against the account
+	challenge, required, err := s.mfaChallenge(ctx, user.ID)
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	if required {
+		return domain.TokenResponse{MFARequired: true, MFAToken: challenge}, nil
+	}
+	s.resetLoginFailures(ctx, user)
+	return s.startSession(ctx, user, client)
 }
 
 func (s *authService) Health(ctx context.Context) error {
@@ -112,7 +183,16 @@ func (s *authService) DeleteUser(ctx context.Context, userID string) error {
 		}
 		return err
 	}
-	return nil
+	if err := s.actions.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.mfa.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.sessions.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.tokens.DeleteUser(ctx, userID)
 }
 
 // FindByID
@@ -127,3 +207,8 @@ func (s *authService) FindByID(ctx context.Context, userID string) (*domain.User
 	}
 	return user, nil
 }
+
+// JWKS returns the public keys used to verify access tokens
+func (s *authService) JWKS(ctx context.Context) domain.JWKSet {
+	return jwt.JWKS()
+}
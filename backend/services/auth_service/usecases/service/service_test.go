@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/mailer"
+	"auth_service/repository"
+)
+
+type fakeUsers struct {
+	repository.UserRepo
+	users map[string]*domain.User
+}
+
+func newFakeUsers(users ...*domain.User) *fakeUsers {
+	r := &fakeUsers{users: map[string]*domain.User{}}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *fakeUsers) FindByUserID(ctx context.Context, userID string) (*domain.User, error) {
+	if u, ok := r.users[userID]; ok {
+		return u, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUsers) FindByUserName(ctx context.Context, username string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUsers) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+type fakeActions struct {
+	repository.ActionTokenRepo
+	tokens []domain.ActionToken
+}
+
+func (r *fakeActions) Create(ctx context.Context, t *domain.ActionToken) error {
+	t.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, *t)
+	return nil
+}
+
+func (r *fakeActions) FindByHash(ctx context.Context, purpose, hash string) (*domain.ActionToken, error) {
+	for i := range r.tokens {
+		if t := r.tokens[i]; t.Purpose == purpose && t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeActions) Consume(ctx context.Context, id string, at time.Time) error {
+	for i := range r.tokens {
+		if r.tokens[i].ID == id {
+			if r.tokens[i].UsedAt != nil {
+				return repository.ErrTokenRevoked
+			}
+			r.tokens[i].UsedAt = &at
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *fakeActions) LatestCreatedAt(ctx context.Context, userID, purpose string) (time.Time, error) {
+	var latest time.Time
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.Purpose == purpose && t.CreatedAt.After(latest) {
+			latest = t.CreatedAt
+		}
+	}
+	if latest.IsZero() {
+		return time.Time{}, repository.ErrNotFound
+	}
+	return latest, nil
+}
+
+// fakeMailer records what it was asked to send, failing with err if set
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+	err  error
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return m.err
+}
+
+var errMailer = errors.New("smtp: connection refused")
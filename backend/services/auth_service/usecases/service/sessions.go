@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/repository"
+	"auth_service/usecases"
+)
+
+// touchInterval limits last-seen writes from the gateway's session checks
+const touchInterval = time.Minute
+
+// startSession records a new signed-in device and issues its first token pair
+func (s *authService) startSession(ctx context.Context, user *domain.User, client domain.ClientInfo) (domain.TokenResponse, error) {
+	now := time.Now()
+	sess := &domain.Session{
+		ID:         domain.NewUUID(),
+		UserID:     user.ID,
+		UserAgent:  truncate(client.UserAgent, 255),
+		IP:         client.IP,
+		LastSeenAt: now,
+	}
+	if err := s.sessions.Create(ctx, sess); err != nil {
+		return domain.TokenResponse{}, err
+	}
+	return s.issueTokens(ctx, user, sess.ID, domain.NewUUID())
+}
+
+// endSession revokes a session together with its refresh token family
+func (s *authService) endSession(ctx context.Context, userID, sessionID string, at time.Time) error {
+	if err := s.tokens.RevokeFamily(ctx, sessionID, at); err != nil {
+		return err
+	}
+	if err := s.sessions.Revoke(ctx, userID, sessionID, at); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// endAllSessions signs the user out everywhere
+func (s *authService) endAllSessions(ctx context.Context, userID string, at time.Time) error {
+	if err := s.tokens.RevokeUser(ctx, userID, at); err != nil {
+		return err
+	}
+	return s.sessions.RevokeUser(ctx, userID, at)
+}
+
+// ListSessions returns the user's live sessions, flagging currentID
+func (s *authService) ListSessions(ctx context.Context, userID, currentID string) ([]domain.SessionResponse, error) {
+	sessions, err := s.sessions.ListActive(ctx, userID, time.Now().Add(-s.cfg.RefreshTTL))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		out = append(out, sessions[i].ToResponse(currentID))
+	}
+	return out, nil
+}
+
+// RevokeSession signs one of the user's devices out
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	now := time.Now()
+	if err := s.sessions.Revoke(ctx, userID, sessionID, now); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return usecases.ErrSessionNotFound
+		}
+		return err
+	}
+	return s.tokens.RevokeFamily(ctx, sessionID, now)
+}
+
+// CheckSession tells the gateway whether an access token's session is live
+func (s *authService) CheckSession(ctx context.Context, sessionID string) (domain.SessionStatus, error) {
+	sess, err := s.sessions.Get(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.SessionStatus{}, usecases.ErrSessionNotFound
+		}
+		return domain.SessionStatus{}, err
+	}
+	now := time.Now()
+	if sess.RevokedAt != nil || now.Sub(sess.LastSeenAt) > s.cfg.RefreshTTL {
+		return domain.SessionStatus{}, usecases.ErrSessionNotFound
+	}
+	user, err := s.FindByID(ctx, sess.UserID)
+	if err != nil {
+		if errors.Is(err, usecases.ErrUserNotFound) {
+			return domain.SessionStatus{}, usecases.ErrSessionNotFound
+		}
+		return domain.SessionStatus{}, err
+	}
+	if now.Sub(sess.LastSeenAt) > touchInterval {
+		if err := s.sessions.Touch(ctx, sess.ID, now, ""); err != nil {
+			log.Printf("CheckSession: touch %s: %v", sess.ID, err)
+		}
+	}
+	return domain.SessionStatus{
+		ID:            sess.ID,
+		UserID:        user.ID,
+		EmailVerified: user.EmailVerified,
+		Roles:         domain.ImpliedRoles(user.Role),
+	}, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/jwt"
+	"auth_service/repository"
+	"auth_service/usecases"
+)
+
+// newOpaqueToken returns a random URL-safe token and its storage hash
+func newOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("read random: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+	return raw, hashToken(raw), nil
+}
+
+// hashToken is what gets persisted; raw tokens never touch the DB
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens signs an access token and stores refresh token tokenID in
+// familyID, which is also the session the access token is bound to
+func (s *authService) issueTokens(ctx context.Context, user *domain.User, familyID, tokenID string) (domain.TokenResponse, error) {
+	access, err := jwt.GenerateToken(user, familyID)
+	if err != nil {
+		return domain.TokenResponse{}, fmt.Errorf("generate token: %w", err)
+	}
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return domain.TokenResponse{}, err
+	}
+	rt := &domain.RefreshToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTTL),
+	}
+	if err := s.tokens.Create(ctx, rt); err != nil {
+		return domain.TokenResponse{}, fmt.Errorf("store refresh token: %w", err)
+	}
+	return domain.TokenResponse{
+		AccessToken:  access,
+		RefreshToken: raw,
+		ExpiresIn:    int64(jwt.AccessTTL() / time.Second),
+	}, nil
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already
+// rotated or revoked is treated as theft and ends the whole session.
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client domain.ClientInfo) (domain.TokenResponse, error) {
+	rt, err := s.tokens.FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.TokenResponse{}, usecases.ErrInvalidRefreshToken
+		}
+		return domain.TokenResponse{}, err
+	}
+	now := time.Now()
+	if rt.RevokedAt != nil {
+		if err := s.endSession(ctx, rt.UserID, rt.FamilyID, now); err != nil {
+			return domain.TokenResponse{}, err
+		}
+		return domain.TokenResponse{}, usecases.ErrRefreshTokenReused
+	}
+	if now.After(rt.ExpiresAt) {
+		return domain.TokenResponse{}, usecases.ErrInvalidRefreshToken
+	}
+	user, err := s.FindByID(ctx, rt.UserID)
+	if err != nil {
+		if errors.Is(err, usecases.ErrUserNotFound) {
+			return domain.TokenResponse{}, usecases.ErrInvalidRefreshToken
+		}
+		return domain.TokenResponse{}, err
+	}
+	if s.cfg.RequireVerifiedLogin && !user.EmailVerified {
+		return domain.TokenResponse{}, usecases.ErrEmailNotVerified
+	}
+	if err := s.resumeSession(ctx, rt, client, now); err != nil {
+		return domain.TokenResponse{}, err
+	}
+
+	// reserve the old token first so a concurrent refresh loses the race
+	next := domain.NewUUID()
+	if err := s.tokens.Revoke(ctx, rt.ID, next, now); err != nil {
+		if errors.Is(err, repository.ErrTokenRevoked) {
+			_ = s.endSession(ctx, rt.UserID, rt.FamilyID, now)
+			return domain.TokenResponse{}, usecases.ErrRefreshTokenReused
+		}
+		return domain.TokenResponse{}, err
+	}
+	return s.issueTokens(ctx, user, rt.FamilyID, next)
+}
+
+// resumeSession checks the session of a refresh token family and records
+// the activity. Families issued before sessions existed get one here.
+func (s *authService) resumeSession(ctx context.Context, rt *domain.RefreshToken, client domain.ClientInfo, now time.Time) error {
+	sess, err := s.sessions.Get(ctx, rt.FamilyID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.sessions.Create(ctx, &domain.Session{
+			ID:         rt.FamilyID,
+			UserID:     rt.UserID,
+			UserAgent:  truncate(client.UserAgent, 255),
+			IP:         client.IP,
+			LastSeenAt: now,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if sess.RevokedAt != nil {
+		return usecases.ErrInvalidRefreshToken
+	}
+	return s.sessions.Touch(ctx, sess.ID, now, client.IP)
+}
+
+// Logout ends the session the given refresh token belongs to.
+// Unknown tokens are ignored so logout is idempotent.
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	rt, err := s.tokens.FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.endSession(ctx, rt.UserID, rt.FamilyID, time.Now())
+}
+
+// createActionToken stores a one-time token for purpose and returns the raw value to mail
+func (s *authService) createActionToken(ctx context.Context, userID, purpose, payload string, ttl time.Duration) (string, error) {
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	t := &domain.ActionToken{
+		ID:        domain.NewUUID(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		Payload:   payload,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.actions.Create(ctx, t); err != nil {
+		return "", fmt.Errorf("store action token: %w", err)
+	}
+	return raw, nil
+}
+
+// consumeActionToken validates and burns a one-time token.
+// Unknown, expired and already used tokens all map to ErrInvalidToken.
+func (s *authService) consumeActionToken(ctx context.Context, purpose, raw string) (*domain.ActionToken, error) {
+	t, err := s.actions.FindByHash(ctx, purpose, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, usecases.ErrInvalidToken
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if t.UsedAt != nil || now.After(t.ExpiresAt) {
+		return nil, usecases.ErrInvalidToken
+	}
+	if err := s.actions.Consume(ctx, t.ID, now); err != nil {
+		if errors.Is(err, repository.ErrTokenRevoked) {
+			return nil, usecases.ErrInvalidToken
+		}
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/jwt"
+	"auth_service/repository/memory"
+	"auth_service/usecases"
+)
+
+func newTokenService(t *testing.T, refreshTTL time.Duration) *authService {
+	t.Helper()
+	if err := jwt.Init("", "", 0); err != nil {
+		t.Fatal(err)
+	}
+	users := newFakeUsers(&domain.User{ID: "u1", Username: "ann", Email: "ann@example.com"})
+	return &authService{
+		repo:     users,
+		tokens:   memory.NewRefreshTokenRepo(),
+		sessions: memory.NewSessionRepo(),
+		cfg:      Config{RefreshTTL: refreshTTL},
+	}
+}
+
+// signIn starts a session for u1 and returns its first token pair and session ID
+func signIn(t *testing.T, s *authService) (domain.TokenResponse, string) {
+	t.Helper()
+	user, _ := s.repo.FindByUserID(context.Background(), "u1")
+	tokens, err := s.startSession(context.Background(), user, domain.ClientInfo{IP: "10.0.0.1", UserAgent: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := jwt.ValidateToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokens, claims.SessionID
+}
+
+func sessionRevoked(t *testing.T, s *authService, id string) bool {
+	t.Helper()
+	sess, err := s.sessions.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sess.RevokedAt != nil
+}
+
+func TestRefreshRotates(t *testing.T) {
+	s := newTokenService(t, time.Hour)
+	ctx := context.Background()
+	first, sid := signIn(t, s)
+
+	second, err := s.Refresh(ctx, first.RefreshToken, domain.ClientInfo{IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh token was not rotated: %q", second.RefreshToken)
+	}
+	claims, err := jwt.ValidateToken(second.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SessionID != sid {
+		t.Errorf("rotated access token is bound to session %s, want %s", claims.SessionID, sid)
+	}
+	if sess, _ := s.sessions.Get(ctx, sid); sess.IP != "10.0.0.2" || sess.RevokedAt != nil {
+		t.Errorf("session after refresh = %+v", sess)
+	}
+	// the new token rotates in turn
+	if _, err := s.Refresh(ctx, second.RefreshToken, domain.ClientInfo{}); err != nil {
+		t.Errorf("second rotation: %v", err)
+	}
+	if _, err := s.Refresh(ctx, "unknown", domain.ClientInfo{}); !errors.Is(err, usecases.ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s := newTokenService(t, time.Hour)
+	ctx := context.Background()
+	first, sid := signIn(t, s)
+	other, otherSID := signIn(t, s)
+
+	second, err := s.Refresh(ctx, first.RefreshToken, domain.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// replaying the rotated token looks like theft: the whole session ends
+	if _, err := s.Refresh(ctx, first.RefreshToken, domain.ClientInfo{}); !errors.Is(err, usecases.ErrRefreshTokenReused) {
+		t.Fatalf("reused token: %v", err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken, domain.ClientInfo{}); err == nil {
+		t.Error("the latest token of the family still works after reuse")
+	}
+	if !sessionRevoked(t, s, sid) {
+		t.Error("session survived the reuse")
+	}
+
+	// other devices of the user are not affected
+	if sessionRevoked(t, s, otherSID) {
+		t.Error("another session was revoked")
+	}
+	if _, err := s.Refresh(ctx, other.RefreshToken, domain.ClientInfo{}); err != nil {
+		t.Errorf("other session: %v", err)
+	}
+}
+
+func TestRefreshExpired(t *testing.T) {
+	s := newTokenService(t, -time.Minute)
+	first, _ := signIn(t, s)
+	if _, err := s.Refresh(context.Background(), first.RefreshToken, domain.ClientInfo{}); !errors.Is(err, usecases.ErrInvalidRefreshToken) {
+		t.Errorf("expired token: %v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s := newTokenService(t, time.Hour)
+	ctx := context.Background()
+	first, sid := signIn(t, s)
+	second, err := s.Refresh(ctx, first.RefreshToken, domain.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Logout(ctx, second.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if !sessionRevoked(t, s, sid) {
+		t.Error("session still live after logout")
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken, domain.ClientInfo{}); err == nil {
+		t.Error("token still refreshes after logout")
+	}
+	// logout is idempotent and ignores unknown tokens
+	if err := s.Logout(ctx, second.RefreshToken); err != nil {
+		t.Errorf("second logout: %v", err)
+	}
+	if err := s.Logout(ctx, "unknown"); err != nil {
+		t.Errorf("unknown token: %v", err)
+	}
+}
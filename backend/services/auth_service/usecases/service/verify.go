@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"auth_service/domain"
+	"auth_service/mailer"
+	"auth_service/repository"
+	"auth_service/usecases"
+)
+
+// sendVerification mails a fresh verification link to the user
+func (s *authService) sendVerification(ctx context.Context, user *domain.User) error {
+	raw, err := s.createActionToken(ctx, user.ID, domain.PurposeVerifyEmail, user.Email, s.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/verify?token=%s", s.cfg.APIURL, url.QueryEscape(raw))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your HealthBuddy email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nplease confirm that this is your email address by opening the link below within %s:\n\n%s\n\n"+
+				"If you did not sign up for HealthBuddy, just ignore this email.\n",
+			user.Username, s.cfg.VerifyTTL, link,
+		),
+	}
+	if err := s.mail.Send(ctx, msg); err != nil {
+		return fmt.Errorf("%w: %v", usecases.ErrMailerDown, err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the address the token was sent to as verified
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	t, err := s.consumeActionToken(ctx, domain.PurposeVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+	user, err := s.FindByID(ctx, t.UserID)
+	if err != nil {
+		if errors.Is(err, usecases.ErrUserNotFound) {
+			return usecases.ErrInvalidToken
+		}
+		return err
+	}
+	// the address changed since the mail was sent
+	if user.Email != t.Payload {
+		return usecases.ErrInvalidToken
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	if err := s.repo.MarkEmailVerified(ctx, user.ID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return usecases.ErrInvalidToken
+		}
+		return err
+	}
+	return nil
+}
+
+// ResendVerification sends a new link at most once per VerifyResendInterval.
+// Like ForgotPassword it reports success in every case, so that neither
+// throttling nor a mailer failure can tell a registered address apart.
+func (s *authService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	last, err := s.actions.LatestCreatedAt(ctx, user.ID, domain.PurposeVerifyEmail)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if err == nil {
+		if wait := s.cfg.VerifyResendInterval - time.Since(last); wait > 0 {
+			log.Printf("ResendVerification: user %s asked again within %s", user.ID, wait.Round(time.Second))
+			return nil
+		}
+	}
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("ResendVerification: mail to user %s failed: %v", user.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth_service/domain"
+)
+
+func TestResendVerificationAnswersAlike(t *testing.T) {
+	users := newFakeUsers(
+		&domain.User{ID: "u1", Username: "ann", Email: "ann@example.com"},
+		&domain.User{ID: "u2", Username: "bob", Email: "bob@example.com", EmailVerified: true},
+		&domain.User{ID: "u3", Username: "cat", Email: "cat@example.com"},
+	)
+	mail := &fakeMailer{}
+	s := &authService{repo: users, actions: &fakeActions{}, mail: mail, cfg: Config{VerifyTTL: time.Hour, VerifyResendInterval: time.Minute}}
+	ctx := context.Background()
+
+	tests := []struct {
+		name, email string
+		mailErr     error
+		sent        int
+	}{
+		{"unknown", "nobody@example.com", nil, 0},
+		{"verified", "bob@example.com", nil, 0},
+		{"unverified", "ann@example.com", nil, 1},
+		// throttling must look the same as success
+		{"asked again", "ann@example.com", nil, 1},
+		// and so must a mailer that is down
+		{"mailer down", "cat@example.com", errMailer, 2},
+	}
+	for _, tt := range tests {
+		mail.err = tt.mailErr
+		if err := s.ResendVerification(ctx, tt.email); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if len(mail.sent) != tt.sent {
+			t.Errorf("%s: %d mails sent in total, want %d", tt.name, len(mail.sent), tt.sent)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"net/http"
+
+	"feed_service/api/http/apierrors"
+	"feed_service/domain"
+	"feed_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// challengeError maps challenge usecase errors to API errors
+func challengeError(err error) error {
+	switch err {
+	case usecases.ErrNotFound:
+		return apierrors.NewNotFound(err.Error())
+	case usecases.ErrWrongJoinCode, usecases.ErrNotParticipant, usecases.ErrForbidden:
+		return apierrors.NewForbidden(err.Error())
+	case usecases.ErrChallengeClosed, usecases.ErrOutsideWindow:
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	return apierrors.NewInternal(err)
+}
+
+// CreateChallenge handles POST /feed/challenges
+func (h *FeedHandler) CreateChallenge(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.CreateChallenge(c.Request.Context(), userID, req)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// ListChallenges handles GET /feed/challenges?status=&limit=&cursor=
+func (h *FeedHandler) ListChallenges(c *gin.Context) error {
+	status := c.Query("status")
+	switch status {
+	case "", domain.ChallengeUpcoming, domain.ChallengeActive, domain.ChallengeFinished:
+	default:
+		return apierrors.NewBadRequest("status must be upcoming, active or finished", nil)
+	}
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListChallenges(c.Request.Context(), status, c.GetHeader("X-User-ID"), page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// GetChallenge handles GET /feed/challenges/:id
+func (h *FeedHandler) GetChallenge(c *gin.Context) error {
+	out, err := h.svc.GetChallenge(c.Request.Context(), c.Param("id"), c.GetHeader("X-User-ID"))
+	if err != nil {
+		return challengeError(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// DeleteChallenge handles DELETE /feed/challenges/:id
+// Only the creator of the challenge or a moderator can delete it.
+func (h *FeedHandler) DeleteChallenge(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	id := c.Param("id")
+
+	existing, err := h.svc.GetChallenge(c.Request.Context(), id, userID)
+	if err != nil {
+		return challengeError(err)
+	}
+	if existing.CreatorID != userID && !isModerator(c) {
+		return apierrors.NewForbidden("unauthorized to delete this challenge")
+	}
+
+	if err := h.svc.DeleteChallenge(c.Request.Context(), id); err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// JoinChallenge handles PUT /feed/challenges/:id/participants/me
+func (h *FeedHandler) JoinChallenge(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.JoinChallengeRequest
+	// the body is optional for open challenges
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+	}
+
+	out, err := h.svc.JoinChallenge(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		return challengeError(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// LeaveChallenge handles DELETE /feed/challenges/:id/participants/me
+func (h *FeedHandler) LeaveChallenge(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	out, err := h.svc.LeaveChallenge(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		return challengeError(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// AddChallengeEntry handles POST /feed/challenges/:id/entries
+func (h *FeedHandler) AddChallengeEntry(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.ChallengeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.AddChallengeEntry(c.Request.Context(), c.Param("id"), userID, req)
+	if err != nil {
+		return challengeError(err)
+	}
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// DeleteChallengeEntry handles DELETE /feed/challenges/:id/entries/:entry_id
+func (h *FeedHandler) DeleteChallengeEntry(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	err := h.svc.DeleteChallengeEntry(c.Request.Context(), c.Param("id"), c.Param("entry_id"), userID, isModerator(c))
+	if err != nil {
+		return challengeError(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// Leaderboard handles GET /feed/challenges/:id/leaderboard?limit=&cursor=
+func (h *FeedHandler) Leaderboard(c *gin.Context) error {
+	page, err := domain.NewOffsetPage(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	out, err := h.svc.Leaderboard(c.Request.Context(), c.Param("id"), c.GetHeader("X-User-ID"), page)
+	if err != nil {
+		return challengeError(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// ListChallengePublications handles GET /feed/challenges/:id/publications?limit=&cursor=&type=
+func (h *FeedHandler) ListChallengePublications(c *gin.Context) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	filter, err := publicationFilter(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListChallengePublications(c.Request.Context(), c.Param("id"), c.GetHeader("X-User-ID"), filter, page)
+	if err != nil {
+		return challengeError(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
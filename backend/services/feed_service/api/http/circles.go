@@ -0,0 +1,148 @@
+package http
+
+import (
+	"net/http"
+
+	"feed_service/api/http/apierrors"
+	"feed_service/domain"
+	"feed_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCircle handles POST /feed/circles
+func (h *FeedHandler) CreateCircle(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.CircleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	out, err := h.svc.CreateCircle(c.Request.Context(), userID, req)
+	if err != nil {
+		if err == usecases.ErrUserNotFound {
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// ListCircles handles GET /feed/circles, the caller's circles by name
+func (h *FeedHandler) ListCircles(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	out, err := h.svc.ListCircles(c.Request.Context(), userID)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, gin.H{"circles": out})
+	return nil
+}
+
+// ownCircle loads the circle named in the path and checks that the caller
+// owns it; other users' circles are reported as missing
+func (h *FeedHandler) ownCircle(c *gin.Context) (domain.CircleResponse, error) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return domain.CircleResponse{}, apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	circle, err := h.svc.GetCircle(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return domain.CircleResponse{}, apierrors.NewNotFound(err.Error())
+		}
+		return domain.CircleResponse{}, apierrors.NewInternal(err)
+	}
+	if circle.UserID != userID {
+		return domain.CircleResponse{}, apierrors.NewNotFound(usecases.ErrNotFound.Error())
+	}
+	return circle, nil
+}
+
+// GetCircle handles GET /feed/circles/:id, with the member list
+func (h *FeedHandler) GetCircle(c *gin.Context) error {
+	circle, err := h.ownCircle(c)
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, circle)
+	return nil
+}
+
+// RenameCircle handles PUT /feed/circles/:id
+func (h *FeedHandler) RenameCircle(c *gin.Context) error {
+	circle, err := h.ownCircle(c)
+	if err != nil {
+		return err
+	}
+	var req domain.CircleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	out, err := h.svc.RenameCircle(c.Request.Context(), circle.CircleID, req.Name)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// DeleteCircle handles DELETE /feed/circles/:id
+// Publications shared with the circle become private.
+func (h *FeedHandler) DeleteCircle(c *gin.Context) error {
+	circle, err := h.ownCircle(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.DeleteCircle(c.Request.Context(), circle.CircleID); err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// AddCircleMember handles PUT /feed/circles/:id/members/:user_id
+func (h *FeedHandler) AddCircleMember(c *gin.Context) error {
+	circle, err := h.ownCircle(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.AddCircleMember(c.Request.Context(), circle.CircleID, c.Param("user_id"))
+	if err != nil {
+		switch err {
+		case usecases.ErrSelfCircle, usecases.ErrCircleFull:
+			return apierrors.NewBadRequest(err.Error(), err)
+		case usecases.ErrUserNotFound:
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// RemoveCircleMember handles DELETE /feed/circles/:id/members/:user_id
+func (h *FeedHandler) RemoveCircleMember(c *gin.Context) error {
+	circle, err := h.ownCircle(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.RemoveCircleMember(c.Request.Context(), circle.CircleID, c.Param("user_id"))
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
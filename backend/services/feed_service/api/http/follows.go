@@ -0,0 +1,104 @@
+package http
+
+import (
+	"net/http"
+
+	"feed_service/api/http/apierrors"
+	"feed_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Follow handles PUT /feed/users/:id/follow
+func (h *FeedHandler) Follow(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	out, err := h.svc.Follow(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		switch err {
+		case usecases.ErrSelfFollow:
+			return apierrors.NewBadRequest(err.Error(), err)
+		case usecases.ErrUserNotFound:
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// Unfollow handles DELETE /feed/users/:id/follow
+func (h *FeedHandler) Unfollow(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	out, err := h.svc.Unfollow(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// FollowCounts handles GET /feed/users/:id/follows
+func (h *FeedHandler) FollowCounts(c *gin.Context) error {
+	out, err := h.svc.FollowCounts(c.Request.Context(), c.Param("id"), c.GetHeader("X-User-ID"))
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// ListFollowers handles GET /feed/users/:id/followers?limit=&cursor=
+func (h *FeedHandler) ListFollowers(c *gin.Context) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListFollowers(c.Request.Context(), c.Param("id"), page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// ListFollowing handles GET /feed/users/:id/following?limit=&cursor=
+func (h *FeedHandler) ListFollowing(c *gin.Context) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListFollowing(c.Request.Context(), c.Param("id"), page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// Timeline handles GET /feed/timeline?limit=&cursor=&type=
+func (h *FeedHandler) Timeline(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	filter, err := publicationFilter(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.Timeline(c.Request.Context(), userID, filter, page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
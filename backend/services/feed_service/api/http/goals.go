@@ -0,0 +1,187 @@
+package http
+
+import (
+	"net/http"
+
+	"feed_service/api/http/apierrors"
+	"feed_service/domain"
+	"feed_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateGoal handles POST /feed/goals
+func (h *FeedHandler) CreateGoal(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	var req domain.GoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.CreateGoal(c.Request.Context(), userID, req)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// ListGoals handles GET /feed/goals?user_id=&limit=&cursor=
+// Without user_id it lists the caller's goals. Goals are private to their
+// owner and moderators; others see the goals attached to posts they can see.
+func (h *FeedHandler) ListGoals(c *gin.Context) error {
+	callerID := c.GetHeader("X-User-ID")
+	userID := c.DefaultQuery("user_id", callerID)
+	if userID == "" {
+		return apierrors.NewBadRequest("missing user_id query parameter", nil)
+	}
+	if userID != callerID && !isModerator(c) {
+		return apierrors.NewForbidden("unauthorized to view this user's goals")
+	}
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListGoals(c.Request.Context(), userID, page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// GetGoal handles GET /feed/goals/:id
+// Only the owner of the goal or a moderator can view it.
+func (h *FeedHandler) GetGoal(c *gin.Context) error {
+	goal, err := h.ownGoal(c, true, "view")
+	if err != nil {
+		return err
+	}
+	c.JSON(http.StatusOK, goal)
+	return nil
+}
+
+// ownGoal loads the goal in :id and checks that the caller owns it.
+// Moderators pass when moderatorOK is set; action names the refused
+// operation in the error.
+func (h *FeedHandler) ownGoal(c *gin.Context, moderatorOK bool, action string) (domain.GoalResponse, error) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return domain.GoalResponse{}, apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	goal, err := h.svc.GetGoal(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return domain.GoalResponse{}, apierrors.NewNotFound(err.Error())
+		}
+		return domain.GoalResponse{}, apierrors.NewInternal(err)
+	}
+	if goal.UserID != userID && !(moderatorOK && isModerator(c)) {
+		return domain.GoalResponse{}, apierrors.NewForbidden("unauthorized to " + action + " this goal")
+	}
+	return goal, nil
+}
+
+// UpdateGoal handles PUT /feed/goals/:id
+// Only the owner of the goal can update it.
+func (h *FeedHandler) UpdateGoal(c *gin.Context) error {
+	goal, err := h.ownGoal(c, false, "update")
+	if err != nil {
+		return err
+	}
+	var req domain.UpdateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.UpdateGoal(c.Request.Context(), goal.GoalID, req)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// DeleteGoal handles DELETE /feed/goals/:id
+// Only the owner of the goal or a moderator can delete it.
+func (h *FeedHandler) DeleteGoal(c *gin.Context) error {
+	goal, err := h.ownGoal(c, true, "delete")
+	if err != nil {
+		return err
+	}
+	if err := h.svc.DeleteGoal(c.Request.Context(), goal.GoalID); err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// AddCheckIn handles POST /feed/goals/:id/checkins
+func (h *FeedHandler) AddCheckIn(c *gin.Context) error {
+	goal, err := h.ownGoal(c, false, "change")
+	if err != nil {
+		return err
+	}
+	var req domain.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.AddCheckIn(c.Request.Context(), goal.GoalID, req)
+	if err != nil {
+		if err == usecases.ErrValueRequired || err == usecases.ErrGoalAbandoned {
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// ListCheckIns handles GET /feed/goals/:id/checkins?limit=&cursor=
+// Only the owner of the goal or a moderator can view them.
+func (h *FeedHandler) ListCheckIns(c *gin.Context) error {
+	goal, err := h.ownGoal(c, true, "view")
+	if err != nil {
+		return err
+	}
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListCheckIns(c.Request.Context(), goal.GoalID, page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// DeleteCheckIn handles DELETE /feed/goals/:id/checkins/:checkin_id
+func (h *FeedHandler) DeleteCheckIn(c *gin.Context) error {
+	goal, err := h.ownGoal(c, false, "change")
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.DeleteCheckIn(c.Request.Context(), goal.GoalID, c.Param("checkin_id"))
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
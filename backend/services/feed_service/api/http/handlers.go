@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"strings"
 
 	"feed_service/api/http/apierrors"
 	"feed_service/api/http/middleware"
@@ -14,34 +15,175 @@ import (
 // FeedHandler handles HTTP requests for publications and comments
 // and delegates to the FeedService business logic.
 type FeedHandler struct {
-	svc usecases.FeedService
+	svc    usecases.FeedService
+	limits UploadLimits
+}
+
+// UploadLimits caps the size of uploaded files, in bytes
+type UploadLimits struct {
+	TrackBytes int64
+	// MediaBytes caps a whole media upload request, which may carry
+	// several files
+	MediaBytes int64
 }
 
 // NewFeedHandler constructs a new FeedHandler
-func NewFeedHandler(svc usecases.FeedService) *FeedHandler {
-	return &FeedHandler{svc: svc}
+func NewFeedHandler(svc usecases.FeedService, limits UploadLimits) *FeedHandler {
+	return &FeedHandler{svc: svc, limits: limits}
 }
 
-// RegisterRoutes registers feed routes on the Gin engine
-func (h *FeedHandler) RegisterRoutes(r *gin.Engine) {
+// RegisterRoutes registers feed routes on the Gin engine.
+// requireVerified blocks posting until the author's email is verified;
+// serviceToken guards the internal routes called by auth_service and
+// profileToken those called by profile_service.
+func (h *FeedHandler) RegisterRoutes(r *gin.Engine, requireVerified bool, serviceToken, profileToken string) {
+	verified := middleware.VerifiedEmailMiddleware(requireVerified)
 	grp := r.Group("/feed")
 	{
 		grp.GET("/health", middleware.ErrorHandlerMiddleware(h.Health))
 		// Publications
-		grp.POST("/publications", middleware.ErrorHandlerMiddleware(h.CreatePublication))
+		grp.POST("/publications", verified, middleware.ErrorHandlerMiddleware(h.CreatePublication))
 		grp.GET("/publications", middleware.ErrorHandlerMiddleware(h.ListPublications))
 		grp.GET("/publications/:id", middleware.ErrorHandlerMiddleware(h.GetPublication))
 		grp.PUT("/publications/:id", middleware.ErrorHandlerMiddleware(h.UpdatePublication))
 		grp.DELETE("/publications/:id", middleware.ErrorHandlerMiddleware(h.DeletePublication))
 
+		// Media attachments
+		grp.POST("/publications/:id/media", middleware.ErrorHandlerMiddleware(h.AttachMedia))
+		grp.DELETE("/publications/:id/media/:media_id", middleware.ErrorHandlerMiddleware(h.DeleteMedia))
+		grp.GET("/media/:id", middleware.ErrorHandlerMiddleware(h.ServeMedia))
+
 		// Comments
-		grp.POST("/comments", middleware.ErrorHandlerMiddleware(h.CreateComment))
+		grp.POST("/comments", verified, middleware.ErrorHandlerMiddleware(h.CreateComment))
 		grp.GET("/comments", middleware.ErrorHandlerMiddleware(h.ListComments))
 		grp.GET("/comments/:id", middleware.ErrorHandlerMiddleware(h.GetComment))
+		grp.GET("/comments/:id/replies", middleware.ErrorHandlerMiddleware(h.ListReplies))
 		grp.PUT("/comments/:id", middleware.ErrorHandlerMiddleware(h.UpdateComment))
 		grp.DELETE("/comments/:id", middleware.ErrorHandlerMiddleware(h.DeleteComment))
 		grp.GET("/user/publications", middleware.ErrorHandlerMiddleware(h.ListUserPublications))
+
+		// Reactions
+		grp.GET("/reactions/types", middleware.ErrorHandlerMiddleware(h.ReactionTypes))
+		grp.PUT("/publications/:id/reactions/:type", middleware.ErrorHandlerMiddleware(h.AddReaction(domain.KindPublication)))
+		grp.DELETE("/publications/:id/reactions/:type", middleware.ErrorHandlerMiddleware(h.RemoveReaction(domain.KindPublication)))
+		grp.PUT("/comments/:id/reactions/:type", middleware.ErrorHandlerMiddleware(h.AddReaction(domain.KindComment)))
+		grp.DELETE("/comments/:id/reactions/:type", middleware.ErrorHandlerMiddleware(h.RemoveReaction(domain.KindComment)))
+
+		// Follow graph and home timeline
+		grp.GET("/timeline", middleware.ErrorHandlerMiddleware(h.Timeline))
+		grp.GET("/users/:id/follows", middleware.ErrorHandlerMiddleware(h.FollowCounts))
+		grp.GET("/users/:id/followers", middleware.ErrorHandlerMiddleware(h.ListFollowers))
+		grp.GET("/users/:id/following", middleware.ErrorHandlerMiddleware(h.ListFollowing))
+		grp.PUT("/users/:id/follow", middleware.ErrorHandlerMiddleware(h.Follow))
+		grp.DELETE("/users/:id/follow", middleware.ErrorHandlerMiddleware(h.Unfollow))
+		grp.GET("/users/:id/badges", middleware.ErrorHandlerMiddleware(h.Badges))
+
+		// Circles for circle-visible publications
+		grp.POST("/circles", middleware.ErrorHandlerMiddleware(h.CreateCircle))
+		grp.GET("/circles", middleware.ErrorHandlerMiddleware(h.ListCircles))
+		grp.GET("/circles/:id", middleware.ErrorHandlerMiddleware(h.GetCircle))
+		grp.PUT("/circles/:id", middleware.ErrorHandlerMiddleware(h.RenameCircle))
+		grp.DELETE("/circles/:id", middleware.ErrorHandlerMiddleware(h.DeleteCircle))
+		grp.PUT("/circles/:id/members/:user_id", middleware.ErrorHandlerMiddleware(h.AddCircleMember))
+		grp.DELETE("/circles/:id/members/:user_id", middleware.ErrorHandlerMiddleware(h.RemoveCircleMember))
+
+		// Goals
+		grp.POST("/goals", middleware.ErrorHandlerMiddleware(h.CreateGoal))
+		grp.GET("/goals", middleware.ErrorHandlerMiddleware(h.ListGoals))
+		grp.GET("/goals/:id", middleware.ErrorHandlerMiddleware(h.GetGoal))
+		grp.PUT("/goals/:id", middleware.ErrorHandlerMiddleware(h.UpdateGoal))
+		grp.DELETE("/goals/:id", middleware.ErrorHandlerMiddleware(h.DeleteGoal))
+		grp.POST("/goals/:id/checkins", middleware.ErrorHandlerMiddleware(h.AddCheckIn))
+		grp.GET("/goals/:id/checkins", middleware.ErrorHandlerMiddleware(h.ListCheckIns))
+		grp.DELETE("/goals/:id/checkins/:checkin_id", middleware.ErrorHandlerMiddleware(h.DeleteCheckIn))
+
+		// Challenges
+		grp.POST("/challenges", middleware.ErrorHandlerMiddleware(h.CreateChallenge))
+		grp.GET("/challenges", middleware.ErrorHandlerMiddleware(h.ListChallenges))
+		grp.GET("/challenges/:id", middleware.ErrorHandlerMiddleware(h.GetChallenge))
+		grp.DELETE("/challenges/:id", middleware.ErrorHandlerMiddleware(h.DeleteChallenge))
+		grp.PUT("/challenges/:id/participants/me", middleware.ErrorHandlerMiddleware(h.JoinChallenge))
+		grp.DELETE("/challenges/:id/participants/me", middleware.ErrorHandlerMiddleware(h.LeaveChallenge))
+		grp.POST("/challenges/:id/entries", middleware.ErrorHandlerMiddleware(h.AddChallengeEntry))
+		grp.DELETE("/challenges/:id/entries/:entry_id", middleware.ErrorHandlerMiddleware(h.DeleteChallengeEntry))
+		grp.GET("/challenges/:id/leaderboard", middleware.ErrorHandlerMiddleware(h.Leaderboard))
+		grp.GET("/challenges/:id/publications", middleware.ErrorHandlerMiddleware(h.ListChallengePublications))
+
+		// GPS tracks
+		grp.POST("/tracks", middleware.ErrorHandlerMiddleware(h.ImportTrack))
+		grp.GET("/tracks", middleware.ErrorHandlerMiddleware(h.ListTracks))
+		grp.GET("/tracks/:id", middleware.ErrorHandlerMiddleware(h.GetTrack))
+		grp.DELETE("/tracks/:id", middleware.ErrorHandlerMiddleware(h.DeleteTrack))
+
+		// Search
+		grp.GET("/search", middleware.ErrorHandlerMiddleware(h.Search))
+
+		// Internal
+		grp.PUT("/internal/users/:id/name",
+			middleware.ServiceAuthMiddleware(serviceToken),
+			middleware.ErrorHandlerMiddleware(h.RenameAuthor),
+		)
+		grp.POST("/internal/users/:id/workouts",
+			middleware.ServiceAuthMiddleware(profileToken),
+			middleware.ErrorHandlerMiddleware(h.ImportWorkout),
+		)
+		grp.POST("/internal/users/:id/badges/evaluate",
+			middleware.ServiceAuthMiddleware(profileToken),
+			middleware.ErrorHandlerMiddleware(h.EvaluateBadges),
+		)
+	}
+}
+
+// changeablePublication loads the publication a caller wants to change.
+// Moderators find every publication, others only those they can see.
+func (h *FeedHandler) changeablePublication(c *gin.Context, id, userID string) (domain.PublicationResponse, error) {
+	var (
+		pub domain.PublicationResponse
+		err error
+	)
+	if isModerator(c) {
+		pub, err = h.svc.GetPublicationForModeration(c.Request.Context(), id)
+	} else {
+		pub, err = h.svc.GetPublication(c.Request.Context(), id, userID)
+	}
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return pub, apierrors.NewNotFound(err.Error())
+		}
+		return pub, apierrors.NewInternal(err)
 	}
+	return pub, nil
+}
+
+// changeableComment is changeablePublication for comments
+func (h *FeedHandler) changeableComment(c *gin.Context, id, userID string) (domain.CommentResponse, error) {
+	var (
+		comment domain.CommentResponse
+		err     error
+	)
+	if isModerator(c) {
+		comment, err = h.svc.GetCommentForModeration(c.Request.Context(), id)
+	} else {
+		comment, err = h.svc.GetComment(c.Request.Context(), id, userID)
+	}
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return comment, apierrors.NewNotFound(err.Error())
+		}
+		return comment, apierrors.NewInternal(err)
+	}
+	return comment, nil
+}
+
+// isModerator reports whether the gateway's X-User-Roles grants moderation
+func isModerator(c *gin.Context) bool {
+	for _, role := range strings.Split(c.GetHeader("X-User-Roles"), ",") {
+		switch strings.TrimSpace(role) {
+		case domain.RoleModerator, domain.RoleAdmin:
+			return true
+		}
+	}
+	return false
 }
 
 // Health endpoint
@@ -70,6 +212,9 @@ func (h *FeedHandler) CreatePublication(c *gin.Context) error {
 
 	out, err := h.svc.CreatePublication(c.Request.Context(), userID, req)
 	if err != nil {
+		if err == usecases.ErrBadAttachment || err == usecases.ErrNotParticipant || err == usecases.ErrBadTrack || err == usecases.ErrBadCircle {
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
 		return apierrors.NewInternal(err)
 	}
 	c.JSON(http.StatusCreated, out)
@@ -79,7 +224,7 @@ func (h *FeedHandler) CreatePublication(c *gin.Context) error {
 // GetPublication handles GET /feed/publications/:id
 func (h *FeedHandler) GetPublication(c *gin.Context) error {
 	id := c.Param("id")
-	out, err := h.svc.GetPublication(c.Request.Context(), id)
+	out, err := h.svc.GetPublication(c.Request.Context(), id, c.GetHeader("X-User-ID"))
 	if err != nil {
 		if err == usecases.ErrNotFound {
 			return apierrors.NewNotFound(err.Error())
@@ -90,9 +235,35 @@ func (h *FeedHandler) GetPublication(c *gin.Context) error {
 	return nil
 }
 
-// ListPublications handles GET /feed/publications
+// pageRequest reads the ?limit=&cursor= query parameters
+func pageRequest(c *gin.Context) (domain.PageRequest, error) {
+	page, err := domain.NewPageRequest(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		return domain.PageRequest{}, apierrors.NewBadRequest(err.Error(), err)
+	}
+	return page, nil
+}
+
+// publicationFilter parses the ?type= filter shared by publication lists
+func publicationFilter(c *gin.Context) (domain.PublicationFilter, error) {
+	filter, err := domain.NewPublicationFilter(c.Query("type"))
+	if err != nil {
+		return domain.PublicationFilter{}, apierrors.NewBadRequest(err.Error(), err)
+	}
+	return filter, nil
+}
+
+// ListPublications handles GET /feed/publications?limit=&cursor=&type=
 func (h *FeedHandler) ListPublications(c *gin.Context) error {
-	list, err := h.svc.ListPublications(c.Request.Context())
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	filter, err := publicationFilter(c)
+	if err != nil {
+		return err
+	}
+	list, err := h.svc.ListPublications(c.Request.Context(), c.GetHeader("X-User-ID"), filter, page)
 	if err != nil {
 		return apierrors.NewInternal(err)
 	}
@@ -102,8 +273,16 @@ func (h *FeedHandler) ListPublications(c *gin.Context) error {
 
 func (h *FeedHandler) ListUserPublications(c *gin.Context) error {
 	userID := c.GetHeader("X-User-ID")
-	// call usecase to get all publications for the user
-	posts, err := h.svc.ListPublicationsByUser(c.Request.Context(), userID)
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	filter, err := publicationFilter(c)
+	if err != nil {
+		return err
+	}
+	// call usecase to get a page of the user's publications
+	posts, err := h.svc.ListPublicationsByUser(c.Request.Context(), userID, userID, filter, page)
 	if err != nil {
 		return apierrors.NewInternal(err)
 	}
@@ -112,7 +291,7 @@ func (h *FeedHandler) ListUserPublications(c *gin.Context) error {
 }
 
 // UpdatePublication handles PUT /feed/publications/:id
-// Only the owner of the publication can update it.
+// Only the owner of the publication or a moderator can update it.
 func (h *FeedHandler) UpdatePublication(c *gin.Context) error {
 	userID := c.GetHeader("X-User-ID")
 	if userID == "" {
@@ -121,14 +300,11 @@ func (h *FeedHandler) UpdatePublication(c *gin.Context) error {
 	id := c.Param("id")
 
 	// Fetch existing publication to verify ownership
-	existing, err := h.svc.GetPublication(c.Request.Context(), id)
+	existing, err := h.changeablePublication(c, id, userID)
 	if err != nil {
-		if err == usecases.ErrNotFound {
-			return apierrors.NewNotFound(err.Error())
-		}
-		return apierrors.NewInternal(err)
+		return err
 	}
-	if existing.UserID != userID {
+	if existing.UserID != userID && !isModerator(c) {
 		return apierrors.NewForbidden("unauthorized to update this publication")
 	}
 
@@ -141,6 +317,9 @@ func (h *FeedHandler) UpdatePublication(c *gin.Context) error {
 	}
 	out, err := h.svc.UpdatePublication(c.Request.Context(), id, req)
 	if err != nil {
+		if err == usecases.ErrBadAttachment || err == usecases.ErrNotParticipant || err == usecases.ErrBadTrack || err == usecases.ErrBadCircle {
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
 		return apierrors.NewInternal(err)
 	}
 	c.JSON(http.StatusOK, out)
@@ -148,7 +327,7 @@ func (h *FeedHandler) UpdatePublication(c *gin.Context) error {
 }
 
 // DeletePublication handles DELETE /feed/publications/:id
-// Only the owner of the publication can delete it.
+// Only the owner of the publication or a moderator can delete it.
 func (h *FeedHandler) DeletePublication(c *gin.Context) error {
 	userID := c.GetHeader("X-User-ID")
 	if userID == "" {
@@ -157,14 +336,11 @@ func (h *FeedHandler) DeletePublication(c *gin.Context) error {
 	id := c.Param("id")
 
 	// Fetch existing publication to verify ownership
-	existing, err := h.svc.GetPublication(c.Request.Context(), id)
+	existing, err := h.changeablePublication(c, id, userID)
 	if err != nil {
-		if err == usecases.ErrNotFound {
-			return apierrors.NewNotFound(err.Error())
-		}
-		return apierrors.NewInternal(err)
+		return err
 	}
-	if existing.UserID != userID {
+	if existing.UserID != userID && !isModerator(c) {
 		return apierrors.NewForbidden("unauthorized to delete this publication")
 	}
 
@@ -192,31 +368,73 @@ func (h *FeedHandler) CreateComment(c *gin.Context) error {
 
 	out, err := h.svc.CreateComment(c.Request.Context(), userID, req)
 	if err != nil {
+		if err == usecases.ErrInvalidParent || err == usecases.ErrThreadTooDeep {
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
 		return apierrors.NewInternal(err)
 	}
 	c.JSON(http.StatusCreated, out)
 	return nil
 }
 
-// ListComments handles GET /feed/comments?post_id=...
+// ListComments handles GET /feed/comments?post_id=...&limit=&cursor=&tree=
+// With tree=true the page holds top-level comments with nested replies.
 func (h *FeedHandler) ListComments(c *gin.Context) error {
 	postID := c.Query("post_id")
 	if postID == "" {
 		return apierrors.NewBadRequest("missing post_id query parameter", nil)
 	}
-	list, err := h.svc.ListComments(c.Request.Context(), postID)
+	page, err := pageRequest(c)
 	if err != nil {
+		return err
+	}
+	list := h.svc.ListComments
+	if c.Query("tree") == "true" {
+		list = h.svc.ListCommentTree
+	}
+	out, err := list(c.Request.Context(), postID, c.GetHeader("X-User-ID"), page)
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
 		return apierrors.NewInternal(err)
 	}
-	c.JSON(http.StatusOK, list)
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// ListReplies handles GET /feed/comments/:id/replies?limit=&cursor=
+// It continues a thread past the replies a comment tree holds.
+func (h *FeedHandler) ListReplies(c *gin.Context) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListReplies(c.Request.Context(), c.Param("id"), c.GetHeader("X-User-ID"), page)
+	if err != nil {
+		switch err {
+		case usecases.ErrNotFound:
+			return apierrors.NewNotFound(err.Error())
+		case usecases.ErrNotTopLevel:
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
 	return nil
 }
 
 // GetComment handles GET /feed/comments/:id
 func (h *FeedHandler) GetComment(c *gin.Context) error {
 	id := c.Param("id")
-	out, err := h.svc.GetComment(c.Request.Context(), id)
+	out, err := h.svc.GetComment(c.Request.Context(), id, c.GetHeader("X-User-ID"))
 	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
 		return apierrors.NewInternal(err)
 	}
 	c.JSON(http.StatusOK, out)
@@ -224,7 +442,7 @@ func (h *FeedHandler) GetComment(c *gin.Context) error {
 }
 
 // UpdateComment handles PUT /feed/comments/:id
-// Only the owner of the comment can update it.
+// Only the owner of the comment or a moderator can update it.
 func (h *FeedHandler) UpdateComment(c *gin.Context) error {
 	userID := c.GetHeader("X-User-ID")
 	if userID == "" {
@@ -233,24 +451,28 @@ func (h *FeedHandler) UpdateComment(c *gin.Context) error {
 	id := c.Param("id")
 
 	// Fetch existing comment to verify ownership
-	existing, err := h.svc.GetComment(c.Request.Context(), id)
+	existing, err := h.changeableComment(c, id, userID)
 	if err != nil {
-		return apierrors.NewInternal(err)
+		return err
 	}
-	if existing.UserID != userID {
+	if existing.UserID != userID && !isModerator(c) {
 		return apierrors.NewForbidden("unauthorized to update this comment")
 	}
 
 	var req domain.PutCommentRequest
-	req.CommentID = id
 	if err := c.ShouldBindJSON(&req); err != nil {
 		return apierrors.NewBadRequest(err.Error(), err)
 	}
+	// the path decides which comment is edited, whatever the body says
+	req.CommentID = id
 	if err := req.Validate(); err != nil {
 		return apierrors.NewBadRequest(err.Error(), err)
 	}
 	out, err := h.svc.UpdateComment(c.Request.Context(), req)
 	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
 		return apierrors.NewInternal(err)
 	}
 	c.JSON(http.StatusOK, out)
@@ -258,7 +480,7 @@ func (h *FeedHandler) UpdateComment(c *gin.Context) error {
 }
 
 // DeleteComment handles DELETE /feed/comments/:id
-// Only the owner of the comment can delete it.
+// Only the owner of the comment or a moderator can delete it.
 func (h *FeedHandler) DeleteComment(c *gin.Context) error {
 	userID := c.GetHeader("X-User-ID")
 	if userID == "" {
@@ -267,11 +489,11 @@ func (h *FeedHandler) DeleteComment(c *gin.Context) error {
 	id := c.Param("id")
 
 	// Fetch existing comment to verify ownership
-	existing, err := h.svc.GetComment(c.Request.Context(), id)
+	existing, err := h.changeableComment(c, id, userID)
 	if err != nil {
-		return apierrors.NewInternal(err)
+		return err
 	}
-	if existing.UserID != userID {
+	if existing.UserID != userID && !isModerator(c) {
 		return apierrors.NewForbidden("unauthorized to delete this comment")
 	}
 
@@ -281,3 +503,87 @@ func (h *FeedHandler) DeleteComment(c *gin.Context) error {
 	c.Status(http.StatusNoContent)
 	return nil
 }
+
+// RenameAuthor handles PUT /feed/internal/users/:id/name
+// Called by auth_service so old posts show the new username.
+func (h *FeedHandler) RenameAuthor(c *gin.Context) error {
+	var req domain.RenameAuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	if err := h.svc.RenameAuthor(c.Request.Context(), c.Param("id"), req); err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// Search handles GET /feed/search?q=&type=&author=&from=&to=&limit=&cursor=
+func (h *FeedHandler) Search(c *gin.Context) error {
+	q, err := domain.NewSearchQuery(
+		c.Query("q"), c.Query("type"), c.Query("author"),
+		c.Query("from"), c.Query("to"), c.Query("limit"), c.Query("cursor"),
+	)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	q.ViewerID = c.GetHeader("X-User-ID")
+	res, err := h.svc.Search(c.Request.Context(), q)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, res)
+	return nil
+}
+
+// Badges handles GET /feed/users/:id/badges
+func (h *FeedHandler) Badges(c *gin.Context) error {
+	badges, err := h.svc.Badges(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, gin.H{"badges": badges})
+	return nil
+}
+
+// ImportWorkout handles POST /feed/internal/users/:id/workouts
+// Called by profile_service while importing a health app export; answers
+// 201 for a new post and 200 with the existing one for a re-import.
+func (h *FeedHandler) ImportWorkout(c *gin.Context) error {
+	var req domain.ImportedWorkoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, created, err := h.svc.ImportWorkout(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, out)
+	return nil
+}
+
+// EvaluateBadges handles POST /feed/internal/users/:id/badges/evaluate
+// Called by profile_service once an import has posted its workouts.
+func (h *FeedHandler) EvaluateBadges(c *gin.Context) error {
+	awarded, err := h.svc.EvaluateBadges(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	if awarded == nil {
+		awarded = []domain.BadgeResponse{}
+	}
+	c.JSON(http.StatusOK, gin.H{"badges": awarded})
+	return nil
+}
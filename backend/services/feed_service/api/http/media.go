@@ -0,0 +1,143 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"feed_service/api/http/apierrors"
+	"feed_service/domain"
+	"feed_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachMedia handles POST /feed/publications/:id/media, a multipart upload
+// of one or more "file" parts. Only the author of the publication can
+// attach media; each file is checked and stored as it streams in.
+func (h *FeedHandler) AttachMedia(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	pub, err := h.svc.GetPublication(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	if pub.UserID != userID {
+		return apierrors.NewForbidden("unauthorized to attach media to this publication")
+	}
+
+	tooLarge := fmt.Sprintf("media uploads are limited to %d MB per request", h.limits.MediaBytes>>20)
+	if c.Request.ContentLength > h.limits.MediaBytes {
+		return apierrors.NewTooLarge(tooLarge)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.limits.MediaBytes)
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return apierrors.NewBadRequest("expected a multipart/form-data upload", err)
+	}
+	var out []domain.MediaResponse
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return apierrors.NewTooLarge(tooLarge)
+			}
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		m, err := h.svc.AttachMedia(c.Request.Context(), pub.PostID, userID, part)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxErr):
+				return apierrors.NewTooLarge(tooLarge)
+			case errors.Is(err, usecases.ErrMediaTooLarge):
+				return apierrors.NewTooLarge(err.Error())
+			case errors.Is(err, usecases.ErrUnsupportedMedia), err == usecases.ErrTooManyMedia:
+				return apierrors.NewBadRequest(err.Error(), err)
+			}
+			return apierrors.NewInternal(err)
+		}
+		out = append(out, m)
+	}
+	if len(out) == 0 {
+		return apierrors.NewBadRequest("missing file part", nil)
+	}
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// ServeMedia handles GET /feed/media/:id, streaming the stored file to
+// viewers of its publication. Files never change once stored, so clients
+// may cache them for good; shared caches may not, as access depends on
+// the viewer.
+func (h *FeedHandler) ServeMedia(c *gin.Context) error {
+	m, body, err := h.svc.OpenMedia(c.Request.Context(), c.Param("id"), c.GetHeader("X-User-ID"))
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	defer body.Close()
+
+	etag := `"` + m.MediaID + `"`
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return nil
+	}
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, m.Size, m.ContentType, body, map[string]string{
+		"Content-Disposition": "inline",
+		"Content-Length":      strconv.FormatInt(m.Size, 10),
+	})
+	return nil
+}
+
+// DeleteMedia handles DELETE /feed/publications/:id/media/:media_id
+// Only the author of the publication or a moderator can remove media.
+func (h *FeedHandler) DeleteMedia(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	pub, err := h.changeablePublication(c, c.Param("id"), userID)
+	if err != nil {
+		return err
+	}
+	if pub.UserID != userID && !isModerator(c) {
+		return apierrors.NewForbidden("unauthorized to remove media from this publication")
+	}
+	found := false
+	for _, m := range pub.Media {
+		found = found || m.MediaID == c.Param("media_id")
+	}
+	if !found {
+		return apierrors.NewNotFound(usecases.ErrNotFound.Error())
+	}
+	if err := h.svc.DeleteMedia(c.Request.Context(), c.Param("media_id")); err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"feed_service/api/http/apierrors"
 	"log"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,3 +25,29 @@ func ErrorHandlerMiddleware(h HandlerFunc) gin.HandlerFunc {
 		}
 	}
 }
+
+// VerifiedEmailMiddleware rejects callers whose access token says the
+// email is not verified yet. It is a no-op when required is false.
+func VerifiedEmailMiddleware(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && c.GetHeader("X-Email-Verified") != "true" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "verify your email address before posting"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ServiceAuthMiddleware only lets through other services presenting the
+// shared X-Service-Token. An unset token disables the route.
+func ServiceAuthMiddleware(expectedToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if expectedToken == "" || c.GetHeader("X-Service-Token") != expectedToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "service unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"feed_service/api/http/apierrors"
+	"feed_service/domain"
+	"feed_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReactionTypes handles GET /feed/reactions/types
+func (h *FeedHandler) ReactionTypes(c *gin.Context) error {
+	c.JSON(http.StatusOK, gin.H{"types": h.svc.ReactionTypes()})
+	return nil
+}
+
+// AddReaction handles PUT /feed/{publications,comments}/:id/reactions/:type
+func (h *FeedHandler) AddReaction(targetType string) func(c *gin.Context) error {
+	return h.reaction(targetType, h.svc.AddReaction)
+}
+
+// RemoveReaction handles DELETE /feed/{publications,comments}/:id/reactions/:type
+func (h *FeedHandler) RemoveReaction(targetType string) func(c *gin.Context) error {
+	return h.reaction(targetType, h.svc.RemoveReaction)
+}
+
+type reactionFunc func(ctx context.Context, targetType, targetID, userID, reactionType string) ([]domain.ReactionCount, error)
+
+// reaction runs fn and replies with the target's updated summary
+func (h *FeedHandler) reaction(targetType string, fn reactionFunc) func(c *gin.Context) error {
+	return func(c *gin.Context) error {
+		userID := c.GetHeader("X-User-ID")
+		if userID == "" {
+			return apierrors.NewBadRequest("missing X-User-ID header", nil)
+		}
+
+		out, err := fn(c.Request.Context(), targetType, c.Param("id"), userID, c.Param("type"))
+		switch {
+		case errors.Is(err, usecases.ErrUnknownReaction):
+			return apierrors.NewBadRequest(err.Error(), err)
+		case errors.Is(err, usecases.ErrNotFound):
+			return apierrors.NewNotFound(err.Error())
+		case err != nil:
+			return apierrors.NewInternal(err)
+		}
+		c.JSON(http.StatusOK, gin.H{"reactions": out})
+		return nil
+	}
+}
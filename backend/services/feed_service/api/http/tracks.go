@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"feed_service/api/http/apierrors"
+	"feed_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportTrack handles POST /feed/tracks, a multipart upload with the
+// recording in the "file" part. The part is parsed as it streams in.
+func (h *FeedHandler) ImportTrack(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	tooLarge := fmt.Sprintf("track files are limited to %d MB", h.limits.TrackBytes>>20)
+	if c.Request.ContentLength > h.limits.TrackBytes {
+		return apierrors.NewTooLarge(tooLarge)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.limits.TrackBytes)
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return apierrors.NewBadRequest("expected a multipart/form-data upload", err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return apierrors.NewBadRequest("missing file part", nil)
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return apierrors.NewTooLarge(tooLarge)
+			}
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		out, err := h.svc.ImportTrack(c.Request.Context(), userID, part.FileName(), part)
+		if err != nil {
+			if errors.Is(err, usecases.ErrUnreadableTrack) {
+				return apierrors.NewBadRequest(err.Error(), err)
+			}
+			return apierrors.NewInternal(err)
+		}
+		c.JSON(http.StatusCreated, out)
+		return nil
+	}
+}
+
+// ListTracks handles GET /feed/tracks?limit=&cursor=, the caller's tracks
+func (h *FeedHandler) ListTracks(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	page, err := pageRequest(c)
+	if err != nil {
+		return err
+	}
+	out, err := h.svc.ListTracks(c.Request.Context(), userID, page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// GetTrack handles GET /feed/tracks/:id
+// Drafts are only shown to their owner and moderators, posted tracks to
+// those who can see the publication.
+func (h *FeedHandler) GetTrack(c *gin.Context) error {
+	viewerID := c.GetHeader("X-User-ID")
+	t, err := h.svc.GetTrack(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	if t.UserID != viewerID {
+		if t.PostID == nil && !isModerator(c) {
+			return apierrors.NewNotFound(usecases.ErrNotFound.Error())
+		}
+		if t.PostID != nil {
+			if _, err := h.svc.GetPublication(c.Request.Context(), *t.PostID, viewerID); err != nil {
+				if err == usecases.ErrNotFound {
+					return apierrors.NewNotFound(err.Error())
+				}
+				return apierrors.NewInternal(err)
+			}
+		}
+	}
+	c.JSON(http.StatusOK, t)
+	return nil
+}
+
+// DeleteTrack handles DELETE /feed/tracks/:id
+// Only the owner of the track or a moderator can delete it.
+func (h *FeedHandler) DeleteTrack(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	t, err := h.svc.GetTrack(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	if t.UserID != userID && !isModerator(c) {
+		return apierrors.NewForbidden("unauthorized to delete this track")
+	}
+	if err := h.svc.DeleteTrack(c.Request.Context(), t.TrackID); err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
@@ -9,9 +9,13 @@ import (
 	"syscall"
 	"time"
 
+	"blob"
 	handler "feed_service/api/http"
 	"feed_service/cmd/config"
+	"feed_service/repository"
 	"feed_service/repository/db"
+	"feed_service/usecases/achievements"
+	"feed_service/usecases/media"
 	feedService "feed_service/usecases/service"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +25,7 @@ func main() {
 	// Config
 	dbCfg := config.LoadDBConfig()
 	svcCfg := config.LoadServiceConfige()
+	mediaCfg := config.LoadMediaConfig()
 
 	// DB init
 	gormDB, err := db.InitDB(dbCfg)
@@ -50,10 +55,58 @@ func main() {
 	router := gin.New()
 	router.Use(gin.Logger(), gin.Recovery())
 
+	// achievement rules: built-in badges plus ACHIEVEMENTS_FILE
+	rules, err := achievements.LoadRules(svcCfg.AchievementsFile)
+	if err != nil {
+		log.Fatalf("failed to load achievements: %v", err)
+	}
+	badges := achievements.NewEngine(db.NewAchievementRepo(gormDB), rules, achievements.SystemClock{})
+
+	// media files: local directory or S3-compatible bucket
+	var blobs repository.BlobStore
+	switch mediaCfg.Store {
+	case "s3":
+		blobs = blob.NewS3Store(blob.S3Config{
+			Endpoint:  mediaCfg.S3Endpoint,
+			Region:    mediaCfg.S3Region,
+			Bucket:    mediaCfg.S3Bucket,
+			AccessKey: mediaCfg.S3AccessKey,
+			SecretKey: mediaCfg.S3SecretKey,
+		})
+	case "fs":
+		if blobs, err = blob.NewFSStore(mediaCfg.Dir); err != nil {
+			log.Fatalf("failed to init media store: %v", err)
+		}
+	default:
+		log.Fatalf("unknown MEDIA_STORE %q", mediaCfg.Store)
+	}
+
 	repo := db.NewFeedRepo(gormDB)
-	svc := feedService.NewFeedService(repo, svcCfg.ProfileURl)
-	h := handler.NewFeedHandler(svc)
-	h.RegisterRoutes(router)
+	svc := feedService.NewFeedService(
+		repo,
+		db.NewFollowRepo(gormDB),
+		db.NewCircleRepo(gormDB),
+		db.NewGoalRepo(gormDB),
+		db.NewChallengeRepo(gormDB),
+		db.NewTrackRepo(gormDB),
+		db.NewMediaRepo(gormDB),
+		blobs,
+		media.Config{
+			URLPrefix:     mediaCfg.URLPrefix,
+			MaxImageBytes: mediaCfg.MaxImageBytes,
+			MaxVideoBytes: mediaCfg.MaxVideoBytes,
+			MaxVideo:      mediaCfg.MaxVideo,
+		},
+		badges,
+		db.NewSearchRepo(gormDB),
+		svcCfg.ProfileURl,
+		svcCfg.ReactionTypes,
+	)
+	h := handler.NewFeedHandler(svc, handler.UploadLimits{
+		TrackBytes: svcCfg.MaxTrackBytes,
+		MediaBytes: mediaCfg.MaxUpload,
+	})
+	h.RegisterRoutes(router, svcCfg.RequireVerifiedEmail, svcCfg.ServiceAuthToken, svcCfg.ProfileServiceToken)
 
 	srv := &http.Server{
 		Addr:    ":8082",
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,12 +19,57 @@ type DBConfig struct {
 }
 
 type ServiceConfig struct {
-	ProfileURl string
+	ProfileURl           string
+	RequireVerifiedEmail bool
+	ServiceAuthToken     string
+	ProfileServiceToken  string
+	ReactionTypes        []string
+	AchievementsFile     string
+	MaxTrackBytes        int64
+}
+
+// MediaConfig selects where attachments are stored and bounds their size
+type MediaConfig struct {
+	Store         string // fs or s3
+	Dir           string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	URLPrefix     string
+	MaxImageBytes int64
+	MaxVideoBytes int64
+	MaxVideo      time.Duration
+	MaxUpload     int64
+}
+
+func LoadMediaConfig() MediaConfig {
+	return MediaConfig{
+		Store:         getEnv("MEDIA_STORE", "fs"),
+		Dir:           getEnv("MEDIA_DIR", "./data/media"),
+		S3Endpoint:    os.Getenv("S3_ENDPOINT"),
+		S3Region:      os.Getenv("S3_REGION"),
+		S3Bucket:      os.Getenv("S3_BUCKET"),
+		S3AccessKey:   os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:   os.Getenv("S3_SECRET_KEY"),
+		URLPrefix:     getEnv("MEDIA_URL_PREFIX", "/feed/media"),
+		MaxImageBytes: int64(getEnvAsInt("MAX_IMAGE_BYTES", 10<<20)),
+		MaxVideoBytes: int64(getEnvAsInt("MAX_VIDEO_BYTES", 50<<20)),
+		MaxVideo:      time.Duration(getEnvAsInt("MAX_VIDEO_SECONDS", 60)) * time.Second,
+		MaxUpload:     int64(getEnvAsInt("MAX_MEDIA_UPLOAD_BYTES", 100<<20)),
+	}
 }
 
 func LoadServiceConfige() ServiceConfig {
 	return ServiceConfig{
-		ProfileURl: os.Getenv("PROFILE_SERVICE_URL"),
+		ProfileURl:           os.Getenv("PROFILE_SERVICE_URL"),
+		RequireVerifiedEmail: getEnvAsBool("REQUIRE_VERIFIED_EMAIL_TO_POST", false),
+		ServiceAuthToken:     os.Getenv("AUTH_SERVICE_AUTH_TOKEN"),
+		ProfileServiceToken:  os.Getenv("PROFILE_SERVICE_AUTH_TOKEN"),
+		AchievementsFile:     os.Getenv("ACHIEVEMENTS_FILE"),
+		ReactionTypes:        getEnvAsList("REACTION_TYPES", []string{"like", "cheer", "strong", "fire", "clap"}),
+		MaxTrackBytes:        int64(getEnvAsInt("MAX_TRACK_BYTES", 25<<20)),
 	}
 }
 
@@ -40,6 +86,13 @@ func LoadDBConfig() DBConfig {
 	}
 }
 
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -67,3 +120,33 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 
 	return value
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsList splits a comma separated variable, dropping empty entries
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, v := range strings.Split(valueStr, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
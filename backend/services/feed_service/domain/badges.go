@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Badge is an achievement a user has earned. BadgeID names a rule of the
+// achievements engine, so new badges need no schema change.
+type Badge struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    string    `gorm:"type:char(36);not null;uniqueIndex:idx_badges_user_badge,priority:1"`
+	BadgeID   string    `gorm:"size:64;not null;uniqueIndex:idx_badges_user_badge,priority:2"`
+	AwardedAt time.Time `gorm:"not null"`
+}
+
+// ActivityStats is the snapshot of a user's activity that badge rules are
+// evaluated against. The day lists hold distinct UTC days with activity,
+// newest first, going back as far as the rules need.
+type ActivityStats struct {
+	Publications   int64
+	Comments       int64
+	CheckIns       int64
+	GoalsCompleted int64
+	ReactionsGiven int64
+	PostDays       []time.Time
+	CheckInDays    []time.Time
+}
+
+// badge response
+type BadgeResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AwardedAt   time.Time `json:"awarded_at"`
+}
@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// join policies
+const (
+	JoinOpen   = "open"   // anyone can join until the end
+	JoinCode   = "code"   // joining needs the code set by the creator
+	JoinClosed = "closed" // nobody new can join
+)
+
+// how entries add up on the leaderboard
+const (
+	AggregateSum = "sum"
+	AggregateMax = "max"
+)
+
+// challenge statuses, derived from the dates
+const (
+	ChallengeUpcoming = "upcoming"
+	ChallengeActive   = "active"
+	ChallengeFinished = "finished"
+)
+
+// Challenge is a time-boxed group event, e.g. a 30-day step challenge.
+// Participants submit entries of Metric, ranked by their sum or maximum.
+type Challenge struct {
+	gorm.Model
+	ChallengeID string    `gorm:"type:char(36);uniqueIndex"`
+	CreatorID   string    `gorm:"type:char(36);not null"`
+	Title       string    `gorm:"size:100;not null"`
+	Description string    `gorm:"size:2000"`
+	Metric      string    `gorm:"size:32;not null"`
+	Unit        string    `gorm:"size:16;not null"`
+	Aggregate   string    `gorm:"size:8;not null;default:sum"`
+	StartsAt    time.Time `gorm:"not null;index"`
+	EndsAt      time.Time `gorm:"not null;index"`
+	JoinPolicy  string    `gorm:"size:8;not null;default:open"`
+	JoinCode    string    `gorm:"size:32"`
+}
+
+// Status tells where now falls in the challenge window
+func (c *Challenge) Status(now time.Time) string {
+	switch {
+	case now.Before(c.StartsAt):
+		return ChallengeUpcoming
+	case now.Before(c.EndsAt):
+		return ChallengeActive
+	}
+	return ChallengeFinished
+}
+
+// ChallengeParticipant is a user who joined a challenge
+type ChallengeParticipant struct {
+	ID          uint      `gorm:"primaryKey"`
+	ChallengeID string    `gorm:"type:char(36);not null;uniqueIndex:idx_participants_challenge_user,priority:1"`
+	UserID      string    `gorm:"type:char(36);not null;uniqueIndex:idx_participants_challenge_user,priority:2;index"`
+	JoinedAt    time.Time `gorm:"not null"`
+}
+
+// ChallengeEntry is one submitted value of a participant
+type ChallengeEntry struct {
+	gorm.Model
+	EntryID     string    `gorm:"type:char(36);uniqueIndex"`
+	ChallengeID string    `gorm:"type:char(36);not null;index:idx_entries_challenge_user,priority:1"`
+	UserID      string    `gorm:"type:char(36);not null;index:idx_entries_challenge_user,priority:2"`
+	Value       float64   `gorm:"not null"`
+	RecordedAt  time.Time `gorm:"not null"`
+}
+
+// create challenge request
+type ChallengeRequest struct {
+	Title       string    `json:"title" validate:"required,max=100"`
+	Description string    `json:"description" validate:"max=2000"`
+	Metric      string    `json:"metric" validate:"required,max=32"`
+	Unit        string    `json:"unit" validate:"required,max=16"`
+	Aggregate   string    `json:"aggregate" validate:"omitempty,oneof=sum max"`
+	StartsAt    time.Time `json:"starts_at" validate:"required"`
+	EndsAt      time.Time `json:"ends_at" validate:"required"`
+	JoinPolicy  string    `json:"join_policy" validate:"omitempty,oneof=open code closed"`
+	JoinCode    string    `json:"join_code" validate:"max=32"`
+}
+
+func (r *ChallengeRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
+		}
+	}
+	if !r.EndsAt.After(r.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if r.EndsAt.Sub(r.StartsAt) > 366*24*time.Hour {
+		return errors.New("a challenge can last at most a year")
+	}
+	if (r.JoinPolicy == JoinCode) != (r.JoinCode != "") {
+		return errors.New("join_code is required with, and only with, the code join policy")
+	}
+	return nil
+}
+
+// ToChallenge builds the challenge created by creatorID
+func (r *ChallengeRequest) ToChallenge(creatorID string) *Challenge {
+	c := &Challenge{
+		ChallengeID: NewUUID(),
+		CreatorID:   creatorID,
+		Title:       r.Title,
+		Description: r.Description,
+		Metric:      r.Metric,
+		Unit:        r.Unit,
+		Aggregate:   r.Aggregate,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+		JoinPolicy:  r.JoinPolicy,
+		JoinCode:    r.JoinCode,
+	}
+	if c.Aggregate == "" {
+		c.Aggregate = AggregateSum
+	}
+	if c.JoinPolicy == "" {
+		c.JoinPolicy = JoinOpen
+	}
+	return c
+}
+
+// join request; Code is needed by the code join policy
+type JoinChallengeRequest struct {
+	Code string `json:"code"`
+}
+
+// entry request
+type ChallengeEntryRequest struct {
+	Value      float64    `json:"value"`
+	RecordedAt *time.Time `json:"recorded_at"`
+}
+
+func (r *ChallengeEntryRequest) Validate() error {
+	if r.Value < 0 || math.IsInf(r.Value, 0) || math.IsNaN(r.Value) {
+		return errors.New("value must be a non-negative number")
+	}
+	return nil
+}
+
+// challenge response
+type ChallengeResponse struct {
+	ChallengeID  string    `json:"challenge_id"`
+	CreatorID    string    `json:"creator_id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Metric       string    `json:"metric"`
+	Unit         string    `json:"unit"`
+	Aggregate    string    `json:"aggregate"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	JoinPolicy   string    `json:"join_policy"`
+	Status       string    `json:"status"`
+	Participants int64     `json:"participants"`
+	Joined       bool      `json:"joined"`
+}
+
+func (c *Challenge) ToResponse(now time.Time) ChallengeResponse {
+	return ChallengeResponse{
+		ChallengeID: c.ChallengeID,
+		CreatorID:   c.CreatorID,
+		Title:       c.Title,
+		Description: c.Description,
+		Metric:      c.Metric,
+		Unit:        c.Unit,
+		Aggregate:   c.Aggregate,
+		StartsAt:    c.StartsAt,
+		EndsAt:      c.EndsAt,
+		JoinPolicy:  c.JoinPolicy,
+		Status:      c.Status(now),
+	}
+}
+
+// entry response
+type ChallengeEntryResponse struct {
+	EntryID     string    `json:"entry_id"`
+	ChallengeID string    `json:"challenge_id"`
+	UserID      string    `json:"user_id"`
+	Value       float64   `json:"value"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+func (e *ChallengeEntry) ToResponse() ChallengeEntryResponse {
+	return ChallengeEntryResponse{
+		EntryID:     e.EntryID,
+		ChallengeID: e.ChallengeID,
+		UserID:      e.UserID,
+		Value:       e.Value,
+		RecordedAt:  e.RecordedAt,
+	}
+}
+
+// LeaderboardRow is one participant's standing. Equal scores share a rank
+// (1, 1, 3); among them whoever got there first is listed first.
+type LeaderboardRow struct {
+	Rank        int        `json:"rank"`
+	UserID      string     `json:"user_id"`
+	Score       float64    `json:"score"`
+	Entries     int        `json:"entries"`
+	LastEntryAt *time.Time `json:"last_entry_at,omitempty"`
+}
+
+// Leaderboard is a page of rows plus the caller's own row when they take part
+type Leaderboard struct {
+	Items      []LeaderboardRow `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Me         *LeaderboardRow  `json:"me,omitempty"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// Follow is an edge of the follow graph: FollowerID follows FolloweeID
+type Follow struct {
+	ID         uint   `gorm:"primaryKey"`
+	FollowerID string `gorm:"type:char(36);not null;uniqueIndex:idx_follows_pair,priority:1"`
+	FolloweeID string `gorm:"type:char(36);not null;uniqueIndex:idx_follows_pair,priority:2"`
+	CreatedAt  time.Time
+}
+
+// FollowResponse is one entry of a followers or following list
+type FollowResponse struct {
+	UserID     string    `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// FollowCounts summarizes a user's place in the graph.
+// FollowedByMe tells whether the caller follows the user.
+type FollowCounts struct {
+	UserID       string `json:"user_id"`
+	Followers    int64  `json:"followers"`
+	Following    int64  `json:"following"`
+	FollowedByMe bool   `json:"followed_by_me"`
+}
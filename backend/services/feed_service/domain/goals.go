@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// goal types
+const (
+	GoalDistance = "distance"
+	GoalWeight   = "weight"
+	GoalSteps    = "steps"
+	GoalHabit    = "habit"
+)
+
+// goal statuses
+const (
+	GoalActive    = "active"
+	GoalCompleted = "completed"
+	GoalAbandoned = "abandoned"
+)
+
+// defaultUnits is used when a goal is created without a unit
+var defaultUnits = map[string]string{
+	GoalDistance: "km",
+	GoalWeight:   "kg",
+	GoalSteps:    "steps",
+	GoalHabit:    "times",
+}
+
+// Goal is something a user works towards. Distance, steps and habit goals
+// add up their check-ins; a weight goal follows the latest check-in from
+// StartValue to TargetValue in either direction. CurrentValue is kept up
+// to date on every check-in.
+type Goal struct {
+	gorm.Model
+	GoalID       string     `gorm:"type:char(36);uniqueIndex"`
+	UserID       string     `gorm:"type:char(36);not null"`
+	Type         string     `gorm:"size:16;not null"`
+	Title        string     `gorm:"size:100;not null"`
+	StartValue   float64    `gorm:"not null;default:0"`
+	TargetValue  float64    `gorm:"not null"`
+	CurrentValue float64    `gorm:"not null;default:0"`
+	Unit         string     `gorm:"size:16;not null"`
+	Deadline     *time.Time `gorm:"type:date"`
+	Status       string     `gorm:"size:16;not null;default:active"`
+	CompletedAt  *time.Time
+}
+
+// Cumulative reports whether check-ins add up (all types but weight)
+func (g *Goal) Cumulative() bool {
+	return g.Type != GoalWeight
+}
+
+// Percent is the share of the way from start to target, 0-100
+func (g *Goal) Percent() float64 {
+	span := g.TargetValue - g.StartValue
+	if span == 0 {
+		return 100
+	}
+	p := (g.CurrentValue - g.StartValue) / span * 100
+	return math.Round(math.Max(0, math.Min(100, p))*10) / 10
+}
+
+// Retarget moves the target. A new target can finish an active goal, or
+// put a completed one out of reach again, which makes it active.
+func (g *Goal) Retarget(target float64, now time.Time) {
+	g.TargetValue = target
+	reached := g.Percent() >= 100
+	switch {
+	case g.Status == GoalActive && reached:
+		g.Status, g.CompletedAt = GoalCompleted, &now
+	case g.Status == GoalCompleted && !reached:
+		g.Status, g.CompletedAt = GoalActive, nil
+	}
+}
+
+// CheckIn records progress towards a goal at a point in time
+type CheckIn struct {
+	gorm.Model
+	CheckInID  string    `gorm:"type:char(36);uniqueIndex"`
+	GoalID     string    `gorm:"type:char(36);not null"`
+	UserID     string    `gorm:"type:char(36);not null"`
+	Value      float64   `gorm:"not null"`
+	Note       string    `gorm:"size:500"`
+	RecordedAt time.Time `gorm:"not null"`
+}
+
+// create goal request
+type GoalRequest struct {
+	Type        string     `json:"type" validate:"required,oneof=distance weight steps habit"`
+	Title       string     `json:"title" validate:"required,max=100"`
+	StartValue  *float64   `json:"start_value"`
+	TargetValue float64    `json:"target_value" validate:"gt=0"`
+	Unit        string     `json:"unit" validate:"max=16"`
+	Deadline    *time.Time `json:"deadline"`
+}
+
+func (r *GoalRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
+		}
+	}
+	if r.Type == GoalWeight && r.StartValue == nil {
+		return errors.New("start_value is required for weight goals")
+	}
+	if r.Type != GoalWeight && r.StartValue != nil && *r.StartValue != 0 {
+		return errors.New("start_value is only used by weight goals")
+	}
+	return nil
+}
+
+// ToGoal builds a new active goal owned by userID
+func (r *GoalRequest) ToGoal(userID string) *Goal {
+	g := &Goal{
+		GoalID:      NewUUID(),
+		UserID:      userID,
+		Type:        r.Type,
+		Title:       r.Title,
+		TargetValue: r.TargetValue,
+		Unit:        r.Unit,
+		Deadline:    r.Deadline,
+		Status:      GoalActive,
+	}
+	if g.Unit == "" {
+		g.Unit = defaultUnits[r.Type]
+	}
+	if r.StartValue != nil {
+		g.StartValue = *r.StartValue
+		g.CurrentValue = *r.StartValue
+	}
+	return g
+}
+
+// update goal request; absent fields stay unchanged
+type UpdateGoalRequest struct {
+	Title       *string    `json:"title" validate:"omitempty,max=100"`
+	TargetValue *float64   `json:"target_value" validate:"omitempty,gt=0"`
+	Deadline    *time.Time `json:"deadline"`
+	Status      *string    `json:"status" validate:"omitempty,oneof=active completed abandoned"`
+}
+
+func (r *UpdateGoalRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
+		}
+	}
+	return nil
+}
+
+// check-in request; habit check-ins default to a value of 1
+type CheckInRequest struct {
+	Value      *float64   `json:"value"`
+	Note       string     `json:"note" validate:"max=500"`
+	RecordedAt *time.Time `json:"recorded_at"`
+}
+
+func (r *CheckInRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
+		}
+	}
+	if r.Value != nil && (*r.Value < 0 || math.IsInf(*r.Value, 0) || math.IsNaN(*r.Value)) {
+		return errors.New("value must be a non-negative number")
+	}
+	return nil
+}
+
+// AddCheckIn response
+type CheckInResult struct {
+	CheckIn CheckInResponse `json:"check_in"`
+	Goal    GoalResponse    `json:"goal"`
+}
+
+// goal response
+type GoalResponse struct {
+	GoalID       string     `json:"goal_id"`
+	UserID       string     `json:"user_id"`
+	Type         string     `json:"type"`
+	Title        string     `json:"title"`
+	StartValue   float64    `json:"start_value"`
+	TargetValue  float64    `json:"target_value"`
+	CurrentValue float64    `json:"current_value"`
+	Unit         string     `json:"unit"`
+	Percent      float64    `json:"percent"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+	Status       string     `json:"status"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// check-in response
+type CheckInResponse struct {
+	CheckInID  string    `json:"check_in_id"`
+	GoalID     string    `json:"goal_id"`
+	Value      float64   `json:"value"`
+	Note       string    `json:"note,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+func (g *Goal) ToResponse() GoalResponse {
+	return GoalResponse{
+		GoalID:       g.GoalID,
+		UserID:       g.UserID,
+		Type:         g.Type,
+		Title:        g.Title,
+		StartValue:   g.StartValue,
+		TargetValue:  g.TargetValue,
+		CurrentValue: g.CurrentValue,
+		Unit:         g.Unit,
+		Percent:      g.Percent(),
+		Deadline:     g.Deadline,
+		Status:       g.Status,
+		CompletedAt:  g.CompletedAt,
+		CreatedAt:    g.CreatedAt,
+	}
+}
+
+func (c *CheckIn) ToResponse() CheckInResponse {
+	return CheckInResponse{
+		CheckInID:  c.CheckInID,
+		GoalID:     c.GoalID,
+		Value:      c.Value,
+		Note:       c.Note,
+		RecordedAt: c.RecordedAt,
+	}
+}
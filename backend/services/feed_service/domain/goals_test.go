@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoalRetarget(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-24 * time.Hour)
+	tests := []struct {
+		name       string
+		goal       Goal
+		target     float64
+		wantStatus string
+		completed  *time.Time
+	}{
+		{
+			name:       "lowered below progress completes",
+			goal:       Goal{Type: GoalDistance, TargetValue: 100, CurrentValue: 60, Status: GoalActive},
+			target:     50,
+			wantStatus: GoalCompleted,
+			completed:  &now,
+		},
+		{
+			name:       "raised above progress reopens",
+			goal:       Goal{Type: GoalDistance, TargetValue: 50, CurrentValue: 60, Status: GoalCompleted, CompletedAt: &earlier},
+			target:     100,
+			wantStatus: GoalActive,
+		},
+		{
+			name:       "raised but still reached stays completed",
+			goal:       Goal{Type: GoalSteps, TargetValue: 50, CurrentValue: 80, Status: GoalCompleted, CompletedAt: &earlier},
+			target:     70,
+			wantStatus: GoalCompleted,
+			completed:  &earlier,
+		},
+		{
+			name:       "still out of reach stays active",
+			goal:       Goal{Type: GoalDistance, TargetValue: 100, CurrentValue: 10, Status: GoalActive},
+			target:     80,
+			wantStatus: GoalActive,
+		},
+		{
+			name:       "weight goal reached going down",
+			goal:       Goal{Type: GoalWeight, StartValue: 90, TargetValue: 80, CurrentValue: 84, Status: GoalActive},
+			target:     85,
+			wantStatus: GoalCompleted,
+			completed:  &now,
+		},
+		{
+			name:       "abandoned goal is left alone",
+			goal:       Goal{Type: GoalDistance, TargetValue: 100, CurrentValue: 60, Status: GoalAbandoned},
+			target:     50,
+			wantStatus: GoalAbandoned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tt.goal
+			g.Retarget(tt.target, now)
+			if g.TargetValue != tt.target {
+				t.Errorf("target = %v, want %v", g.TargetValue, tt.target)
+			}
+			if g.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", g.Status, tt.wantStatus)
+			}
+			switch {
+			case tt.completed == nil && g.CompletedAt != nil:
+				t.Errorf("completed at %v, want unset", g.CompletedAt)
+			case tt.completed != nil && (g.CompletedAt == nil || !g.CompletedAt.Equal(*tt.completed)):
+				t.Errorf("completed at %v, want %v", g.CompletedAt, *tt.completed)
+			}
+		})
+	}
+}
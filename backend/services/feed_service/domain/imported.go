@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ImportedWorkoutRequest is sent by profile_service for each workout found
+// in a health app export. ExternalID identifies the workout within the
+// author's imports, so importing the same export twice posts it once.
+type ImportedWorkoutRequest struct {
+	ExternalID string          `json:"external_id" validate:"required,max=64"`
+	Name       string          `json:"name" validate:"max=30"`
+	Title      string          `json:"title" validate:"required,max=100"`
+	StartedAt  time.Time       `json:"started_at" validate:"required"`
+	Workout    *WorkoutDetails `json:"workout" validate:"required"`
+	// Visibility defaults to private so years of history do not flood
+	// the followers' timelines
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public followers private"`
+}
+
+func (r *ImportedWorkoutRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
+		}
+	}
+	return r.Workout.checkHeartRate()
+}
+
+// ToPublication builds the workout post, dated to when the workout started
+func (r *ImportedWorkoutRequest) ToPublication(userID string) *Publication {
+	externalID := r.ExternalID
+	pub := &Publication{
+		PostID:     NewUUID(),
+		UserID:     userID,
+		Name:       r.Name,
+		Title:      r.Title,
+		Visibility: VisibilityPrivate,
+		ExternalID: &externalID,
+	}
+	if r.Visibility != "" {
+		pub.Visibility = r.Visibility
+	}
+	pub.CreatedAt = r.StartedAt.UTC()
+	req := PublicationRequest{Type: PublicationWorkout, Workout: r.Workout}
+	req.applyTo(pub)
+	return pub
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxMediaPerPublication bounds the attachments of one post
+const MaxMediaPerPublication = 10
+
+// Media is a photo or video attached to a publication. The file itself is
+// kept in the blob store under Key, with its metadata stripped.
+type Media struct {
+	gorm.Model
+	MediaID     string `gorm:"type:char(36);uniqueIndex"`
+	PostID      string `gorm:"type:char(36);not null;index"`
+	UserID      string `gorm:"type:char(36);not null"`
+	Kind        string `gorm:"size:8;not null"`
+	ContentType string `gorm:"size:32;not null"`
+	Key         string `gorm:"size:100;not null"`
+	Size        int64  `gorm:"not null"`
+	Width       int
+	Height      int
+	DurationMs  int64
+}
+
+// media response; URL is filled in by the service
+type MediaResponse struct {
+	MediaID     string    `json:"media_id"`
+	Kind        string    `json:"kind"`
+	ContentType string    `json:"content_type"`
+	URL         string    `json:"url"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width,omitempty"`
+	Height      int       `json:"height,omitempty"`
+	DurationMs  int64     `json:"duration_ms,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (m *Media) ToResponse() MediaResponse {
+	return MediaResponse{
+		MediaID:     m.MediaID,
+		Kind:        m.Kind,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		DurationMs:  m.DurationMs,
+		CreatedAt:   m.CreatedAt,
+	}
+}
@@ -22,33 +22,112 @@ func init() {
 	})
 }
 
+// roles granted by auth_service, forwarded by the gateway in X-User-Roles
+const (
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 // publication structure
 type Publication struct {
 	gorm.Model
 	PostID  string `gorm:"type:char(36);uniqueIndex" json:"post_id" validate:"required,uuid4"`
-	UserID  string `gorm:"type:char(36)" json:"user_id" validate:"required,uuid4"`
+	UserID  string `gorm:"type:char(36);uniqueIndex:idx_publications_external,priority:1" json:"user_id" validate:"required,uuid4"`
 	Name    string `gorm:"size:30" json:"name"`
 	Title   string `gorm:"size:100" json:"title"`
 	Content string `gorm:"size:10000" json:"content"`
+	// Type is text, workout or meal; Details holds the typed fields as JSON
+	Type    string  `gorm:"size:16;not null;default:text" json:"type"`
+	Details Details `gorm:"type:jsonb" json:"-"`
+	// optional goal progress shown with the post
+	GoalID    *string `gorm:"type:char(36);index" json:"goal_id"`
+	CheckInID *string `gorm:"type:char(36);index" json:"check_in_id"`
+	// optional challenge the post is tagged to
+	ChallengeID *string `gorm:"type:char(36)" json:"challenge_id"`
+	// optional GPS track of a workout; a track belongs to one post at most
+	TrackID *string `gorm:"type:char(36);uniqueIndex" json:"track_id"`
+	// Visibility decides who sees the post and its comments; CircleID is
+	// set for circle visibility
+	Visibility string  `gorm:"size:16;not null;default:public" json:"visibility"`
+	CircleID   *string `gorm:"type:char(36);index" json:"circle_id"`
+	// ExternalID is set on workouts imported from a health app
+	ExternalID *string `gorm:"size:64;uniqueIndex:idx_publications_external,priority:2" json:"-"`
 }
 
+// MaxCommentDepth bounds reply nesting; top-level comments have depth 0
+const MaxCommentDepth = 5
+
+// TreeReplies is how many replies of each thread a comment tree holds; the
+// rest are paged through GET /feed/comments/:id/replies
+const TreeReplies = 50
+
 // comment structure
+// Replies point at their parent and at the top-level comment of the thread
+// (both nil for top-level comments). A deleted comment that still has
+// replies is kept as a tombstone so the thread stays connected.
 type Comment struct {
 	gorm.Model
-	CommentID string `gorm:"type:char(36);uniqueIndex" json:"comment_id" validate:"required,uuid4"`
-	PostID    string `gorm:"type:char(36)" json:"post_id" validate:"required,uuid4"`
-	UserID    string `gorm:"type:char(36)" json:"user_id" validate:"required,uuid4"`
-	Name      string `gorm:"size:30" json:"name"`
-	Content   string `gorm:"size:10000" json:"content" validate:"required,max=10000"`
+	CommentID       string  `gorm:"type:char(36);uniqueIndex" json:"comment_id" validate:"required,uuid4"`
+	PostID          string  `gorm:"type:char(36)" json:"post_id" validate:"required,uuid4"`
+	ParentCommentID *string `gorm:"type:char(36);index" json:"parent_comment_id"`
+	RootCommentID   *string `gorm:"type:char(36);index" json:"root_comment_id"`
+	Depth           int     `gorm:"not null;default:0" json:"depth"`
+	Deleted         bool    `gorm:"not null;default:false" json:"deleted"`
+	UserID          string  `gorm:"type:char(36)" json:"user_id" validate:"required,uuid4"`
+	Name            string  `gorm:"size:30" json:"name"`
+	Content         string  `gorm:"size:10000" json:"content" validate:"required,max=10000"`
 }
 
 // post/put publication requests
 type PublicationRequest struct {
 	Title   string `json:"title" validate:"required,max=300"`
-	Content string `json:"content" validate:"required,max=10000"`
+	Content string `json:"content" validate:"max=10000"`
+	// Type defaults to text; workout and meal posts carry matching details
+	Type    string          `json:"type" validate:"omitempty,oneof=text workout meal"`
+	Workout *WorkoutDetails `json:"workout"`
+	Meal    *MealDetails    `json:"meal"`
+	// GoalID and CheckInID attach the author's goal or one of its check-ins
+	GoalID    *string `json:"goal_id" validate:"omitempty,uuid4"`
+	CheckInID *string `json:"check_in_id" validate:"omitempty,uuid4"`
+	// ChallengeID tags the post to a challenge the author takes part in
+	ChallengeID *string `json:"challenge_id" validate:"omitempty,uuid4"`
+	// TrackID attaches one of the author's uploaded tracks to a workout;
+	// the workout details default to the track's summary
+	TrackID *string `json:"track_id" validate:"omitempty,uuid4"`
+	// Visibility defaults to public on create and is kept on update when
+	// left out; circle visibility names one of the author's circles
+	Visibility string  `json:"visibility" validate:"omitempty,oneof=public followers private circle"`
+	CircleID   *string `json:"circle_id" validate:"omitempty,uuid4"`
 }
 
 func (r *PublicationRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
+		}
+	}
+	if err := r.validateVisibility(); err != nil {
+		return err
+	}
+	return r.validateTyped()
+}
+
+// ApplyTo copies the fields of r onto p, replacing any previous details
+func (r *PublicationRequest) ApplyTo(p *Publication) {
+	p.Title = r.Title
+	p.Content = r.Content
+	if r.Visibility != "" {
+		p.Visibility, p.CircleID = r.Visibility, r.CircleID
+	}
+	r.applyTo(p)
+}
+
+// internal request from auth_service after a username change
+type RenameAuthorRequest struct {
+	Name string `json:"name" validate:"required,min=3,max=30,username"`
+}
+
+func (r *RenameAuthorRequest) Validate() error {
 	if err := validate.Struct(r); err != nil {
 		for _, e := range err.(validator.ValidationErrors) {
 			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
@@ -59,18 +138,40 @@ func (r *PublicationRequest) Validate() error {
 
 // get publication responce
 type PublicationResponse struct {
-	PostID    string    `json:"post_id"`
-	UserID    string    `json:"user_id"`
-	Name      string    `json:"name"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	PostID  string `json:"post_id"`
+	UserID  string `json:"user_id"`
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Type    string `json:"type"`
+	// Workout or Meal is set according to Type
+	Workout   *WorkoutDetails `json:"workout,omitempty"`
+	Meal      *MealDetails    `json:"meal,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	// Reactions is filled in by the service for the viewing user
+	Reactions []ReactionCount `json:"reactions"`
+	// attached goal progress, filled in by the service
+	GoalID    *string          `json:"goal_id,omitempty"`
+	CheckInID *string          `json:"check_in_id,omitempty"`
+	Goal      *GoalResponse    `json:"goal,omitempty"`
+	CheckIn   *CheckInResponse `json:"check_in,omitempty"`
+	// ChallengeID is the challenge the post is tagged to
+	ChallengeID *string `json:"challenge_id,omitempty"`
+	// attached GPS track, filled in by the service
+	TrackID *string        `json:"track_id,omitempty"`
+	Track   *TrackResponse `json:"track,omitempty"`
+	// Visibility is public, followers, private or circle
+	Visibility string  `json:"visibility"`
+	CircleID   *string `json:"circle_id,omitempty"`
+	// attached photos and videos, filled in by the service
+	Media []MediaResponse `json:"media,omitempty"`
 }
 
 // post comment request
 type PostCommentRequest struct {
-	PostID  string `json:"post_id" validate:"required,uuid4"`
-	Content string `json:"content" validate:"required,max=10000"`
+	PostID          string  `json:"post_id" validate:"required,uuid4"`
+	ParentCommentID *string `json:"parent_comment_id" validate:"omitempty,uuid4"`
+	Content         string  `json:"content" validate:"required,max=10000"`
 }
 
 func (r *PostCommentRequest) Validate() error {
@@ -99,33 +200,68 @@ func (r *PutCommentRequest) Validate() error {
 
 // comment response
 type CommentResponse struct {
-	CommentID string    `json:"comment_id"`
-	UserID    string    `json:"user_id"`
-	Name      string    `json:"name"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	CommentID       string    `json:"comment_id"`
+	PostID          string    `json:"post_id"`
+	ParentCommentID *string   `json:"parent_comment_id"`
+	Deleted         bool      `json:"deleted,omitempty"`
+	UserID          string    `json:"user_id"`
+	Name            string    `json:"name"`
+	Content         string    `json:"content"`
+	CreatedAt       time.Time `json:"created_at"`
+	// Reactions is filled in by the service for the viewing user
+	Reactions []ReactionCount `json:"reactions"`
+	// ReplyCount counts direct replies; Replies is only set in tree mode,
+	// and RepliesCursor on a top-level comment whose thread goes on
+	ReplyCount    int               `json:"reply_count"`
+	Replies       []CommentResponse `json:"replies,omitempty"`
+	RepliesCursor string            `json:"replies_cursor,omitempty"`
 }
 
 // Converter
 func (p *Publication) ToResponse() PublicationResponse {
-	return PublicationResponse{
-		PostID:    p.PostID,
-		UserID:    p.UserID,
-		Name:      p.Name,
-		Title:     p.Title,
-		Content:   p.Content,
-		CreatedAt: p.CreatedAt,
+	out := PublicationResponse{
+		PostID:      p.PostID,
+		UserID:      p.UserID,
+		Name:        p.Name,
+		Title:       p.Title,
+		Content:     p.Content,
+		Type:        p.Type,
+		CreatedAt:   p.CreatedAt,
+		Reactions:   []ReactionCount{},
+		GoalID:      p.GoalID,
+		CheckInID:   p.CheckInID,
+		ChallengeID: p.ChallengeID,
+		TrackID:     p.TrackID,
+		Visibility:  p.Visibility,
+		CircleID:    p.CircleID,
+	}
+	if out.Type == "" {
+		out.Type = PublicationText
 	}
+	if out.Visibility == "" {
+		out.Visibility = VisibilityPublic
+	}
+	p.decodeDetails(&out)
+	return out
 }
 
+// ToResponse hides the author and text of tombstones
 func (p *Comment) ToResponse() CommentResponse {
-	return CommentResponse{
-		CommentID: p.CommentID,
-		UserID:    p.UserID,
-		Name:      p.Name,
-		Content:   p.Content,
-		CreatedAt: p.CreatedAt,
+	out := CommentResponse{
+		CommentID:       p.CommentID,
+		PostID:          p.PostID,
+		ParentCommentID: p.ParentCommentID,
+		Deleted:         p.Deleted,
+		UserID:          p.UserID,
+		Name:            p.Name,
+		Content:         p.Content,
+		CreatedAt:       p.CreatedAt,
+		Reactions:       []ReactionCount{},
+	}
+	if p.Deleted {
+		out.UserID, out.Name, out.Content = "", "", ""
 	}
+	return out
 }
 
 func NewUUID() string {
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// publication types
+const (
+	PublicationText    = "text"
+	PublicationWorkout = "workout"
+	PublicationMeal    = "meal"
+)
+
+// workout activities
+const (
+	ActivityRun      = "run"
+	ActivityWalk     = "walk"
+	ActivityRide     = "ride"
+	ActivitySwim     = "swim"
+	ActivityHike     = "hike"
+	ActivityStrength = "strength"
+	ActivityYoga     = "yoga"
+	ActivityOther    = "other"
+)
+
+// WorkoutDetails are the fields of a workout publication
+type WorkoutDetails struct {
+	Activity        string            `json:"activity" validate:"required,oneof=run walk ride swim hike strength yoga other"`
+	DurationSeconds int               `json:"duration_seconds" validate:"gt=0,lte=172800"`
+	DistanceMeters  *float64          `json:"distance_m,omitempty" validate:"omitempty,gte=0,lte=1000000"`
+	Calories        *int              `json:"calories,omitempty" validate:"omitempty,gte=0,lte=20000"`
+	HeartRate       *HeartRateSummary `json:"heart_rate,omitempty"`
+}
+
+// HeartRateSummary is the heart rate of a workout in beats per minute
+type HeartRateSummary struct {
+	Avg int  `json:"avg" validate:"gte=25,lte=250"`
+	Max *int `json:"max,omitempty" validate:"omitempty,gte=25,lte=250"`
+	Min *int `json:"min,omitempty" validate:"omitempty,gte=25,lte=250"`
+}
+
+// checkHeartRate runs after the field tags have been validated
+func (w *WorkoutDetails) checkHeartRate() error {
+	if hr := w.HeartRate; hr != nil {
+		if hr.Max != nil && *hr.Max < hr.Avg || hr.Min != nil && *hr.Min > hr.Avg {
+			return errors.New("heart_rate must satisfy min <= avg <= max")
+		}
+	}
+	return nil
+}
+
+// MealDetails are the fields of a meal publication; nutrients are in grams
+type MealDetails struct {
+	Meal     string   `json:"meal" validate:"required,oneof=breakfast lunch dinner snack"`
+	Calories *int     `json:"calories,omitempty" validate:"omitempty,gte=0,lte=20000"`
+	Protein  *float64 `json:"protein_g,omitempty" validate:"omitempty,gte=0,lte=2000"`
+	Carbs    *float64 `json:"carbs_g,omitempty" validate:"omitempty,gte=0,lte=2000"`
+	Fat      *float64 `json:"fat_g,omitempty" validate:"omitempty,gte=0,lte=2000"`
+	Items    []string `json:"items,omitempty" validate:"max=50,dive,required,max=100"`
+}
+
+// Details holds the type specific fields of a publication as JSON
+type Details []byte
+
+// Value stores Details in a jsonb column; empty details are NULL
+func (d Details) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+// Scan reads a jsonb column
+func (d *Details) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+	case []byte:
+		*d = append(Details(nil), v...)
+	case string:
+		*d = Details(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Details", src)
+	}
+	return nil
+}
+
+// validateTyped checks that a request carries exactly the details of its
+// type; the details' own fields are covered by the struct tags. Plain text
+// posts need a body, workouts and meals may leave it empty. A workout may
+// name a track instead of its details, the service fills them in.
+func (r *PublicationRequest) validateTyped() error {
+	switch r.Type {
+	case "", PublicationText:
+		if r.Workout != nil || r.Meal != nil || r.TrackID != nil {
+			return errors.New("text publications take no workout, meal or track")
+		}
+		if r.Content == "" {
+			return errors.New("content is required for text publications")
+		}
+	case PublicationWorkout:
+		if r.Workout == nil && r.TrackID == nil || r.Meal != nil {
+			return errors.New("workout publications need a workout or a track and no meal")
+		}
+		if r.Workout != nil {
+			return r.Workout.checkHeartRate()
+		}
+	case PublicationMeal:
+		if r.Meal == nil || r.Workout != nil || r.TrackID != nil {
+			return errors.New("meal publications need a meal and no workout or track")
+		}
+		return nil
+	}
+	return nil
+}
+
+// applyTo sets the type and encoded details of p from r
+func (r *PublicationRequest) applyTo(p *Publication) {
+	p.Type, p.Details = PublicationText, nil
+	switch r.Type {
+	case PublicationWorkout:
+		p.Type = PublicationWorkout
+		p.Details, _ = json.Marshal(r.Workout)
+	case PublicationMeal:
+		p.Type = PublicationMeal
+		p.Details, _ = json.Marshal(r.Meal)
+	}
+}
+
+// decodeDetails fills the typed details of a response from p.Details
+func (p *Publication) decodeDetails(out *PublicationResponse) {
+	if len(p.Details) == 0 {
+		return
+	}
+	switch p.Type {
+	case PublicationWorkout:
+		var w WorkoutDetails
+		if json.Unmarshal(p.Details, &w) == nil {
+			out.Workout = &w
+		}
+	case PublicationMeal:
+		var m MealDetails
+		if json.Unmarshal(p.Details, &m) == nil {
+			out.Meal = &m
+		}
+	}
+}
+
+// PublicationFilter narrows publication lists; an empty Type matches all types
+type PublicationFilter struct {
+	Type string
+	// ViewerID limits the list to what that user may see; the service
+	// always sets it, and an empty viewer only sees public posts
+	ViewerID string
+}
+
+// NewPublicationFilter validates the ?type= query parameter of feed lists
+func NewPublicationFilter(typ string) (PublicationFilter, error) {
+	switch typ {
+	case "", PublicationText, PublicationWorkout, PublicationMeal:
+		return PublicationFilter{Type: typ}, nil
+	}
+	return PublicationFilter{}, errors.New("type must be text, workout or meal")
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Reaction is one user's reaction of one type to a publication or comment.
+// TargetType is KindPublication or KindComment; a user may leave several
+// types on the same target but each type only once.
+type Reaction struct {
+	ID         uint   `gorm:"primaryKey"`
+	TargetType string `gorm:"size:16;not null;uniqueIndex:idx_reactions_target_user_type,priority:1"`
+	TargetID   string `gorm:"type:char(36);not null;uniqueIndex:idx_reactions_target_user_type,priority:2"`
+	UserID     string `gorm:"type:char(36);not null;uniqueIndex:idx_reactions_target_user_type,priority:3"`
+	Type       string `gorm:"size:20;not null;uniqueIndex:idx_reactions_target_user_type,priority:4"`
+	CreatedAt  time.Time
+}
+
+// ReactionCount is the per-type summary embedded in responses
+type ReactionCount struct {
+	Type        string `json:"type"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
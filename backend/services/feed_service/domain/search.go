@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Kinds of search hits
+const (
+	KindPublication = "publication"
+	KindComment     = "comment"
+)
+
+// Snippet highlight markers around matched words
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// SearchQuery is a parsed GET /feed/search request
+type SearchQuery struct {
+	Text     string
+	Kind     string // "" for both
+	AuthorID string
+	From     *time.Time
+	To       *time.Time
+	// ViewerID is the caller; hits are limited to what they may see
+	ViewerID string
+	OffsetPage
+}
+
+// SearchHit is one matching publication or comment, best matches first
+type SearchHit struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	PostID    string    `json:"post_id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Title     string    `json:"title,omitempty"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewSearchQuery validates the query string parameters of GET /feed/search.
+// Dates are RFC 3339 or YYYY-MM-DD; "to" is exclusive.
+func NewSearchQuery(q, kind, author, from, to, limit, cursor string) (SearchQuery, error) {
+	sq := SearchQuery{Text: strings.TrimSpace(q), Kind: kind, AuthorID: author}
+	if len(sq.Text) < 2 || len(sq.Text) > 200 {
+		return SearchQuery{}, errors.New("q must be between 2 and 200 characters")
+	}
+	switch kind {
+	case "", KindPublication, KindComment:
+	default:
+		return SearchQuery{}, errors.New("type must be publication or comment")
+	}
+	var err error
+	if sq.From, err = parseDate(from); err != nil {
+		return SearchQuery{}, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+	if sq.To, err = parseDate(to); err != nil {
+		return SearchQuery{}, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+	// results are ordered by rank, so the cursor is simply an offset
+	if sq.OffsetPage, err = NewOffsetPage(limit, cursor); err != nil {
+		return SearchQuery{}, err
+	}
+	return sq, nil
+}
+
+func parseDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSearchQuery(t *testing.T) {
+	q, err := NewSearchQuery("  easy run  ", KindComment, "u1", "2024-05-01", "2024-05-02T10:30:00+02:00", "5", base64.RawURLEncoding.EncodeToString([]byte("10")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, to := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)
+	if q.Text != "easy run" || q.Kind != KindComment || q.AuthorID != "u1" ||
+		q.From == nil || !q.From.Equal(from) || q.To == nil || !q.To.Equal(to) || q.Limit != 5 || q.Offset != 10 {
+		t.Errorf("query = %+v", q)
+	}
+
+	q, err = NewSearchQuery("run", "", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Kind != "" || q.From != nil || q.To != nil || q.Limit != DefaultPageLimit || q.Offset != 0 {
+		t.Errorf("defaults = %+v", q)
+	}
+	if q, _ := NewSearchQuery("run", "", "", "", "", "1000", ""); q.Limit != MaxPageLimit {
+		t.Errorf("limit = %d, want %d", q.Limit, MaxPageLimit)
+	}
+	if next := (OffsetPage{Limit: 5, Offset: 10}).NextCursor(); next != base64.RawURLEncoding.EncodeToString([]byte("15")) {
+		t.Errorf("next cursor = %q", next)
+	}
+}
+
+func TestNewSearchQueryRejects(t *testing.T) {
+	tests := []struct {
+		name                             string
+		q, kind, from, to, limit, cursor string
+	}{
+		{name: "short query", q: " a "},
+		{name: "long query", q: strings.Repeat("x", 201)},
+		{name: "unknown type", q: "run", kind: "user"},
+		{name: "bad from", q: "run", from: "May 1st"},
+		{name: "bad to", q: "run", to: "2024-13-01"},
+		{name: "zero limit", q: "run", limit: "0"},
+		{name: "bad limit", q: "run", limit: "ten"},
+		{name: "bad cursor", q: "run", cursor: "%%%"},
+		{name: "negative offset", q: "run", cursor: base64.RawURLEncoding.EncodeToString([]byte("-20"))},
+	}
+	for _, tt := range tests {
+		if q, err := NewSearchQuery(tt.q, tt.kind, "", tt.from, tt.to, tt.limit, tt.cursor); err == nil {
+			t.Errorf("%s: accepted as %+v", tt.name, q)
+		}
+	}
+}
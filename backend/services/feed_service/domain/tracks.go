@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Track is an uploaded GPS recording, kept as a simplified polyline plus
+// its summary. A track no publication points at is a draft that only its
+// owner can see.
+type Track struct {
+	gorm.Model
+	TrackID         string `gorm:"type:char(36);uniqueIndex"`
+	UserID          string `gorm:"type:char(36);not null"`
+	Format          string `gorm:"size:8;not null"`
+	Name            string `gorm:"size:100"`
+	Activity        string `gorm:"size:16;not null"`
+	StartedAt       *time.Time
+	DistanceMeters  float64 `gorm:"not null;default:0"`
+	DurationSeconds int     `gorm:"not null;default:0"`
+	ElevationGain   float64 `gorm:"not null;default:0"`
+	Pace            float64 `gorm:"not null;default:0"` // seconds per km
+	HeartRateAvg    *int
+	HeartRateMax    *int
+	HeartRateMin    *int
+	Polyline        string `gorm:"type:text"`
+	Splits          Splits `gorm:"type:jsonb"`
+}
+
+// TrackSplit is one kilometre of a track; the last one may be shorter
+type TrackSplit struct {
+	DistanceMeters  float64 `json:"distance_m"`
+	DurationSeconds int     `json:"duration_seconds"`
+	Pace            float64 `json:"pace_s_per_km"`
+	ElevationGain   float64 `json:"elevation_gain_m"`
+}
+
+// Splits is stored as a jsonb array
+type Splits []TrackSplit
+
+func (s Splits) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+func (s *Splits) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("cannot scan %T into Splits", src)
+}
+
+// Workout is the workout publication a track describes
+func (t *Track) Workout() *WorkoutDetails {
+	w := &WorkoutDetails{Activity: t.Activity, DurationSeconds: t.DurationSeconds}
+	if w.Activity == "" {
+		w.Activity = ActivityOther
+	}
+	if t.DistanceMeters > 0 {
+		w.DistanceMeters = &t.DistanceMeters
+	}
+	if t.HeartRateAvg != nil {
+		w.HeartRate = &HeartRateSummary{Avg: *t.HeartRateAvg, Max: t.HeartRateMax, Min: t.HeartRateMin}
+	}
+	return w
+}
+
+// track response
+type TrackResponse struct {
+	TrackID         string            `json:"track_id"`
+	UserID          string            `json:"user_id"`
+	PostID          *string           `json:"post_id"` // nil while the track is a draft
+	Format          string            `json:"format"`
+	Name            string            `json:"name,omitempty"`
+	Activity        string            `json:"activity"`
+	StartedAt       *time.Time        `json:"started_at,omitempty"`
+	DistanceMeters  float64           `json:"distance_m"`
+	DurationSeconds int               `json:"duration_seconds"`
+	ElevationGain   float64           `json:"elevation_gain_m"`
+	Pace            float64           `json:"pace_s_per_km"`
+	HeartRate       *HeartRateSummary `json:"heart_rate,omitempty"`
+	Polyline        string            `json:"polyline"`
+	Splits          []TrackSplit      `json:"splits"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+// TrackImport is the result of an upload: the stored track and a workout
+// publication prefilled from it, ready to be posted as is or edited
+type TrackImport struct {
+	Track TrackResponse      `json:"track"`
+	Draft PublicationRequest `json:"draft"`
+}
+
+func (t *Track) ToResponse() TrackResponse {
+	out := TrackResponse{
+		TrackID:         t.TrackID,
+		UserID:          t.UserID,
+		Format:          t.Format,
+		Name:            t.Name,
+		Activity:        t.Activity,
+		StartedAt:       t.StartedAt,
+		DistanceMeters:  t.DistanceMeters,
+		DurationSeconds: t.DurationSeconds,
+		ElevationGain:   t.ElevationGain,
+		Pace:            t.Pace,
+		Polyline:        t.Polyline,
+		Splits:          t.Splits,
+		CreatedAt:       t.CreatedAt,
+	}
+	if out.Splits == nil {
+		out.Splits = []TrackSplit{}
+	}
+	out.HeartRate = t.Workout().HeartRate
+	return out
+}
+
+// Draft prefills a workout publication for the track
+func (t *Track) Draft() PublicationRequest {
+	title := t.Name
+	if title == "" {
+		title = "Workout"
+		if t.StartedAt != nil {
+			title += " on " + t.StartedAt.Format(time.DateOnly)
+		}
+	}
+	return PublicationRequest{
+		Title:   title,
+		Type:    PublicationWorkout,
+		Workout: t.Workout(),
+		TrackID: &t.TrackID,
+	}
+}
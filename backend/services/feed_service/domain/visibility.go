@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// who can see a publication and its comments
+const (
+	VisibilityPublic    = "public"    // every signed-in user
+	VisibilityFollowers = "followers" // the author's followers
+	VisibilityPrivate   = "private"   // only the author
+	VisibilityCircle    = "circle"    // members of one of the author's circles
+)
+
+// MaxCircleMembers bounds the size of a circle
+const MaxCircleMembers = 500
+
+// Circle is a named group of users an author can share publications with.
+// Only the owner can list or change a circle.
+type Circle struct {
+	gorm.Model
+	CircleID string `gorm:"type:char(36);uniqueIndex"`
+	UserID   string `gorm:"type:char(36);not null;index"`
+	Name     string `gorm:"size:50;not null"`
+}
+
+// CircleMember puts a user in a circle
+type CircleMember struct {
+	ID        uint   `gorm:"primaryKey"`
+	CircleID  string `gorm:"type:char(36);not null;uniqueIndex:idx_circle_members_pair,priority:1"`
+	MemberID  string `gorm:"type:char(36);not null;uniqueIndex:idx_circle_members_pair,priority:2;index"`
+	CreatedAt time.Time
+}
+
+// create or rename circle request
+type CircleRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+	// MemberIDs seeds a new circle; it is ignored on rename
+	MemberIDs []string `json:"member_ids" validate:"max=500,dive,uuid4"`
+}
+
+func (r *CircleRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("field %q failed on the %q tag", e.Field(), e.Tag())
+		}
+	}
+	return nil
+}
+
+// circle response; MemberIDs is only filled in for a single circle
+type CircleResponse struct {
+	CircleID    string    `json:"circle_id"`
+	UserID      string    `json:"user_id"`
+	Name        string    `json:"name"`
+	MemberCount int64     `json:"member_count"`
+	MemberIDs   []string  `json:"member_ids,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (c *Circle) ToResponse() CircleResponse {
+	return CircleResponse{
+		CircleID:  c.CircleID,
+		UserID:    c.UserID,
+		Name:      c.Name,
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+// validateVisibility checks that a circle is named exactly when the
+// publication is shared with one
+func (r *PublicationRequest) validateVisibility() error {
+	if r.Visibility == VisibilityCircle && r.CircleID == nil {
+		return errors.New("circle_id is required for circle visibility")
+	}
+	if r.Visibility != VisibilityCircle && r.CircleID != nil {
+		return errors.New("circle_id is only allowed with circle visibility")
+	}
+	return nil
+}
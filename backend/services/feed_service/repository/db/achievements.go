@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pgAchievementRepo reads activity across the feed tables and stores badges
+type pgAchievementRepo struct {
+	db *gorm.DB
+}
+
+// NewAchievementRepo constructor
+func NewAchievementRepo(db *gorm.DB) repository.AchievementRepository {
+	return &pgAchievementRepo{db: db}
+}
+
+func (r *pgAchievementRepo) ActivityStats(ctx context.Context, userID string, since time.Time) (domain.ActivityStats, error) {
+	var s domain.ActivityStats
+	err := r.db.WithContext(ctx).Raw(`SELECT
+		(SELECT COUNT(*) FROM publications WHERE user_id = @user AND deleted_at IS NULL) AS publications,
+		(SELECT COUNT(*) FROM comments WHERE user_id = @user AND deleted_at IS NULL AND NOT deleted) AS comments,
+		(SELECT COUNT(*) FROM check_ins WHERE user_id = @user AND deleted_at IS NULL) AS check_ins,
+		(SELECT COUNT(*) FROM goals WHERE user_id = @user AND deleted_at IS NULL AND status = @completed) AS goals_completed,
+		(SELECT COUNT(*) FROM reactions WHERE user_id = @user) AS reactions_given`,
+		map[string]any{"user": userID, "completed": domain.GoalCompleted},
+	).Row().Scan(&s.Publications, &s.Comments, &s.CheckIns, &s.GoalsCompleted, &s.ReactionsGiven)
+	if err != nil {
+		return domain.ActivityStats{}, err
+	}
+
+	if s.PostDays, err = r.activeDays(ctx, "publications", "created_at", userID, since); err != nil {
+		return domain.ActivityStats{}, err
+	}
+	if s.CheckInDays, err = r.activeDays(ctx, "check_ins", "recorded_at", userID, since); err != nil {
+		return domain.ActivityStats{}, err
+	}
+	return s, nil
+}
+
+// activeDays lists distinct UTC days with rows in table, newest first
+func (r *pgAchievementRepo) activeDays(ctx context.Context, table, column, userID string, since time.Time) ([]time.Time, error) {
+	var days []time.Time
+	err := r.db.WithContext(ctx).
+		Table(table).
+		Select("DISTINCT ("+column+" AT TIME ZONE 'UTC')::date AS day").
+		Where("user_id = ? AND deleted_at IS NULL AND "+column+" >= ?", userID, since).
+		Order("day DESC").
+		Pluck("day", &days).Error
+	return days, err
+}
+
+func (r *pgAchievementRepo) AwardBadge(ctx context.Context, b *domain.Badge) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(b)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *pgAchievementRepo) ListBadges(ctx context.Context, userID string) ([]domain.Badge, error) {
+	var badges []domain.Badge
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("awarded_at, id").Find(&badges).Error
+	return badges, err
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pgChallengeRepo handles challenges, participants and entries
+type pgChallengeRepo struct {
+	db *gorm.DB
+}
+
+// NewChallengeRepo constructor
+func NewChallengeRepo(db *gorm.DB) repository.ChallengeRepository {
+	return &pgChallengeRepo{db: db}
+}
+
+func (r *pgChallengeRepo) CreateChallenge(ctx context.Context, c *domain.Challenge) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		// the creator takes part in their own challenge
+		return tx.Create(&domain.ChallengeParticipant{
+			ChallengeID: c.ChallengeID,
+			UserID:      c.CreatorID,
+			JoinedAt:    c.CreatedAt,
+		}).Error
+	})
+}
+
+func (r *pgChallengeRepo) GetChallenge(ctx context.Context, challengeID string) (*domain.Challenge, error) {
+	var c domain.Challenge
+	err := r.db.WithContext(ctx).Where("challenge_id = ?", challengeID).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &c, err
+}
+
+func (r *pgChallengeRepo) ListChallenges(ctx context.Context, status string, now time.Time, after *domain.Cursor, limit int) ([]domain.Challenge, error) {
+	var challenges []domain.Challenge
+	q := r.db.WithContext(ctx)
+	switch status {
+	case domain.ChallengeUpcoming:
+		q = q.Where("starts_at > ?", now)
+	case domain.ChallengeActive:
+		q = q.Where("starts_at <= ? AND ends_at > ?", now, now)
+	case domain.ChallengeFinished:
+		q = q.Where("ends_at <= ?", now)
+	}
+	err := keyset(q, after, limit).Find(&challenges).Error
+	return challenges, err
+}
+
+func (r *pgChallengeRepo) DeleteChallenge(ctx context.Context, challengeID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Publication{}).
+			Where("challenge_id = ?", challengeID).
+			Update("challenge_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("challenge_id = ?", challengeID).Delete(&domain.ChallengeEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("challenge_id = ?", challengeID).Delete(&domain.ChallengeParticipant{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("challenge_id = ?", challengeID).Delete(&domain.Challenge{}).Error
+	})
+}
+
+func (r *pgChallengeRepo) Join(ctx context.Context, p *domain.ChallengeParticipant) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(p).Error
+}
+
+func (r *pgChallengeRepo) Leave(ctx context.Context, challengeID, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("challenge_id = ? AND user_id = ?", challengeID, userID).
+			Delete(&domain.ChallengeEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("challenge_id = ? AND user_id = ?", challengeID, userID).
+			Delete(&domain.ChallengeParticipant{}).Error
+	})
+}
+
+func (r *pgChallengeRepo) IsParticipant(ctx context.Context, challengeID, userID string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.ChallengeParticipant{}).
+		Where("challenge_id = ? AND user_id = ?", challengeID, userID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *pgChallengeRepo) CountParticipants(ctx context.Context, challengeIDs []string) (map[string]int64, error) {
+	out := make(map[string]int64, len(challengeIDs))
+	if len(challengeIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		ChallengeID string
+		Count       int64
+	}
+	err := r.db.WithContext(ctx).Model(&domain.ChallengeParticipant{}).
+		Select("challenge_id, COUNT(*) AS count").
+		Where("challenge_id IN ?", challengeIDs).
+		Group("challenge_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.ChallengeID] = row.Count
+	}
+	return out, nil
+}
+
+func (r *pgChallengeRepo) JoinedChallenges(ctx context.Context, userID string, challengeIDs []string) (map[string]bool, error) {
+	out := make(map[string]bool, len(challengeIDs))
+	if userID == "" || len(challengeIDs) == 0 {
+		return out, nil
+	}
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&domain.ChallengeParticipant{}).
+		Where("user_id = ? AND challenge_id IN ?", userID, challengeIDs).
+		Pluck("challenge_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		out[id] = true
+	}
+	return out, nil
+}
+
+func (r *pgChallengeRepo) AddEntry(ctx context.Context, e *domain.ChallengeEntry) error {
+	return r.db.WithContext(ctx).Create(e).Error
+}
+
+func (r *pgChallengeRepo) GetEntry(ctx context.Context, entryID string) (*domain.ChallengeEntry, error) {
+	var e domain.ChallengeEntry
+	err := r.db.WithContext(ctx).Where("entry_id = ?", entryID).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &e, err
+}
+
+func (r *pgChallengeRepo) DeleteEntry(ctx context.Context, entryID string) error {
+	return r.db.WithContext(ctx).Unscoped().Where("entry_id = ?", entryID).Delete(&domain.ChallengeEntry{}).Error
+}
+
+// standings ranks every participant of the challenge; participants without
+// entries score 0. RANK gives equal scores the same rank, and the earlier
+// last entry wins the tie for display order.
+func (r *pgChallengeRepo) standings(ctx context.Context, c *domain.Challenge) *gorm.DB {
+	agg := "SUM"
+	if c.Aggregate == domain.AggregateMax {
+		agg = "MAX"
+	}
+	scores := r.db.Table("challenge_participants AS p").
+		Select(`p.user_id,
+			COALESCE(`+agg+`(e.value), 0) AS score,
+			COUNT(e.id) AS entries,
+			MAX(e.recorded_at) AS last_entry_at`).
+		Joins("LEFT JOIN challenge_entries AS e ON e.challenge_id = p.challenge_id AND e.user_id = p.user_id AND e.deleted_at IS NULL").
+		Where("p.challenge_id = ?", c.ChallengeID).
+		Group("p.user_id")
+	return r.db.WithContext(ctx).
+		Table("(?) AS s", scores).
+		Select("RANK() OVER (ORDER BY score DESC) AS rank, user_id, score, entries, last_entry_at")
+}
+
+func (r *pgChallengeRepo) Leaderboard(ctx context.Context, c *domain.Challenge, offset, limit int) ([]domain.LeaderboardRow, error) {
+	var rows []domain.LeaderboardRow
+	err := r.standings(ctx, c).
+		Order("rank, last_entry_at NULLS LAST, user_id").
+		Offset(offset).Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *pgChallengeRepo) LeaderboardRow(ctx context.Context, c *domain.Challenge, userID string) (*domain.LeaderboardRow, error) {
+	var rows []domain.LeaderboardRow
+	err := r.db.WithContext(ctx).
+		Table("(?) AS ranked", r.standings(ctx, c)).
+		Where("user_id = ?", userID).
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+func (r *pgChallengeRepo) ListChallengePublications(ctx context.Context, challengeID string, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error) {
+	var pubs []domain.Publication
+	q := filterPublications(r.db.WithContext(ctx).Where("challenge_id = ?", challengeID), filter)
+	err := keyset(q, after, limit).Find(&pubs).Error
+	return pubs, err
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pgCircleRepo keeps circles and their members
+type pgCircleRepo struct {
+	db *gorm.DB
+}
+
+// NewCircleRepo constructor
+func NewCircleRepo(db *gorm.DB) repository.CircleRepository {
+	return &pgCircleRepo{db: db}
+}
+
+// CreateCircle stores the circle with its first members in one transaction
+func (r *pgCircleRepo) CreateCircle(ctx context.Context, c *domain.Circle, memberIDs []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		if len(memberIDs) == 0 {
+			return nil
+		}
+		members := make([]domain.CircleMember, 0, len(memberIDs))
+		for _, id := range memberIDs {
+			members = append(members, domain.CircleMember{CircleID: c.CircleID, MemberID: id})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+	})
+}
+
+func (r *pgCircleRepo) GetCircle(ctx context.Context, circleID string) (*domain.Circle, error) {
+	var c domain.Circle
+	err := r.db.WithContext(ctx).Where("circle_id = ?", circleID).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &c, err
+}
+
+func (r *pgCircleRepo) ListCircles(ctx context.Context, userID string) ([]domain.Circle, error) {
+	var circles []domain.Circle
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("name, id").Find(&circles).Error
+	return circles, err
+}
+
+func (r *pgCircleRepo) RenameCircle(ctx context.Context, circleID, name string) error {
+	return r.db.WithContext(ctx).Model(&domain.Circle{}).
+		Where("circle_id = ?", circleID).
+		Update("name", name).Error
+}
+
+// DeleteCircle falls back to private rather than public so that removing a
+// circle never widens who can see a post
+func (r *pgCircleRepo) DeleteCircle(ctx context.Context, circleID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Publication{}).
+			Where("circle_id = ?", circleID).
+			Updates(map[string]any{"visibility": domain.VisibilityPrivate, "circle_id": nil}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("circle_id = ?", circleID).Delete(&domain.CircleMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("circle_id = ?", circleID).Delete(&domain.Circle{}).Error
+	})
+}
+
+func (r *pgCircleRepo) AddMember(ctx context.Context, circleID, memberID string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.CircleMember{CircleID: circleID, MemberID: memberID}).Error
+}
+
+func (r *pgCircleRepo) RemoveMember(ctx context.Context, circleID, memberID string) error {
+	return r.db.WithContext(ctx).
+		Where("circle_id = ? AND member_id = ?", circleID, memberID).
+		Delete(&domain.CircleMember{}).Error
+}
+
+func (r *pgCircleRepo) IsMember(ctx context.Context, circleID, userID string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.CircleMember{}).
+		Where("circle_id = ? AND member_id = ?", circleID, userID).
+		Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *pgCircleRepo) ListMembers(ctx context.Context, circleID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&domain.CircleMember{}).
+		Where("circle_id = ?", circleID).
+		Order("created_at, id").
+		Pluck("member_id", &ids).Error
+	return ids, err
+}
+
+func (r *pgCircleRepo) CountMembers(ctx context.Context, circleIDs []string) (map[string]int64, error) {
+	out := make(map[string]int64, len(circleIDs))
+	if len(circleIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		CircleID string
+		Count    int64
+	}
+	err := r.db.WithContext(ctx).Model(&domain.CircleMember{}).
+		Select("circle_id, COUNT(*) AS count").
+		Where("circle_id IN ?", circleIDs).
+		Group("circle_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.CircleID] = row.Count
+	}
+	return out, nil
+}
@@ -14,6 +14,7 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -50,10 +51,61 @@ func (r *pgFeedRepo) GetPublication(postID string) (*domain.Publication, error)
 	return &p, err
 }
 
-// ListPublications returns all posts
-func (r *pgFeedRepo) ListPublications() ([]domain.Publication, error) {
+func (r *pgFeedRepo) CreateImportedPublication(ctx context.Context, p *domain.Publication) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "external_id"}},
+			DoNothing: true,
+		}).
+		Create(p)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *pgFeedRepo) GetPublicationByExternalID(ctx context.Context, userID, externalID string) (*domain.Publication, error) {
+	var p domain.Publication
+	err := r.db.WithContext(ctx).Where("user_id = ? AND external_id = ?", userID, externalID).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &p, err
+}
+
+// keyset narrows q to rows after the cursor in (created_at, id) DESC order
+func keyset(q *gorm.DB, after *domain.Cursor, limit int) *gorm.DB {
+	if after != nil {
+		q = q.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	return q.Order("created_at DESC, id DESC").Limit(limit)
+}
+
+// filterPublications applies the optional filters of a publication list
+// and hides what the viewer may not see
+func filterPublications(q *gorm.DB, f domain.PublicationFilter) *gorm.DB {
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+	cond, args := visibleTo(f.ViewerID)
+	return q.Where(cond, args...)
+}
+
+// visibleTo is the condition on the publications table for the posts
+// viewerID may see: public ones, their own, followers-only posts of people
+// they follow and posts shared with a circle they are in
+func visibleTo(viewerID string) (string, []any) {
+	return `(publications.visibility = '` + domain.VisibilityPublic + `'
+		OR publications.user_id = ?
+		OR (publications.visibility = '` + domain.VisibilityFollowers + `'
+			AND publications.user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?))
+		OR (publications.visibility = '` + domain.VisibilityCircle + `'
+			AND publications.circle_id IN (SELECT circle_id FROM circle_members WHERE member_id = ?)))`,
+		[]any{viewerID, viewerID, viewerID}
+}
+
+// ListPublications returns a page of posts, newest first
+func (r *pgFeedRepo) ListPublications(ctx context.Context, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error) {
 	var pubs []domain.Publication
-	err := r.db.Find(&pubs).Error
+	q := filterPublications(r.db.WithContext(ctx), filter)
+	err := keyset(q, after, limit).Find(&pubs).Error
 	return pubs, err
 }
 
@@ -64,7 +116,12 @@ func (r *pgFeedRepo) UpdatePublication(p *domain.Publication) error {
 
 // DeletePublication soft-deletes a post by ID
 func (r *pgFeedRepo) DeletePublication(postID string) error {
-	return r.db.Unscoped().Where("post_id = ?", postID).Delete(&domain.Publication{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteReactions(tx, domain.KindPublication, postID); err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("post_id = ?", postID).Delete(&domain.Publication{}).Error
+	})
 }
 
 // CreateComment on a post
@@ -72,13 +129,77 @@ func (r *pgFeedRepo) CreateComment(c *domain.Comment) error {
 	return r.db.Create(c).Error
 }
 
-// ListComments returns comments for a post
-func (r *pgFeedRepo) ListComments(postID string) ([]domain.Comment, error) {
+// ListComments returns a page of comments for a post, newest first
+func (r *pgFeedRepo) ListComments(ctx context.Context, postID string, after *domain.Cursor, limit int) ([]domain.Comment, error) {
 	var comments []domain.Comment
-	err := r.db.Where("post_id = ?", postID).Find(&comments).Error
+	q := r.db.WithContext(ctx).Where("post_id = ?", postID)
+	err := keyset(q, after, limit).Find(&comments).Error
 	return comments, err
 }
 
+// ListRootComments returns a page of top-level comments, newest first
+func (r *pgFeedRepo) ListRootComments(ctx context.Context, postID string, after *domain.Cursor, limit int) ([]domain.Comment, error) {
+	var comments []domain.Comment
+	q := r.db.WithContext(ctx).Where("post_id = ? AND parent_comment_id IS NULL", postID)
+	err := keyset(q, after, limit).Find(&comments).Error
+	return comments, err
+}
+
+// ListThreadReplies loads the oldest replies of each thread in one query.
+// A reply is always newer than its parent, so the replies kept form a
+// connected tree.
+func (r *pgFeedRepo) ListThreadReplies(ctx context.Context, rootIDs []string, perThread int) ([]domain.Comment, error) {
+	var replies []domain.Comment
+	if len(rootIDs) == 0 {
+		return replies, nil
+	}
+	numbered := r.db.WithContext(ctx).Model(&domain.Comment{}).
+		Select("comments.*, ROW_NUMBER() OVER (PARTITION BY root_comment_id ORDER BY created_at, id) AS n").
+		Where("root_comment_id IN ?", rootIDs)
+	err := r.db.WithContext(ctx).
+		Table("(?) AS comments", numbered).
+		Where("n <= ?", perThread).
+		Order("created_at, id").
+		Find(&replies).Error
+	return replies, err
+}
+
+// ListReplies returns a page of a thread's replies, oldest first
+func (r *pgFeedRepo) ListReplies(ctx context.Context, rootID string, after *domain.Cursor, limit int) ([]domain.Comment, error) {
+	var replies []domain.Comment
+	q := r.db.WithContext(ctx).Where("root_comment_id = ?", rootID)
+	if after != nil {
+		q = q.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+	err := q.Order("created_at, id").Limit(limit).Find(&replies).Error
+	return replies, err
+}
+
+// CountReplies groups direct replies by parent
+func (r *pgFeedRepo) CountReplies(ctx context.Context, commentIDs []string) (map[string]int, error) {
+	out := make(map[string]int, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		ParentCommentID string
+		Count           int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&domain.Comment{}).
+		Select("parent_comment_id, COUNT(*) AS count").
+		Where("parent_comment_id IN ?", commentIDs).
+		Group("parent_comment_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.ParentCommentID] = row.Count
+	}
+	return out, nil
+}
+
 // GetComment by comment ID
 func (r *pgFeedRepo) GetComment(commentID string) (*domain.Comment, error) {
 	var c domain.Comment
@@ -96,7 +217,51 @@ func (r *pgFeedRepo) UpdateComment(c *domain.Comment) error {
 
 // DeleteComment removes a comment by ID
 func (r *pgFeedRepo) DeleteComment(commentID string) error {
-	return r.db.Unscoped().Where("comment_id = ?", commentID).Delete(&domain.Comment{}).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var c domain.Comment
+		if err := tx.Where("comment_id = ?", commentID).First(&c).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return repository.ErrNotFound
+			}
+			return err
+		}
+		if err := deleteReactions(tx, domain.KindComment, commentID); err != nil {
+			return err
+		}
+
+		var replies int64
+		if err := tx.Model(&domain.Comment{}).Where("parent_comment_id = ?", commentID).Count(&replies).Error; err != nil {
+			return err
+		}
+		if replies > 0 {
+			return tx.Model(&c).Updates(map[string]any{"deleted": true, "content": ""}).Error
+		}
+
+		// walk up, dropping tombstones that no longer hold up any replies
+		for {
+			if err := tx.Unscoped().Delete(&c).Error; err != nil {
+				return err
+			}
+			if c.ParentCommentID == nil {
+				return nil
+			}
+			parentID := *c.ParentCommentID
+			c = domain.Comment{}
+			err := tx.Where("comment_id = ? AND deleted", parentID).First(&c).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&domain.Comment{}).Where("parent_comment_id = ?", parentID).Count(&replies).Error; err != nil {
+				return err
+			}
+			if replies > 0 {
+				return nil
+			}
+		}
+	})
 }
 
 func InitDB(dbConfig config.DBConfig) (*gorm.DB, error) {
@@ -142,19 +307,57 @@ func InitDB(dbConfig config.DBConfig) (*gorm.DB, error) {
 	if err := db.AutoMigrate(
 		&domain.Publication{},
 		&domain.Comment{},
+		&domain.Reaction{},
+		&domain.Follow{},
+		&domain.Goal{},
+		&domain.CheckIn{},
+		&domain.Badge{},
+		&domain.Challenge{},
+		&domain.ChallengeParticipant{},
+		&domain.ChallengeEntry{},
+		&domain.Track{},
+		&domain.Media{},
+		&domain.Circle{},
+		&domain.CircleMember{},
 	); err != nil {
 		return nil, fmt.Errorf("%w: %v", repository.ErrDBMigration, err)
 	}
+	// keyset pagination indexes, matching the ORDER BY of the list queries
+	for _, stmt := range []string{
+		`CREATE INDEX IF NOT EXISTS idx_publications_feed ON publications (created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_publications_user_feed ON publications (user_id, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_publications_type_feed ON publications (type, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_post_feed ON comments (post_id, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_follows_follower ON follows (follower_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows (followee_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_goals_user_feed ON goals (user_id, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_check_ins_goal_feed ON check_ins (goal_id, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_tracks_user_feed ON tracks (user_id, created_at DESC, id DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_user ON comments (user_id) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_check_ins_user_day ON check_ins (user_id, recorded_at DESC) WHERE deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_reactions_user ON reactions (user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_publications_challenge_feed ON publications (challenge_id, created_at DESC, id DESC) WHERE deleted_at IS NULL AND challenge_id IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_post_roots ON comments (post_id, created_at DESC, id DESC) WHERE deleted_at IS NULL AND parent_comment_id IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_thread ON comments (root_comment_id, created_at, id) WHERE deleted_at IS NULL AND root_comment_id IS NOT NULL`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", repository.ErrDBMigration, err)
+		}
+	}
+	for _, stmt := range searchMigrations {
+		if err := db.Exec(stmt).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", repository.ErrDBMigration, err)
+		}
+	}
 
 	log.Println("Database connection established and migrations applied")
 	return db, nil
 }
 
-func (r *pgFeedRepo) ListPublicationsByUser(ctx context.Context, userID string) ([]domain.Publication, error) {
+func (r *pgFeedRepo) ListPublicationsByUser(ctx context.Context, userID string, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error) {
 	var pubs []domain.Publication
-	err := r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
+	q := filterPublications(r.db.WithContext(ctx).Where("user_id = ?", userID), filter)
+	err := keyset(q, after, limit).
 		Find(&pubs).
 		Error
 	if err != nil {
@@ -165,3 +368,16 @@ func (r *pgFeedRepo) ListPublicationsByUser(ctx context.Context, userID string)
 	}
 	return pubs, nil
 }
+
+func (r *pgFeedRepo) RenameAuthor(ctx context.Context, userID, name string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Publication{}).
+			Where("user_id = ?", userID).
+			Update("name", name).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Comment{}).
+			Where("user_id = ?", userID).
+			Update("name", name).Error
+	})
+}
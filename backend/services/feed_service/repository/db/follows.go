@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pgFollowRepo keeps the follow graph in the follows table
+type pgFollowRepo struct {
+	db *gorm.DB
+}
+
+// NewFollowRepo constructor
+func NewFollowRepo(db *gorm.DB) repository.FollowRepository {
+	return &pgFollowRepo{db: db}
+}
+
+func (r *pgFollowRepo) Follow(ctx context.Context, followerID, followeeID string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.Follow{FollowerID: followerID, FolloweeID: followeeID}).Error
+}
+
+func (r *pgFollowRepo) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	return r.db.WithContext(ctx).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&domain.Follow{}).Error
+}
+
+func (r *pgFollowRepo) IsFollowing(ctx context.Context, followerID, followeeID string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *pgFollowRepo) CountFollowers(ctx context.Context, userID string) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Follow{}).Where("followee_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+func (r *pgFollowRepo) CountFollowing(ctx context.Context, userID string) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Follow{}).Where("follower_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+func (r *pgFollowRepo) ListFollowers(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Follow, error) {
+	var follows []domain.Follow
+	q := r.db.WithContext(ctx).Where("followee_id = ?", userID)
+	err := keyset(q, after, limit).Find(&follows).Error
+	return follows, err
+}
+
+func (r *pgFollowRepo) ListFollowing(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Follow, error) {
+	var follows []domain.Follow
+	q := r.db.WithContext(ctx).Where("follower_id = ?", userID)
+	err := keyset(q, after, limit).Find(&follows).Error
+	return follows, err
+}
+
+// Timeline reads each author's newest publications from
+// idx_publications_user_feed, one LATERAL subquery per author, and merges
+// them. Each author contributes at most limit rows, so the cost grows with
+// the number of follows times the page size rather than with the history
+// of the whole feed, and followees who rarely post cost one index probe.
+func (r *pgFollowRepo) Timeline(ctx context.Context, userID string, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error) {
+	var pubs []domain.Publication
+	followees := r.db.Model(&domain.Follow{}).Select("followee_id").Where("follower_id = ?", userID)
+	newest := keyset(filterPublications(r.db.Model(&domain.Publication{}).Where("publications.user_id = authors.author_id"), filter), after, limit)
+	err := r.db.WithContext(ctx).
+		Table("(SELECT ? AS author_id UNION ALL ?) AS authors CROSS JOIN LATERAL (?) AS publications", userID, followees, newest).
+		Select("publications.*").
+		Order("publications.created_at DESC, publications.id DESC").
+		Limit(limit).
+		Find(&pubs).Error
+	return pubs, err
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+)
+
+// pgGoalRepo handles goals and check-ins
+type pgGoalRepo struct {
+	db *gorm.DB
+}
+
+// NewGoalRepo constructor
+func NewGoalRepo(db *gorm.DB) repository.GoalRepository {
+	return &pgGoalRepo{db: db}
+}
+
+func (r *pgGoalRepo) CreateGoal(ctx context.Context, g *domain.Goal) error {
+	return r.db.WithContext(ctx).Create(g).Error
+}
+
+func (r *pgGoalRepo) GetGoal(ctx context.Context, goalID string) (*domain.Goal, error) {
+	var g domain.Goal
+	err := r.db.WithContext(ctx).Where("goal_id = ?", goalID).First(&g).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &g, err
+}
+
+func (r *pgGoalRepo) GetGoals(ctx context.Context, goalIDs []string) (map[string]domain.Goal, error) {
+	out := make(map[string]domain.Goal, len(goalIDs))
+	if len(goalIDs) == 0 {
+		return out, nil
+	}
+	var goals []domain.Goal
+	if err := r.db.WithContext(ctx).Where("goal_id IN ?", goalIDs).Find(&goals).Error; err != nil {
+		return nil, err
+	}
+	for _, g := range goals {
+		out[g.GoalID] = g
+	}
+	return out, nil
+}
+
+// ListGoals returns a page of a user's goals, newest first
+func (r *pgGoalRepo) ListGoals(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Goal, error) {
+	var goals []domain.Goal
+	q := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	err := keyset(q, after, limit).Find(&goals).Error
+	return goals, err
+}
+
+func (r *pgGoalRepo) UpdateGoal(ctx context.Context, g *domain.Goal) error {
+	return r.db.WithContext(ctx).Save(g).Error
+}
+
+func (r *pgGoalRepo) DeleteGoal(ctx context.Context, goalID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Publication{}).
+			Where("goal_id = ?", goalID).
+			Updates(map[string]any{"goal_id": nil, "check_in_id": nil}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("goal_id = ?", goalID).Delete(&domain.CheckIn{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("goal_id = ?", goalID).Delete(&domain.Goal{}).Error
+	})
+}
+
+func (r *pgGoalRepo) AddCheckIn(ctx context.Context, c *domain.CheckIn) error {
+	return r.db.WithContext(ctx).Create(c).Error
+}
+
+func (r *pgGoalRepo) GetCheckIn(ctx context.Context, checkInID string) (*domain.CheckIn, error) {
+	var c domain.CheckIn
+	err := r.db.WithContext(ctx).Where("check_in_id = ?", checkInID).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &c, err
+}
+
+func (r *pgGoalRepo) GetCheckIns(ctx context.Context, checkInIDs []string) (map[string]domain.CheckIn, error) {
+	out := make(map[string]domain.CheckIn, len(checkInIDs))
+	if len(checkInIDs) == 0 {
+		return out, nil
+	}
+	var checkIns []domain.CheckIn
+	if err := r.db.WithContext(ctx).Where("check_in_id IN ?", checkInIDs).Find(&checkIns).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range checkIns {
+		out[c.CheckInID] = c
+	}
+	return out, nil
+}
+
+// ListCheckIns returns a page of a goal's check-ins, newest first
+func (r *pgGoalRepo) ListCheckIns(ctx context.Context, goalID string, after *domain.Cursor, limit int) ([]domain.CheckIn, error) {
+	var checkIns []domain.CheckIn
+	q := r.db.WithContext(ctx).Where("goal_id = ?", goalID)
+	err := keyset(q, after, limit).Find(&checkIns).Error
+	return checkIns, err
+}
+
+func (r *pgGoalRepo) DeleteCheckIn(ctx context.Context, checkInID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Publication{}).
+			Where("check_in_id = ?", checkInID).
+			Update("check_in_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("check_in_id = ?", checkInID).Delete(&domain.CheckIn{}).Error
+	})
+}
+
+func (r *pgGoalRepo) GoalProgress(ctx context.Context, goalID string, cumulative bool) (float64, bool, error) {
+	var row struct {
+		Value float64
+		N     int64
+	}
+	q := r.db.WithContext(ctx).Model(&domain.CheckIn{}).Where("goal_id = ?", goalID)
+	var err error
+	if cumulative {
+		err = q.Select("COALESCE(SUM(value), 0) AS value, COUNT(*) AS n").Scan(&row).Error
+	} else {
+		err = q.Select("value, 1 AS n").Order("recorded_at DESC, id DESC").Limit(1).Scan(&row).Error
+	}
+	return row.Value, row.N > 0, err
+}
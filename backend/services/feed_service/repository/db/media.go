@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pgMediaRepo handles publication attachments
+type pgMediaRepo struct {
+	db *gorm.DB
+}
+
+// NewMediaRepo constructor
+func NewMediaRepo(db *gorm.DB) repository.MediaRepository {
+	return &pgMediaRepo{db: db}
+}
+
+func (r *pgMediaRepo) CreateMedia(ctx context.Context, m *domain.Media) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *pgMediaRepo) GetMedia(ctx context.Context, mediaID string) (*domain.Media, error) {
+	var m domain.Media
+	err := r.db.WithContext(ctx).Where("media_id = ?", mediaID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &m, err
+}
+
+func (r *pgMediaRepo) CountPostMedia(ctx context.Context, postID string) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Media{}).Where("post_id = ?", postID).Count(&n).Error
+	return n, err
+}
+
+func (r *pgMediaRepo) ListPostMedia(ctx context.Context, postIDs []string) (map[string][]domain.Media, error) {
+	out := make(map[string][]domain.Media, len(postIDs))
+	if len(postIDs) == 0 {
+		return out, nil
+	}
+	var list []domain.Media
+	err := r.db.WithContext(ctx).
+		Where("post_id IN ?", postIDs).
+		Order("created_at, id").
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range list {
+		out[m.PostID] = append(out[m.PostID], m)
+	}
+	return out, nil
+}
+
+func (r *pgMediaRepo) DeleteMedia(ctx context.Context, mediaID string) error {
+	return r.db.WithContext(ctx).Unscoped().Where("media_id = ?", mediaID).Delete(&domain.Media{}).Error
+}
+
+func (r *pgMediaRepo) DeletePostMedia(ctx context.Context, postID string) ([]domain.Media, error) {
+	var list []domain.Media
+	err := r.db.WithContext(ctx).Unscoped().
+		Clauses(clause.Returning{}).
+		Where("post_id = ?", postID).
+		Delete(&list).Error
+	return list, err
+}
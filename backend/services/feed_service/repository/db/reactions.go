@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+
+	"feed_service/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddReaction inserts the reaction unless the user already left it
+func (r *pgFeedRepo) AddReaction(ctx context.Context, reaction *domain.Reaction) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(reaction).Error
+}
+
+// RemoveReaction deletes one reaction of a user
+func (r *pgFeedRepo) RemoveReaction(ctx context.Context, targetType, targetID, userID, reactionType string) error {
+	return r.db.WithContext(ctx).
+		Where("target_type = ? AND target_id = ? AND user_id = ? AND type = ?", targetType, targetID, userID, reactionType).
+		Delete(&domain.Reaction{}).Error
+}
+
+// CountReactions groups reactions of all targets at once so list pages
+// cost a single extra query
+func (r *pgFeedRepo) CountReactions(ctx context.Context, targetType string, targetIDs []string, viewerID string) (map[string][]domain.ReactionCount, error) {
+	out := make(map[string][]domain.ReactionCount, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return out, nil
+	}
+
+	var rows []struct {
+		TargetID    string
+		Type        string
+		Count       int
+		ReactedByMe bool
+	}
+	err := r.db.WithContext(ctx).
+		Model(&domain.Reaction{}).
+		Select("target_id, type, COUNT(*) AS count, COALESCE(BOOL_OR(user_id = ?), false) AS reacted_by_me", viewerID).
+		Where("target_type = ? AND target_id IN ?", targetType, targetIDs).
+		Group("target_id, type").
+		Order("count DESC, type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.TargetID] = append(out[row.TargetID], domain.ReactionCount{
+			Type:        row.Type,
+			Count:       row.Count,
+			ReactedByMe: row.ReactedByMe,
+		})
+	}
+	return out, nil
+}
+
+// deleteReactions drops the reactions of a target that is being deleted
+func deleteReactions(tx *gorm.DB, targetType, targetID string) error {
+	return tx.Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Delete(&domain.Reaction{}).Error
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+)
+
+// searchConfig is the text search configuration used both by the generated
+// tsvector columns and by the queries; changing it requires rebuilding them
+const searchConfig = "english"
+
+// searchMigrations add generated tsvector columns with GIN indexes.
+// Titles weigh more than bodies in the ranking.
+var searchMigrations = []string{
+	`ALTER TABLE publications ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('` + searchConfig + `', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('` + searchConfig + `', coalesce(content, '')), 'B')
+	) STORED`,
+	`ALTER TABLE comments ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+		to_tsvector('` + searchConfig + `', coalesce(content, ''))
+	) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_publications_search ON publications USING GIN (search) WHERE deleted_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_comments_search ON comments USING GIN (search) WHERE deleted_at IS NULL`,
+}
+
+// pgSearchRepo runs full-text queries against the generated columns
+type pgSearchRepo struct {
+	db *gorm.DB
+}
+
+// NewSearchRepo constructor
+func NewSearchRepo(db *gorm.DB) repository.SearchRepository {
+	return &pgSearchRepo{db: db}
+}
+
+// Search ranks matches with ts_rank and only builds ts_headline snippets
+// for the rows of the requested page
+func (r *pgSearchRepo) Search(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, error) {
+	var (
+		parts []string
+		args  []any
+	)
+	visible, visibleArgs := visibleTo(q.ViewerID)
+	if q.Kind == "" || q.Kind == domain.KindPublication {
+		sql, a := searchSelect("publications", "'"+domain.KindPublication+"'", "post_id", "title", q)
+		parts, args = append(parts, sql+" AND "+visible), append(append(args, a...), visibleArgs...)
+	}
+	if q.Kind == "" || q.Kind == domain.KindComment {
+		// comments are visible with their publication
+		sql, a := searchSelect("comments", "'"+domain.KindComment+"'", "comment_id", "''", q)
+		sql += " AND post_id IN (SELECT post_id FROM publications WHERE deleted_at IS NULL AND " + visible + ")"
+		parts, args = append(parts, sql), append(append(args, a...), visibleArgs...)
+	}
+
+	headline := "StartSel=" + domain.HighlightStart + ", StopSel=" + domain.HighlightStop +
+		", MaxWords=30, MinWords=10, MaxFragments=2"
+	sql := `SELECT kind, id, post_id, user_id, name, title, rank, created_at,
+		ts_headline('` + searchConfig + `', body, websearch_to_tsquery('` + searchConfig + `', ?), ?) AS snippet
+	FROM (` + strings.Join(parts, " UNION ALL ") + `
+		ORDER BY rank DESC, created_at DESC LIMIT ? OFFSET ?) hits
+	ORDER BY rank DESC, created_at DESC`
+	args = append([]any{q.Text, headline}, args...)
+	args = append(args, q.Limit, q.Offset)
+
+	var hits []domain.SearchHit
+	err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&hits).Error
+	return hits, err
+}
+
+// searchSelect builds one side of the UNION with the optional filters
+func searchSelect(table, kind, idCol, titleCol string, q domain.SearchQuery) (string, []any) {
+	sql := `SELECT ` + kind + ` AS kind, ` + idCol + ` AS id, post_id, user_id, name, ` + titleCol + ` AS title,
+		content AS body, ts_rank(search, websearch_to_tsquery('` + searchConfig + `', ?)) AS rank, created_at
+	FROM ` + table + `
+	WHERE deleted_at IS NULL AND search @@ websearch_to_tsquery('` + searchConfig + `', ?)`
+	args := []any{q.Text, q.Text}
+	if q.AuthorID != "" {
+		sql += ` AND user_id = ?`
+		args = append(args, q.AuthorID)
+	}
+	if q.From != nil {
+		sql += ` AND created_at >= ?`
+		args = append(args, *q.From)
+	}
+	if q.To != nil {
+		sql += ` AND created_at < ?`
+		args = append(args, *q.To)
+	}
+	return sql, args
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+
+	"gorm.io/gorm"
+)
+
+// pgTrackRepo handles uploaded GPS tracks
+type pgTrackRepo struct {
+	db *gorm.DB
+}
+
+// NewTrackRepo constructor
+func NewTrackRepo(db *gorm.DB) repository.TrackRepository {
+	return &pgTrackRepo{db: db}
+}
+
+func (r *pgTrackRepo) CreateTrack(ctx context.Context, t *domain.Track) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *pgTrackRepo) GetTrack(ctx context.Context, trackID string) (*domain.Track, error) {
+	var t domain.Track
+	err := r.db.WithContext(ctx).Where("track_id = ?", trackID).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &t, err
+}
+
+func (r *pgTrackRepo) GetTracks(ctx context.Context, trackIDs []string) (map[string]domain.Track, error) {
+	out := make(map[string]domain.Track, len(trackIDs))
+	if len(trackIDs) == 0 {
+		return out, nil
+	}
+	var tracks []domain.Track
+	if err := r.db.WithContext(ctx).Where("track_id IN ?", trackIDs).Find(&tracks).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range tracks {
+		out[t.TrackID] = t
+	}
+	return out, nil
+}
+
+// ListTracks returns a page of a user's tracks, newest first
+func (r *pgTrackRepo) ListTracks(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Track, error) {
+	var tracks []domain.Track
+	q := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	err := keyset(q, after, limit).Find(&tracks).Error
+	return tracks, err
+}
+
+func (r *pgTrackRepo) TrackPosts(ctx context.Context, trackIDs []string) (map[string]string, error) {
+	out := make(map[string]string, len(trackIDs))
+	if len(trackIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		TrackID string
+		PostID  string
+	}
+	err := r.db.WithContext(ctx).Model(&domain.Publication{}).
+		Select("track_id, post_id").
+		Where("track_id IN ?", trackIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.TrackID] = row.PostID
+	}
+	return out, nil
+}
+
+func (r *pgTrackRepo) DeleteTrack(ctx context.Context, trackID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Publication{}).
+			Where("track_id = ?", trackID).
+			Update("track_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("track_id = ?", trackID).Delete(&domain.Track{}).Error
+	})
+}
@@ -1,9 +1,15 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+
+	"blob"
+)
 
 var (
 	ErrNotFound     = errors.New("record not found")
 	ErrDBConnection = errors.New("DB connection failed")
 	ErrDBMigration  = errors.New("DB migrations failed")
+	// ErrBlobNotFound is what BlobStore.Get returns for a missing key
+	ErrBlobNotFound = blob.ErrNotFound
 )
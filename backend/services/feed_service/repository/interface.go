@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"blob"
 	"feed_service/domain"
 )
 
@@ -11,15 +13,176 @@ import (
 type FeedRepository interface {
 	CreatePublication(pub *domain.Publication) error
 	GetPublication(postID string) (*domain.Publication, error)
-	ListPublications() ([]domain.Publication, error)
+	// CreateImportedPublication stores an imported workout unless the
+	// author already has one with the same ExternalID; it reports whether
+	// a row was added
+	CreateImportedPublication(ctx context.Context, pub *domain.Publication) (bool, error)
+	GetPublicationByExternalID(ctx context.Context, userID, externalID string) (*domain.Publication, error)
+	// List methods return up to limit rows after the cursor, newest first;
+	// publication lists are narrowed by filter
+	ListPublications(ctx context.Context, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error)
 	UpdatePublication(pub *domain.Publication) error
 	DeletePublication(postID string) error
-	ListPublicationsByUser(ctx context.Context, userID string) ([]domain.Publication, error)
+	ListPublicationsByUser(ctx context.Context, userID string, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error)
 
 	CreateComment(cmt *domain.Comment) error
-	ListComments(postID string) ([]domain.Comment, error)
+	ListComments(ctx context.Context, postID string, after *domain.Cursor, limit int) ([]domain.Comment, error)
+	// ListRootComments pages through the top-level comments of a post
+	ListRootComments(ctx context.Context, postID string, after *domain.Cursor, limit int) ([]domain.Comment, error)
+	// ListThreadReplies returns the first perThread replies under each of the
+	// given top-level comments, oldest first
+	ListThreadReplies(ctx context.Context, rootIDs []string, perThread int) ([]domain.Comment, error)
+	// ListReplies pages through the replies under a top-level comment, oldest first
+	ListReplies(ctx context.Context, rootID string, after *domain.Cursor, limit int) ([]domain.Comment, error)
+	// CountReplies counts direct replies of each comment, keyed by comment ID
+	CountReplies(ctx context.Context, commentIDs []string) (map[string]int, error)
 	GetComment(commentID string) (*domain.Comment, error)
 	UpdateComment(cmt *domain.Comment) error
+	// DeleteComment removes a comment, or turns it into a tombstone while it
+	// still has replies; tombstones left without replies are removed as well
 	DeleteComment(commentID string) error
 	Health(ctx context.Context) error
+
+	// AddReaction stores a reaction; adding an existing one is a no-op
+	AddReaction(ctx context.Context, r *domain.Reaction) error
+	// RemoveReaction deletes a reaction; removing a missing one is a no-op
+	RemoveReaction(ctx context.Context, targetType, targetID, userID, reactionType string) error
+	// CountReactions aggregates reactions of many targets in one query,
+	// flagging the types viewerID has left, keyed by target ID
+	CountReactions(ctx context.Context, targetType string, targetIDs []string, viewerID string) (map[string][]domain.ReactionCount, error)
+
+	// RenameAuthor rewrites the denormalized author name on all of a user's posts and comments
+	RenameAuthor(ctx context.Context, userID, name string) error
+}
+
+// FollowRepository stores the follow graph and serves the home timeline
+type FollowRepository interface {
+	// Follow and Unfollow are idempotent
+	Follow(ctx context.Context, followerID, followeeID string) error
+	Unfollow(ctx context.Context, followerID, followeeID string) error
+	IsFollowing(ctx context.Context, followerID, followeeID string) (bool, error)
+	CountFollowers(ctx context.Context, userID string) (int64, error)
+	CountFollowing(ctx context.Context, userID string) (int64, error)
+	// ListFollowers and ListFollowing page through edges, newest first
+	ListFollowers(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Follow, error)
+	ListFollowing(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Follow, error)
+	// Timeline pages through publications of userID and everyone they follow
+	Timeline(ctx context.Context, userID string, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error)
+}
+
+// GoalRepository stores goals and their check-ins
+type GoalRepository interface {
+	CreateGoal(ctx context.Context, g *domain.Goal) error
+	GetGoal(ctx context.Context, goalID string) (*domain.Goal, error)
+	// GetGoals loads many goals at once, keyed by goal ID
+	GetGoals(ctx context.Context, goalIDs []string) (map[string]domain.Goal, error)
+	ListGoals(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Goal, error)
+	UpdateGoal(ctx context.Context, g *domain.Goal) error
+	// DeleteGoal removes the goal, its check-ins and detaches it from publications
+	DeleteGoal(ctx context.Context, goalID string) error
+
+	AddCheckIn(ctx context.Context, c *domain.CheckIn) error
+	GetCheckIn(ctx context.Context, checkInID string) (*domain.CheckIn, error)
+	// GetCheckIns loads many check-ins at once, keyed by check-in ID
+	GetCheckIns(ctx context.Context, checkInIDs []string) (map[string]domain.CheckIn, error)
+	ListCheckIns(ctx context.Context, goalID string, after *domain.Cursor, limit int) ([]domain.CheckIn, error)
+	// DeleteCheckIn removes the check-in and detaches it from publications
+	DeleteCheckIn(ctx context.Context, checkInID string) error
+	// GoalProgress aggregates the check-ins of a goal: their sum when
+	// cumulative, else the latest value; ok is false without check-ins
+	GoalProgress(ctx context.Context, goalID string, cumulative bool) (value float64, ok bool, err error)
+}
+
+// ChallengeRepository stores challenges, their participants and entries
+type ChallengeRepository interface {
+	CreateChallenge(ctx context.Context, c *domain.Challenge) error
+	GetChallenge(ctx context.Context, challengeID string) (*domain.Challenge, error)
+	// ListChallenges pages through challenges by status at now, newest first;
+	// an empty status lists all
+	ListChallenges(ctx context.Context, status string, now time.Time, after *domain.Cursor, limit int) ([]domain.Challenge, error)
+	// DeleteChallenge removes participants and entries and untags publications
+	DeleteChallenge(ctx context.Context, challengeID string) error
+
+	// Join is idempotent; Leave also drops the user's entries
+	Join(ctx context.Context, p *domain.ChallengeParticipant) error
+	Leave(ctx context.Context, challengeID, userID string) error
+	IsParticipant(ctx context.Context, challengeID, userID string) (bool, error)
+	// CountParticipants counts participants of many challenges, keyed by challenge ID
+	CountParticipants(ctx context.Context, challengeIDs []string) (map[string]int64, error)
+	// JoinedChallenges reports which of challengeIDs userID has joined
+	JoinedChallenges(ctx context.Context, userID string, challengeIDs []string) (map[string]bool, error)
+
+	AddEntry(ctx context.Context, e *domain.ChallengeEntry) error
+	GetEntry(ctx context.Context, entryID string) (*domain.ChallengeEntry, error)
+	DeleteEntry(ctx context.Context, entryID string) error
+
+	// Leaderboard ranks all participants by aggregated entries
+	Leaderboard(ctx context.Context, c *domain.Challenge, offset, limit int) ([]domain.LeaderboardRow, error)
+	// LeaderboardRow returns the standing of one participant
+	LeaderboardRow(ctx context.Context, c *domain.Challenge, userID string) (*domain.LeaderboardRow, error)
+	// ListChallengePublications pages through publications tagged to the challenge
+	ListChallengePublications(ctx context.Context, challengeID string, filter domain.PublicationFilter, after *domain.Cursor, limit int) ([]domain.Publication, error)
+}
+
+// TrackRepository stores uploaded GPS tracks
+type TrackRepository interface {
+	CreateTrack(ctx context.Context, t *domain.Track) error
+	GetTrack(ctx context.Context, trackID string) (*domain.Track, error)
+	// GetTracks loads many tracks at once, keyed by track ID
+	GetTracks(ctx context.Context, trackIDs []string) (map[string]domain.Track, error)
+	ListTracks(ctx context.Context, userID string, after *domain.Cursor, limit int) ([]domain.Track, error)
+	// TrackPosts maps the given tracks to the publications they are attached to
+	TrackPosts(ctx context.Context, trackIDs []string) (map[string]string, error)
+	// DeleteTrack removes the track and detaches it from its publication
+	DeleteTrack(ctx context.Context, trackID string) error
+}
+
+// CircleRepository stores the circles authors share publications with
+type CircleRepository interface {
+	CreateCircle(ctx context.Context, c *domain.Circle, memberIDs []string) error
+	GetCircle(ctx context.Context, circleID string) (*domain.Circle, error)
+	ListCircles(ctx context.Context, userID string) ([]domain.Circle, error)
+	RenameCircle(ctx context.Context, circleID, name string) error
+	// DeleteCircle removes the circle and makes its publications private
+	DeleteCircle(ctx context.Context, circleID string) error
+
+	// AddMember is idempotent; RemoveMember of a non-member is a no-op
+	AddMember(ctx context.Context, circleID, memberID string) error
+	RemoveMember(ctx context.Context, circleID, memberID string) error
+	IsMember(ctx context.Context, circleID, userID string) (bool, error)
+	ListMembers(ctx context.Context, circleID string) ([]string, error)
+	// CountMembers counts the members of many circles, keyed by circle ID
+	CountMembers(ctx context.Context, circleIDs []string) (map[string]int64, error)
+}
+
+// MediaRepository stores the attachment records of publications
+type MediaRepository interface {
+	CreateMedia(ctx context.Context, m *domain.Media) error
+	GetMedia(ctx context.Context, mediaID string) (*domain.Media, error)
+	CountPostMedia(ctx context.Context, postID string) (int64, error)
+	// ListPostMedia loads the attachments of many posts, oldest first, keyed by post ID
+	ListPostMedia(ctx context.Context, postIDs []string) (map[string][]domain.Media, error)
+	DeleteMedia(ctx context.Context, mediaID string) error
+	// DeletePostMedia removes the records of a post and returns them so
+	// their files can be removed too
+	DeletePostMedia(ctx context.Context, postID string) ([]domain.Media, error)
+}
+
+// BlobStore keeps uploaded files by key, see the shared blob module.
+// Get returns ErrBlobNotFound for a missing key.
+type BlobStore = blob.Store
+
+// AchievementRepository reads activity for badge rules and stores earned badges
+type AchievementRepository interface {
+	// ActivityStats counts the user's activity; day lists start at since
+	ActivityStats(ctx context.Context, userID string, since time.Time) (domain.ActivityStats, error)
+	// AwardBadge stores the badge and reports whether it is new
+	AwardBadge(ctx context.Context, b *domain.Badge) (bool, error)
+	ListBadges(ctx context.Context, userID string) ([]domain.Badge, error)
+}
+
+// SearchRepository finds publications and comments matching a text query,
+// best matches first
+type SearchRepository interface {
+	Search(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, error)
 }
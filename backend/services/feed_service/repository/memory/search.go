@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+)
+
+// SearchRepo is an in-memory repository.SearchRepository for tests.
+// It matches whole words case-insensitively and ranks by the number of
+// matched occurrences, titles counting double. Visibility follows the
+// database: public publications, the viewer's own, followers-only ones of
+// authors set up with Follow and circle ones of circles set up with
+// AddCircleMember. Comments are shown with an indexed publication the
+// viewer can see.
+type SearchRepo struct {
+	mu       sync.RWMutex
+	pubs     map[string]domain.Publication
+	comments map[string]domain.Comment
+	follows  map[[2]string]bool // follower, followee
+	members  map[[2]string]bool // circle, member
+}
+
+var _ repository.SearchRepository = (*SearchRepo)(nil)
+
+// NewSearchRepo constructor
+func NewSearchRepo() *SearchRepo {
+	return &SearchRepo{
+		pubs:     make(map[string]domain.Publication),
+		comments: make(map[string]domain.Comment),
+		follows:  make(map[[2]string]bool),
+		members:  make(map[[2]string]bool),
+	}
+}
+
+// Follow lets followerID see the followers-only publications of followeeID
+func (r *SearchRepo) Follow(followerID, followeeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.follows[[2]string{followerID, followeeID}] = true
+}
+
+// AddCircleMember lets userID see the publications shared with circleID
+func (r *SearchRepo) AddCircleMember(circleID, userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.members[[2]string{circleID, userID}] = true
+}
+
+// IndexPublication adds or replaces a publication
+func (r *SearchRepo) IndexPublication(p domain.Publication) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pubs[p.PostID] = p
+}
+
+// IndexComment adds or replaces a comment
+func (r *SearchRepo) IndexComment(c domain.Comment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comments[c.CommentID] = c
+}
+
+// Remove drops a publication or comment by its ID
+func (r *SearchRepo) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pubs, id)
+	delete(r.comments, id)
+}
+
+func (r *SearchRepo) Search(_ context.Context, q domain.SearchQuery) ([]domain.SearchHit, error) {
+	terms := words(q.Text)
+
+	r.mu.RLock()
+	var hits []domain.SearchHit
+	if q.Kind == "" || q.Kind == domain.KindPublication {
+		for _, p := range r.pubs {
+			rank := 2*count(p.Title, terms) + count(p.Content, terms)
+			if rank == 0 || !r.visible(p, q.ViewerID) || !matchFilters(q, p.UserID, p.CreatedAt) {
+				continue
+			}
+			hits = append(hits, domain.SearchHit{
+				Kind: domain.KindPublication, ID: p.PostID, PostID: p.PostID, UserID: p.UserID,
+				Name: p.Name, Title: p.Title, Snippet: highlight(p.Content, terms),
+				Rank: float64(rank), CreatedAt: p.CreatedAt,
+			})
+		}
+	}
+	if q.Kind == "" || q.Kind == domain.KindComment {
+		for _, c := range r.comments {
+			rank := count(c.Content, terms)
+			post, ok := r.pubs[c.PostID]
+			if rank == 0 || !ok || !r.visible(post, q.ViewerID) || !matchFilters(q, c.UserID, c.CreatedAt) {
+				continue
+			}
+			hits = append(hits, domain.SearchHit{
+				Kind: domain.KindComment, ID: c.CommentID, PostID: c.PostID, UserID: c.UserID,
+				Name: c.Name, Snippet: highlight(c.Content, terms),
+				Rank: float64(rank), CreatedAt: c.CreatedAt,
+			})
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+	if q.Offset >= len(hits) {
+		return nil, nil
+	}
+	hits = hits[q.Offset:]
+	if len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
+
+// visible mirrors the visibleTo condition of the database repository
+func (r *SearchRepo) visible(p domain.Publication, viewerID string) bool {
+	switch {
+	case p.Visibility == "" || p.Visibility == domain.VisibilityPublic || p.UserID == viewerID:
+		return true
+	case p.Visibility == domain.VisibilityFollowers:
+		return r.follows[[2]string{viewerID, p.UserID}]
+	case p.Visibility == domain.VisibilityCircle:
+		return p.CircleID != nil && r.members[[2]string{*p.CircleID, viewerID}]
+	}
+	return false
+}
+
+func matchFilters(q domain.SearchQuery, userID string, createdAt time.Time) bool {
+	if q.AuthorID != "" && q.AuthorID != userID {
+		return false
+	}
+	if q.From != nil && createdAt.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && !createdAt.Before(*q.To) {
+		return false
+	}
+	return true
+}
+
+// words splits text into lowercased words
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// count returns how many words of text are one of terms
+func count(text string, terms []string) int {
+	n := 0
+	for _, w := range words(text) {
+		if slices.Contains(terms, w) {
+			n++
+		}
+	}
+	return n
+}
+
+// highlight wraps every matched word of text in the highlight markers
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		w := text[start:end]
+		if slices.Contains(terms, strings.ToLower(w)) {
+			b.WriteString(domain.HighlightStart + w + domain.HighlightStop)
+		} else {
+			b.WriteString(w)
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		b.WriteRune(r)
+	}
+	flush(len(text))
+	return b.String()
+}
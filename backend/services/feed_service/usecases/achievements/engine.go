@@ -0,0 +1,123 @@
+// Package achievements awards badges by evaluating rules against a user's
+// feed and goal activity.
+package achievements
+
+import (
+	"context"
+	"time"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+)
+
+// Clock tells the engine what "today" is
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// Engine evaluates rules and stores the badges they award
+type Engine struct {
+	repo  repository.AchievementRepository
+	rules []Rule
+	clock Clock
+	// streakDays is how far back activity days are loaded
+	streakDays int
+}
+
+// NewEngine constructor
+func NewEngine(repo repository.AchievementRepository, rules []Rule, clock Clock) *Engine {
+	e := &Engine{repo: repo, rules: rules, clock: clock, streakDays: 1}
+	for _, r := range rules {
+		if r.Metric == MetricPostStreak || r.Metric == MetricCheckInStreak {
+			e.streakDays = max(e.streakDays, int(r.Threshold))
+		}
+	}
+	return e
+}
+
+// Evaluate awards every badge whose rule the user now meets and returns the
+// ones that are new. Badges already held are left alone, so running it
+// again, or concurrently, awards nothing twice.
+func (e *Engine) Evaluate(ctx context.Context, userID string) ([]domain.BadgeResponse, error) {
+	now := e.clock.Now().UTC()
+	// one extra day so a streak that ended yesterday still counts
+	since := startOfDay(now).AddDate(0, 0, -e.streakDays)
+	stats, err := e.repo.ActivityStats(ctx, userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var awarded []domain.BadgeResponse
+	for _, r := range e.rules {
+		if r.value(stats, now) < r.Threshold {
+			continue
+		}
+		b := &domain.Badge{UserID: userID, BadgeID: r.ID, AwardedAt: now}
+		isNew, err := e.repo.AwardBadge(ctx, b)
+		if err != nil {
+			return awarded, err
+		}
+		if isNew {
+			awarded = append(awarded, e.response(*b))
+		}
+	}
+	return awarded, nil
+}
+
+// Badges lists the user's badges, oldest first. Badges whose rule was
+// removed are still listed under their ID.
+func (e *Engine) Badges(ctx context.Context, userID string) ([]domain.BadgeResponse, error) {
+	badges, err := e.repo.ListBadges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.BadgeResponse, 0, len(badges))
+	for _, b := range badges {
+		out = append(out, e.response(b))
+	}
+	return out, nil
+}
+
+func (e *Engine) response(b domain.Badge) domain.BadgeResponse {
+	out := domain.BadgeResponse{ID: b.BadgeID, Name: b.BadgeID, AwardedAt: b.AwardedAt}
+	for _, r := range e.rules {
+		if r.ID == b.BadgeID {
+			out.Name, out.Description = r.Name, r.Description
+			break
+		}
+	}
+	return out
+}
+
+// Streak counts consecutive days with activity ending today, or ending
+// yesterday so a streak is not lost before today's activity. days are
+// distinct UTC days, newest first.
+func Streak(days []time.Time, now time.Time) int {
+	if len(days) == 0 {
+		return 0
+	}
+	expect := startOfDay(now.UTC())
+	if first := startOfDay(days[0]); first.Before(expect) {
+		expect = expect.AddDate(0, 0, -1)
+	}
+	n := 0
+	for _, d := range days {
+		d = startOfDay(d)
+		if !d.Equal(expect) {
+			break
+		}
+		n++
+		expect = expect.AddDate(0, 0, -1)
+	}
+	return n
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
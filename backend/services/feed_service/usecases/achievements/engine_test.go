@@ -0,0 +1,203 @@
+package achievements
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// fakeAchievements serves fixed activity and keeps badges in a map. Like
+// the database it drops activity days before since.
+type fakeAchievements struct {
+	repository.AchievementRepository
+	stats  domain.ActivityStats
+	since  time.Time
+	badges map[string]domain.Badge
+	order  []string
+}
+
+func (r *fakeAchievements) ActivityStats(ctx context.Context, userID string, since time.Time) (domain.ActivityStats, error) {
+	r.since = since
+	s := r.stats
+	s.PostDays = after(s.PostDays, since)
+	s.CheckInDays = after(s.CheckInDays, since)
+	return s, nil
+}
+
+func after(days []time.Time, since time.Time) []time.Time {
+	var out []time.Time
+	for _, d := range days {
+		if !d.Before(since) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (r *fakeAchievements) AwardBadge(ctx context.Context, b *domain.Badge) (bool, error) {
+	if _, ok := r.badges[b.BadgeID]; ok {
+		return false, nil
+	}
+	r.badges[b.BadgeID] = *b
+	r.order = append(r.order, b.BadgeID)
+	return true, nil
+}
+
+func (r *fakeAchievements) ListBadges(ctx context.Context, userID string) ([]domain.Badge, error) {
+	var out []domain.Badge
+	for _, id := range r.order {
+		out = append(out, r.badges[id])
+	}
+	return out, nil
+}
+
+// day is midnight UTC of 2024-05-d
+func day(d int) time.Time {
+	return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC)
+}
+
+// days lists 2024-05-from down to 2024-05-to, newest first
+func days(from, to int) []time.Time {
+	var out []time.Time
+	for d := from; d >= to; d-- {
+		out = append(out, day(d))
+	}
+	return out
+}
+
+func TestStreak(t *testing.T) {
+	noon := day(10).Add(12 * time.Hour)
+	tests := []struct {
+		name string
+		days []time.Time
+		now  time.Time
+		want int
+	}{
+		{"no activity", nil, noon, 0},
+		{"today only", days(10, 10), noon, 1},
+		{"yesterday only", days(9, 9), noon, 1},
+		{"ended two days ago", days(8, 1), noon, 0},
+		{"ending today", days(10, 4), noon, 7},
+		{"ending yesterday", days(9, 3), noon, 7},
+		{"gap", append(days(10, 8), days(6, 1)...), noon, 3},
+		{"today is not yet a gap", append(days(9, 7), day(5)), noon, 3},
+		{"first second of the day", days(9, 3), day(10), 7},
+		{"last second of the day", days(9, 3), day(11).Add(-time.Second), 7},
+		{"first second of the next day", days(9, 3), day(11), 0},
+		// 01:30 in UTC+3 is still the 9th in UTC
+		{"clock in another zone", days(9, 9), time.Date(2024, 5, 10, 1, 30, 0, 0, time.FixedZone("UTC+3", 3*3600)), 1},
+		// day values are dates; their clock time and zone do not matter
+		{"days with a time of day", []time.Time{day(10).Add(23 * time.Hour), day(9).Add(time.Minute)}, noon, 2},
+	}
+	for _, tt := range tests {
+		if got := Streak(tt.days, tt.now); got != tt.want {
+			t.Errorf("%s: Streak = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateStreakDayBoundary(t *testing.T) {
+	// posts every day from the 3rd to the 9th: a week streak that holds
+	// all of the 10th and is gone at midnight
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"same day", day(9).Add(20 * time.Hour), true},
+		{"next morning", day(10).Add(time.Minute), true},
+		{"next evening", day(11).Add(-time.Nanosecond), true},
+		{"two days later", day(11), false},
+		{"two days later in UTC-5", time.Date(2024, 5, 10, 19, 0, 0, 0, time.FixedZone("UTC-5", -5*3600)), false},
+		{"still the 10th in UTC+5", time.Date(2024, 5, 11, 4, 0, 0, 0, time.FixedZone("UTC+5", 5*3600)), true},
+	}
+	for _, tt := range tests {
+		repo := &fakeAchievements{stats: domain.ActivityStats{Publications: 7, PostDays: days(9, 3)}, badges: map[string]domain.Badge{}}
+		e := NewEngine(repo, DefaultRules, &fakeClock{tt.now})
+		if _, err := e.Evaluate(context.Background(), "u1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, got := repo.badges["post_streak_7"]; got != tt.want {
+			t.Errorf("%s: week streak awarded = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateLoadsDaysForTheLongestStreak(t *testing.T) {
+	now := day(31).Add(9 * time.Hour)
+	repo := &fakeAchievements{badges: map[string]domain.Badge{}}
+	rules := []Rule{
+		{ID: "streak_3", Name: "3", Metric: MetricPostStreak, Threshold: 3},
+		{ID: "streak_30", Name: "30", Metric: MetricPostStreak, Threshold: 30},
+		{ID: "posts_100", Name: "100", Metric: MetricPublications, Threshold: 100},
+	}
+	e := NewEngine(repo, rules, &fakeClock{now})
+
+	// a 30 day streak that ended yesterday needs the day 30 days back
+	repo.stats.PostDays = days(30, 1)
+	if _, err := e.Evaluate(context.Background(), "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.since.Equal(day(1)) {
+		t.Errorf("activity loaded since %v, want %v", repo.since, day(1))
+	}
+	if _, ok := repo.badges["streak_30"]; !ok {
+		t.Error("30 day streak ending yesterday not awarded")
+	}
+}
+
+func TestEvaluateAwardsOnce(t *testing.T) {
+	now := day(10).Add(8 * time.Hour)
+	repo := &fakeAchievements{
+		stats:  domain.ActivityStats{Publications: 1, Comments: 9, GoalsCompleted: 1, CheckInDays: days(10, 1)},
+		badges: map[string]domain.Badge{},
+	}
+	e := NewEngine(repo, DefaultRules, &fakeClock{now})
+
+	awarded, err := e.Evaluate(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, b := range awarded {
+		ids = append(ids, b.ID)
+		if !b.AwardedAt.Equal(now) || b.Name == b.ID {
+			t.Errorf("badge %+v: want awarded at %v with its rule's name", b, now)
+		}
+	}
+	if want := []string{"first_post", "goal_completed"}; len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] {
+		t.Errorf("awarded %v, want %v", ids, want)
+	}
+
+	// ten days of check-ins are not fourteen; a comment more is ten
+	repo.stats.Comments++
+	awarded, err = e.Evaluate(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(awarded) != 1 || awarded[0].ID != "comments_10" {
+		t.Errorf("second run awarded %+v, want only comments_10", awarded)
+	}
+}
+
+func TestBadgesOfRemovedRules(t *testing.T) {
+	repo := &fakeAchievements{badges: map[string]domain.Badge{
+		"first_post": {BadgeID: "first_post"},
+		"retired":    {BadgeID: "retired"},
+	}, order: []string{"first_post", "retired"}}
+	e := NewEngine(repo, DefaultRules, SystemClock{})
+	badges, err := e.Badges(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(badges) != 2 || badges[0].Name != "First Steps" || badges[1].Name != "retired" || badges[1].Description != "" {
+		t.Errorf("badges = %+v", badges)
+	}
+}
@@ -0,0 +1,111 @@
+package achievements
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"feed_service/domain"
+)
+
+// Metrics a rule can test, read from domain.ActivityStats
+const (
+	MetricPublications   = "publications"
+	MetricComments       = "comments"
+	MetricCheckIns       = "check_ins"
+	MetricGoalsCompleted = "goals_completed"
+	MetricReactionsGiven = "reactions_given"
+	MetricPostStreak     = "post_streak"
+	MetricCheckInStreak  = "check_in_streak"
+)
+
+// Rule awards badge ID once Metric reaches Threshold
+type Rule struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Metric      string `json:"metric"`
+	Threshold   int64  `json:"threshold"`
+}
+
+// DefaultRules are the badges built into the service
+var DefaultRules = []Rule{
+	{ID: "first_post", Name: "First Steps", Description: "Shared a first publication", Metric: MetricPublications, Threshold: 1},
+	{ID: "posts_50", Name: "Storyteller", Description: "Shared 50 publications", Metric: MetricPublications, Threshold: 50},
+	{ID: "post_streak_7", Name: "Week Streak", Description: "Posted 7 days in a row", Metric: MetricPostStreak, Threshold: 7},
+	{ID: "post_streak_30", Name: "Month Streak", Description: "Posted 30 days in a row", Metric: MetricPostStreak, Threshold: 30},
+	{ID: "comments_10", Name: "Supporter", Description: "Gave the first 10 comments", Metric: MetricComments, Threshold: 10},
+	{ID: "reactions_50", Name: "Cheerleader", Description: "Reacted to 50 posts or comments", Metric: MetricReactionsGiven, Threshold: 50},
+	{ID: "goal_completed", Name: "Goal Getter", Description: "Completed a goal", Metric: MetricGoalsCompleted, Threshold: 1},
+	{ID: "goals_completed_5", Name: "Unstoppable", Description: "Completed 5 goals", Metric: MetricGoalsCompleted, Threshold: 5},
+	{ID: "check_in_streak_14", Name: "Consistent", Description: "Checked in on goals 14 days in a row", Metric: MetricCheckInStreak, Threshold: 14},
+}
+
+// value returns the metric of the rule for stats; streaks end at today
+func (r Rule) value(s domain.ActivityStats, now time.Time) int64 {
+	switch r.Metric {
+	case MetricPublications:
+		return s.Publications
+	case MetricComments:
+		return s.Comments
+	case MetricCheckIns:
+		return s.CheckIns
+	case MetricGoalsCompleted:
+		return s.GoalsCompleted
+	case MetricReactionsGiven:
+		return s.ReactionsGiven
+	case MetricPostStreak:
+		return int64(Streak(s.PostDays, now))
+	case MetricCheckInStreak:
+		return int64(Streak(s.CheckInDays, now))
+	}
+	return 0
+}
+
+func (r Rule) validate() error {
+	if r.ID == "" || len(r.ID) > 64 || r.Name == "" || r.Threshold < 1 {
+		return fmt.Errorf("rule %q needs an id (≤64 chars), a name and a positive threshold", r.ID)
+	}
+	switch r.Metric {
+	case MetricPublications, MetricComments, MetricCheckIns, MetricGoalsCompleted,
+		MetricReactionsGiven, MetricPostStreak, MetricCheckInStreak:
+		return nil
+	}
+	return fmt.Errorf("rule %q: unknown metric %q", r.ID, r.Metric)
+}
+
+// LoadRules returns DefaultRules merged with the JSON array of rules in
+// path; a file rule replaces the built-in rule with the same ID. An empty
+// path means the defaults only.
+func LoadRules(path string) ([]Rule, error) {
+	rules := append([]Rule(nil), DefaultRules...)
+	if path == "" {
+		return rules, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read achievements file: %w", err)
+	}
+	var extra []Rule
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		return nil, fmt.Errorf("parse achievements file: %w", err)
+	}
+
+	for _, r := range extra {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+		replaced := false
+		for i := range rules {
+			if rules[i].ID == r.ID {
+				rules[i], replaced = r, true
+			}
+		}
+		if !replaced {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
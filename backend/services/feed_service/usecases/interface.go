@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 	"errors"
+	"io"
 
 	"feed_service/domain"
 )
@@ -15,21 +16,136 @@ type FeedService interface {
 
 	// Publication operations
 	CreatePublication(ctx context.Context, userID string, req domain.PublicationRequest) (domain.PublicationResponse, error)
-	GetPublication(ctx context.Context, postID string) (domain.PublicationResponse, error)
-	ListPublications(ctx context.Context) ([]domain.PublicationResponse, error)
+	// viewerID is the caller; it decides the reacted_by_me flags and which
+	// publications are visible. Hidden publications, and their comments,
+	// are reported as ErrNotFound.
+	GetPublication(ctx context.Context, postID, viewerID string) (domain.PublicationResponse, error)
+	// GetPublicationForModeration skips the visibility rules, so moderators
+	// can act on posts hidden from them. It is not for display.
+	GetPublicationForModeration(ctx context.Context, postID string) (domain.PublicationResponse, error)
+	ListPublications(ctx context.Context, viewerID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error)
 	UpdatePublication(ctx context.Context, postID string, req domain.PublicationRequest) (domain.PublicationResponse, error)
 	DeletePublication(ctx context.Context, postID string) error
-	ListPublicationsByUser(ctx context.Context, userID string) ([]domain.PublicationResponse, error)
+	ListPublicationsByUser(ctx context.Context, userID, viewerID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error)
 
 	// Comment operations
 	CreateComment(ctx context.Context, userID string, req domain.PostCommentRequest) (domain.CommentResponse, error)
-	GetComment(ctx context.Context, commentID string) (domain.CommentResponse, error)
-	ListComments(ctx context.Context, postID string) ([]domain.CommentResponse, error)
+	GetComment(ctx context.Context, commentID, viewerID string) (domain.CommentResponse, error)
+	GetCommentForModeration(ctx context.Context, commentID string) (domain.CommentResponse, error)
+	ListComments(ctx context.Context, postID, viewerID string, page domain.PageRequest) (domain.Page[domain.CommentResponse], error)
+	// ListCommentTree pages through top-level comments, each with the start
+	// of its reply tree nested
+	ListCommentTree(ctx context.Context, postID, viewerID string, page domain.PageRequest) (domain.Page[domain.CommentResponse], error)
+	// ListReplies pages through the replies under a top-level comment, oldest first
+	ListReplies(ctx context.Context, commentID, viewerID string, page domain.PageRequest) (domain.Page[domain.CommentResponse], error)
 	UpdateComment(ctx context.Context, req domain.PutCommentRequest) (domain.CommentResponse, error)
 	DeleteComment(ctx context.Context, commentID string) error
+
+	// Reactions; targetType is domain.KindPublication or domain.KindComment.
+	// Both return the target's updated summary.
+	ReactionTypes() []string
+	AddReaction(ctx context.Context, targetType, targetID, userID, reactionType string) ([]domain.ReactionCount, error)
+	RemoveReaction(ctx context.Context, targetType, targetID, userID, reactionType string) ([]domain.ReactionCount, error)
+
+	// Follow graph; Follow and Unfollow are idempotent and return the
+	// followee's updated counts
+	Follow(ctx context.Context, followerID, followeeID string) (domain.FollowCounts, error)
+	Unfollow(ctx context.Context, followerID, followeeID string) (domain.FollowCounts, error)
+	FollowCounts(ctx context.Context, userID, viewerID string) (domain.FollowCounts, error)
+	ListFollowers(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.FollowResponse], error)
+	ListFollowing(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.FollowResponse], error)
+	// Timeline pages through publications of the user and everyone they follow
+	Timeline(ctx context.Context, userID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error)
+
+	// Circles of users an author shares publications with; only the
+	// owner may read or change them, which the handler checks
+	CreateCircle(ctx context.Context, userID string, req domain.CircleRequest) (domain.CircleResponse, error)
+	GetCircle(ctx context.Context, circleID string) (domain.CircleResponse, error)
+	ListCircles(ctx context.Context, userID string) ([]domain.CircleResponse, error)
+	RenameCircle(ctx context.Context, circleID, name string) (domain.CircleResponse, error)
+	DeleteCircle(ctx context.Context, circleID string) error
+	AddCircleMember(ctx context.Context, circleID, memberID string) (domain.CircleResponse, error)
+	RemoveCircleMember(ctx context.Context, circleID, memberID string) (domain.CircleResponse, error)
+
+	// Goals and check-ins
+	CreateGoal(ctx context.Context, userID string, req domain.GoalRequest) (domain.GoalResponse, error)
+	GetGoal(ctx context.Context, goalID string) (domain.GoalResponse, error)
+	ListGoals(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.GoalResponse], error)
+	UpdateGoal(ctx context.Context, goalID string, req domain.UpdateGoalRequest) (domain.GoalResponse, error)
+	DeleteGoal(ctx context.Context, goalID string) error
+	// AddCheckIn records progress and returns it with the updated goal
+	AddCheckIn(ctx context.Context, goalID string, req domain.CheckInRequest) (domain.CheckInResult, error)
+	ListCheckIns(ctx context.Context, goalID string, page domain.PageRequest) (domain.Page[domain.CheckInResponse], error)
+	DeleteCheckIn(ctx context.Context, goalID, checkInID string) (domain.GoalResponse, error)
+
+	// Challenges; viewerID decides the joined flag
+	CreateChallenge(ctx context.Context, userID string, req domain.ChallengeRequest) (domain.ChallengeResponse, error)
+	GetChallenge(ctx context.Context, challengeID, viewerID string) (domain.ChallengeResponse, error)
+	ListChallenges(ctx context.Context, status, viewerID string, page domain.PageRequest) (domain.Page[domain.ChallengeResponse], error)
+	DeleteChallenge(ctx context.Context, challengeID string) error
+	JoinChallenge(ctx context.Context, challengeID, userID string, req domain.JoinChallengeRequest) (domain.ChallengeResponse, error)
+	LeaveChallenge(ctx context.Context, challengeID, userID string) (domain.ChallengeResponse, error)
+	AddChallengeEntry(ctx context.Context, challengeID, userID string, req domain.ChallengeEntryRequest) (domain.ChallengeEntryResponse, error)
+	// DeleteChallengeEntry lets the entry's author, or a moderator, take it back
+	DeleteChallengeEntry(ctx context.Context, challengeID, entryID, userID string, moderator bool) error
+	Leaderboard(ctx context.Context, challengeID, viewerID string, page domain.OffsetPage) (domain.Leaderboard, error)
+	ListChallengePublications(ctx context.Context, challengeID, viewerID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error)
+
+	// ImportTrack parses a GPX, TCX or FIT file named fileName and stores it
+	// as a draft track with a prefilled workout publication
+	ImportTrack(ctx context.Context, userID, fileName string, file io.Reader) (domain.TrackImport, error)
+	GetTrack(ctx context.Context, trackID string) (domain.TrackResponse, error)
+	ListTracks(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.TrackResponse], error)
+	DeleteTrack(ctx context.Context, trackID string) error
+
+	// AttachMedia stores a photo or video, with its metadata stripped, as an
+	// attachment of a publication
+	AttachMedia(ctx context.Context, postID, userID string, file io.Reader) (domain.MediaResponse, error)
+	GetMedia(ctx context.Context, mediaID string) (domain.MediaResponse, error)
+	// OpenMedia returns an attachment and its file if viewerID may see its
+	// publication; the caller closes it
+	OpenMedia(ctx context.Context, mediaID, viewerID string) (domain.MediaResponse, io.ReadCloser, error)
+	DeleteMedia(ctx context.Context, mediaID string) error
+
+	// Badges lists the achievements a user has earned
+	Badges(ctx context.Context, userID string) ([]domain.BadgeResponse, error)
+
+	// Search finds publications and comments matching q, best matches first
+	Search(ctx context.Context, q domain.SearchQuery) (domain.Page[domain.SearchHit], error)
+
+	// RenameAuthor is called by auth_service when a user changes username
+	RenameAuthor(ctx context.Context, userID string, req domain.RenameAuthorRequest) error
+	// ImportWorkout is called by profile_service for workouts found in a
+	// health app export; it reports false when the workout was imported before
+	ImportWorkout(ctx context.Context, userID string, req domain.ImportedWorkoutRequest) (domain.PublicationResponse, bool, error)
+	// EvaluateBadges is called by profile_service once an import has posted
+	// its workouts; it returns the badges that are new
+	EvaluateBadges(ctx context.Context, userID string) ([]domain.BadgeResponse, error)
 }
 
 // error
 var (
-	ErrNotFound = errors.New("recording not found")
+	ErrNotFound         = errors.New("recording not found")
+	ErrUnknownReaction  = errors.New("unknown reaction type")
+	ErrInvalidParent    = errors.New("parent comment not found on this publication")
+	ErrThreadTooDeep    = errors.New("replies are nested too deep")
+	ErrNotTopLevel      = errors.New("comment is a reply, not a top-level comment")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrSelfFollow       = errors.New("users cannot follow themselves")
+	ErrValueRequired    = errors.New("value is required for this goal type")
+	ErrGoalAbandoned    = errors.New("goal is abandoned")
+	ErrBadAttachment    = errors.New("goal or check-in not found among the author's goals")
+	ErrChallengeClosed  = errors.New("challenge is not open for joining")
+	ErrWrongJoinCode    = errors.New("wrong join code")
+	ErrNotParticipant   = errors.New("not taking part in this challenge")
+	ErrOutsideWindow    = errors.New("entry is outside the challenge dates")
+	ErrForbidden        = errors.New("not allowed")
+	ErrUnreadableTrack  = errors.New("cannot read track file")
+	ErrBadTrack         = errors.New("track not found among the author's unposted tracks")
+	ErrUnsupportedMedia = errors.New("unsupported media file")
+	ErrMediaTooLarge    = errors.New("media file is too large")
+	ErrTooManyMedia     = errors.New("publication has the maximum number of attachments")
+	ErrBadCircle        = errors.New("circle not found among the author's circles")
+	ErrSelfCircle       = errors.New("users cannot add themselves to their circles")
+	ErrCircleFull       = errors.New("circle has the maximum number of members")
 )
@@ -0,0 +1,259 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+)
+
+// cleanJPEG copies the segments and scans of a JPEG up to its EOI marker,
+// dropping EXIF/XMP (APP1), IPTC (APP13), comments and vendor APPn
+// segments. JFIF (APP0), ICC profiles (APP2) and Adobe colour info (APP14)
+// are kept. Whatever follows EOI is dropped too: phones append the MPF
+// preview images and motion photo videos there, each with its own EXIF.
+func cleanJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, invalid("jpeg: missing SOI")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xff, 0xd8)
+	orientation := 1
+	scanned := false
+	pos := 2
+	for {
+		// markers may be padded with any number of 0xff bytes
+		for pos < len(data) && data[pos] == 0xff && pos+1 < len(data) && data[pos+1] == 0xff {
+			pos++
+		}
+		if pos+2 > len(data) || data[pos] != 0xff {
+			return nil, invalid("jpeg: bad marker at %d", pos)
+		}
+		marker := data[pos+1]
+		if marker == 0xd9 {
+			if !scanned {
+				return nil, invalid("jpeg: no image data")
+			}
+			out = append(out, 0xff, 0xd9)
+			break
+		}
+		if marker == 0x01 || marker >= 0xd0 && marker <= 0xd7 {
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+		if pos+4 > len(data) {
+			return nil, invalid("jpeg: truncated segment")
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 { // the length counts its own two bytes
+			return nil, invalid("jpeg: bad segment length")
+		}
+		end := pos + 2 + length
+		if end > len(data) {
+			return nil, invalid("jpeg: truncated segment")
+		}
+		body := data[pos+4 : end]
+		if marker == 0xda { // start of scan: the image data runs to the next marker
+			next := scanEnd(data, end)
+			out = append(out, data[pos:next]...)
+			scanned = true
+			if next == len(data) { // cut short before EOI
+				out = append(out, 0xff, 0xd9)
+				break
+			}
+			pos = next
+			continue
+		}
+		switch {
+		case marker == 0xe1:
+			if bytes.HasPrefix(body, []byte("Exif\x00\x00")) {
+				orientation = exifOrientation(body[6:])
+			}
+		case marker == 0xe2 && bytes.HasPrefix(body, []byte("ICC_PROFILE\x00")),
+			marker == 0xe0, marker == 0xee:
+			out = append(out, data[pos:end]...)
+		case marker >= 0xe2 && marker <= 0xef, marker == 0xfe:
+			// other application segments and comments are dropped
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	if orientation == 1 {
+		return out, nil
+	}
+	return reorient(out, orientation)
+}
+
+// scanEnd finds the marker that ends the entropy-coded data starting at
+// pos. Inside it 0xff is only followed by a stuffed 0x00 or a restart
+// marker.
+func scanEnd(data []byte, pos int) int {
+	for i := pos; i+1 < len(data); i++ {
+		if data[i] != 0xff {
+			continue
+		}
+		if next := data[i+1]; next != 0x00 && (next < 0xd0 || next > 0xd7) {
+			return i
+		}
+		i++
+	}
+	return len(data)
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of a TIFF-structured EXIF
+// block, 1 (upright) when it is missing or unreadable
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// maxPixels guards against decompression bombs: small files that
+// declare huge dimensions
+const maxPixels = 50_000_000
+
+// reorient decodes the JPEG, applies the EXIF orientation and encodes it
+// again upright
+func reorient(data []byte, orientation int) ([]byte, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, invalid("jpeg: %v", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, invalid("jpeg: %dx%d pixels", cfg.Width, cfg.Height)
+	}
+	src, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, invalid("jpeg: %v", err)
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// orientations 5-8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 92}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pngDropped are the ancillary chunks that can carry text, dates or EXIF
+var pngDropped = map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true}
+
+// cleanPNG copies every chunk but the metadata ones; chunk CRCs are
+// per chunk, so nothing has to be recomputed
+func cleanPNG(data []byte) ([]byte, error) {
+	const sig = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(sig)) {
+		return nil, invalid("png: bad signature")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, sig...)
+	for pos := len(sig); pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, invalid("png: truncated chunk")
+		}
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:]))
+		if end > len(data) || end < pos {
+			return nil, invalid("png: truncated chunk")
+		}
+		typ := string(data[pos+4 : pos+8])
+		if !pngDropped[typ] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+		if typ == "IEND" {
+			break
+		}
+	}
+	return out, nil
+}
+
+// cleanWebP drops the EXIF and XMP chunks of a RIFF WebP file, clears
+// their flags in the VP8X header and fixes the RIFF size
+func cleanWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, invalid("webp: bad header")
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, invalid("webp: truncated chunk")
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size&1 // chunks are padded to even sizes
+		if end > len(data) || end < pos {
+			return nil, invalid("webp: truncated chunk")
+		}
+		switch fourcc := string(data[pos : pos+4]); fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[pos:end]...)
+			if size > 0 {
+				out[start+8] &^= 0x08 | 0x04 // EXIF and XMP present flags
+			}
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,120 @@
+// Package media checks uploaded photos and videos and removes the metadata
+// that could locate or identify the uploader (EXIF, GPS, XMP, text chunks)
+// before they are stored.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"time"
+
+	_ "golang.org/x/image/webp"
+)
+
+// kinds of media
+const (
+	KindImage = "image"
+	KindVideo = "video"
+)
+
+// accepted content types
+const (
+	TypeJPEG      = "image/jpeg"
+	TypePNG       = "image/png"
+	TypeWebP      = "image/webp"
+	TypeMP4       = "video/mp4"
+	TypeQuickTime = "video/quicktime"
+)
+
+var (
+	ErrUnsupported = errors.New("only JPEG, PNG and WebP images and MP4 or QuickTime videos are accepted")
+	ErrInvalid     = errors.New("invalid media file")
+)
+
+// Config holds the upload limits and where stored media is served from
+type Config struct {
+	URLPrefix     string
+	MaxImageBytes int64
+	MaxVideoBytes int64
+	MaxVideo      time.Duration
+}
+
+// Info describes a cleaned file
+type Info struct {
+	ContentType string
+	Kind        string
+	Width       int
+	Height      int
+	Duration    time.Duration // videos only
+}
+
+// Sniff detects the content type from the first bytes of a file, ignoring
+// whatever the client claims
+func Sniff(head []byte) (contentType, kind string, err error) {
+	// ISO base media files start with an ftyp box; QuickTime brands its own
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		if string(head[8:12]) == "qt  " {
+			return TypeQuickTime, KindVideo, nil
+		}
+		return TypeMP4, KindVideo, nil
+	}
+	switch ct := http.DetectContentType(head); ct {
+	case TypeJPEG, TypePNG, TypeWebP:
+		return ct, KindImage, nil
+	}
+	return "", "", ErrUnsupported
+}
+
+// Extension is the file extension stored blobs get for a content type
+func Extension(contentType string) string {
+	switch contentType {
+	case TypeJPEG:
+		return ".jpg"
+	case TypePNG:
+		return ".png"
+	case TypeWebP:
+		return ".webp"
+	case TypeMP4:
+		return ".mp4"
+	case TypeQuickTime:
+		return ".mov"
+	}
+	return ""
+}
+
+// CleanImage strips metadata from an image and reports its size. JPEGs
+// whose EXIF orientation is not upright are re-encoded rotated, since
+// dropping the tag would otherwise show them sideways.
+func CleanImage(data []byte, contentType string) ([]byte, Info, error) {
+	var (
+		clean []byte
+		err   error
+	)
+	switch contentType {
+	case TypeJPEG:
+		clean, err = cleanJPEG(data)
+	case TypePNG:
+		clean, err = cleanPNG(data)
+	case TypeWebP:
+		clean, err = cleanWebP(data)
+	default:
+		return nil, Info{}, ErrUnsupported
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(clean))
+	if err != nil {
+		return nil, Info{}, invalid("%v", err)
+	}
+	return clean, Info{ContentType: contentType, Kind: KindImage, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+}
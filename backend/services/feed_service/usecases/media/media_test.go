@@ -0,0 +1,430 @@
+package media
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tinyWebP is a lossless 1x1 WebP (RIFF header plus one VP8L chunk)
+var tinyWebP, _ = base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		ct   string
+		kind string
+		err  error
+	}{
+		{"jpeg", testJPEG(t), TypeJPEG, KindImage, nil},
+		{"png", testPNG(t), TypePNG, KindImage, nil},
+		{"webp", tinyWebP, TypeWebP, KindImage, nil},
+		{"mp4", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00"), TypeMP4, KindVideo, nil},
+		{"quicktime", []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00"), TypeQuickTime, KindVideo, nil},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "", "", ErrUnsupported},
+		{"text", []byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), "", "", ErrUnsupported},
+		{"empty", nil, "", "", ErrUnsupported},
+	}
+	for _, tt := range tests {
+		ct, kind, err := Sniff(tt.head)
+		if ct != tt.ct || kind != tt.kind || err != tt.err {
+			t.Errorf("%s: Sniff = %q, %q, %v; want %q, %q, %v", tt.name, ct, kind, err, tt.ct, tt.kind, tt.err)
+		}
+	}
+}
+
+func TestCleanJPEG(t *testing.T) {
+	// testJPEG is 16x8, red on the left half and blue on the right; the
+	// colours say where that left half ended up
+	tests := []struct {
+		orientation   int
+		width, height int
+		red, blue     image.Point
+	}{
+		{1, 16, 8, image.Pt(4, 4), image.Pt(12, 4)},
+		{3, 16, 8, image.Pt(12, 4), image.Pt(4, 4)},  // rotated 180
+		{6, 8, 16, image.Pt(4, 4), image.Pt(4, 12)},  // rotated 90 clockwise
+		{8, 8, 16, image.Pt(4, 12), image.Pt(4, 4)},  // rotated 90 counter-clockwise
+		{2, 16, 8, image.Pt(12, 4), image.Pt(4, 4)},  // mirrored
+		{5, 8, 16, image.Pt(4, 4), image.Pt(4, 12)},  // transposed
+		{0, 16, 8, image.Pt(4, 4), image.Pt(12, 4)},  // out of range, left alone
+		{42, 16, 8, image.Pt(4, 4), image.Pt(12, 4)}, // out of range, left alone
+	}
+	for _, tt := range tests {
+		data := withMetadata(testJPEG(t), tt.orientation)
+		clean, info, err := CleanImage(data, TypeJPEG)
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.orientation, err)
+		}
+		if info.Width != tt.width || info.Height != tt.height || info.ContentType != TypeJPEG || info.Kind != KindImage {
+			t.Errorf("orientation %d: info = %+v, want %dx%d", tt.orientation, info, tt.width, tt.height)
+		}
+		for _, leak := range []string{"Exif", "GPSLatitude", "Photoshop", "secret comment", "MPF", "motion photo"} {
+			if bytes.Contains(clean, []byte(leak)) {
+				t.Errorf("orientation %d: %q survived", tt.orientation, leak)
+			}
+		}
+		if !bytes.HasSuffix(clean, []byte{0xff, 0xd9}) {
+			t.Errorf("orientation %d: output does not end at EOI", tt.orientation)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(clean))
+		if err != nil {
+			t.Fatalf("orientation %d: decode: %v", tt.orientation, err)
+		}
+		if !isRed(img.At(tt.red.X, tt.red.Y)) || !isBlue(img.At(tt.blue.X, tt.blue.Y)) {
+			t.Errorf("orientation %d: red at %v is %v, blue at %v is %v", tt.orientation,
+				tt.red, img.At(tt.red.X, tt.red.Y), tt.blue, img.At(tt.blue.X, tt.blue.Y))
+		}
+	}
+}
+
+func TestCleanJPEGKeepsUpright(t *testing.T) {
+	// an upright photo is not re-encoded: its segments and scan are copied
+	// and only the metadata goes
+	plain := testJPEG(t)
+	clean, _, err := CleanImage(withMetadata(plain, 1), TypeJPEG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	icc := segment(0xe2, []byte("ICC_PROFILE\x00\x01\x01profile"))
+	want := append(append([]byte{0xff, 0xd8}, icc...), plain[2:]...)
+	if !bytes.Equal(clean, want) {
+		t.Errorf("cleaned upright jpeg differs from the original plus its ICC profile")
+	}
+}
+
+func TestCleanJPEGScanEnd(t *testing.T) {
+	// stuffed bytes and restart markers belong to the scan
+	scan := []byte{0x12, 0xff, 0x00, 0x34, 0xff, 0xd3, 0x56, 0xff, 0xff, 0xd9}
+	if got := scanEnd(scan, 0); got != 7 {
+		t.Errorf("scanEnd = %d, want 7", got)
+	}
+	if got := scanEnd(scan[:7], 0); got != 7 {
+		t.Errorf("scanEnd without a marker = %d, want the length 7", got)
+	}
+
+	// a file cut off inside the scan is closed with an EOI
+	plain := testJPEG(t)
+	cut := plain[:len(plain)-20]
+	clean, err := cleanJPEG(cut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(clean, append(bytes.Clone(cut), 0xff, 0xd9)) {
+		t.Error("truncated scan was not copied and closed")
+	}
+}
+
+func TestCleanPNG(t *testing.T) {
+	plain := testPNG(t)
+	// metadata chunks go right after IHDR (8 byte signature, 25 byte IHDR)
+	meta := append(pngChunk("tEXt", []byte("Comment\x00secret comment")), pngChunk("eXIf", []byte("MM\x00*GPSLatitude"))...)
+	meta = append(meta, pngChunk("tIME", []byte{0x07, 0xe8, 5, 1, 12, 0, 0})...)
+	data := append(append(bytes.Clone(plain[:33]), meta...), plain[33:]...)
+	data = append(data, "trailing junk"...)
+
+	clean, info, err := CleanImage(data, TypePNG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(clean, plain) {
+		t.Error("cleaned png differs from the original without its metadata")
+	}
+	if info.Width != 4 || info.Height != 2 {
+		t.Errorf("info = %+v, want 4x2", info)
+	}
+}
+
+func TestCleanWebP(t *testing.T) {
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x04 // EXIF and XMP
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	data = append(data, webpChunk("VP8X", vp8x)...)
+	data = append(data, tinyWebP[12:]...)
+	data = append(data, webpChunk("EXIF", []byte("MM\x00*GPSLatitude"))...)
+	data = append(data, webpChunk("XMP ", []byte("<x:xmpmeta>secret</x:xmpmeta>"))...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	clean, info, err := CleanImage(data, TypeWebP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"EXIF", "XMP ", "GPSLatitude", "secret"} {
+		if bytes.Contains(clean, []byte(leak)) {
+			t.Errorf("%q survived", leak)
+		}
+	}
+	if flags := clean[20]; flags != 0 {
+		t.Errorf("VP8X flags = %#x, want 0", flags)
+	}
+	if size := binary.LittleEndian.Uint32(clean[4:]); int(size) != len(clean)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(clean)-8)
+	}
+	if info.Width != 1 || info.Height != 1 {
+		t.Errorf("info = %+v, want 1x1", info)
+	}
+}
+
+func TestCleanImageRejects(t *testing.T) {
+	plain := testJPEG(t)
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		want        error
+	}{
+		{"gif", "image/gif", []byte("GIF89a"), ErrUnsupported},
+		{"no SOI", TypeJPEG, []byte("not a jpeg"), ErrInvalid},
+		{"no scan", TypeJPEG, []byte{0xff, 0xd8, 0xff, 0xd9}, ErrInvalid},
+		{"bad marker", TypeJPEG, []byte{0xff, 0xd8, 0x00, 0x00, 0xff, 0xd9}, ErrInvalid},
+		{"truncated segment", TypeJPEG, plain[:30], ErrInvalid},
+		{"segment length below 2", TypeJPEG, []byte("\xff\xd8\xff0\x00\x00"), ErrInvalid},
+		{"rotated bomb", TypeJPEG, withMetadata(withSize(t, plain, 60000, 60000), 6), ErrInvalid},
+		{"png signature", TypePNG, []byte("\x89PNX\r\n\x1a\n"), ErrInvalid},
+		{"truncated png", TypePNG, testPNG(t)[:40], ErrInvalid},
+		{"webp header", TypeWebP, []byte("RIFF\x00\x00\x00\x00WEBX"), ErrInvalid},
+		{"truncated webp", TypeWebP, tinyWebP[:24], ErrInvalid},
+		{"webp without image", TypeWebP, []byte("RIFF\x04\x00\x00\x00WEBP"), ErrInvalid},
+	}
+	for _, tt := range tests {
+		if _, _, err := CleanImage(tt.data, tt.contentType); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCleanVideo(t *testing.T) {
+	gps := []byte("\xa9xyz+52.5200+013.4050/")
+	tests := []struct {
+		name     string
+		mvhd     []byte
+		duration time.Duration
+	}{
+		{"mvhd v0", mvhd0(1000, 5000), 5 * time.Second},
+		{"mvhd v1", mvhd1(600, 900), 1500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := bytes.Repeat([]byte("frame"), 20)
+			data := concat(
+				mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+				mp4Box("moov",
+					tt.mvhd,
+					mp4Box("udta", gps),
+					mp4Box("trak", mp4Box("tkhd", make([]byte, 84)), mp4Box("meta", []byte("handler: phone"))),
+				),
+				mp4Box("uuid", []byte("xmp: <rdf>secret</rdf>")),
+				mdat64(samples),
+			)
+			f := tempFile(t, data)
+
+			info, err := CleanVideo(f, int64(len(data)), TypeMP4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Duration != tt.duration || info.Kind != KindVideo || info.ContentType != TypeMP4 {
+				t.Errorf("info = %+v, want duration %v", info, tt.duration)
+			}
+			clean, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(clean) != len(data) {
+				t.Fatalf("size changed from %d to %d", len(data), len(clean))
+			}
+			for _, leak := range []string{"+52.5200", "phone", "secret", "udta", "uuid", "meta"} {
+				if bytes.Contains(clean, []byte(leak)) {
+					t.Errorf("%q survived", leak)
+				}
+			}
+			if n := bytes.Count(clean, []byte("free")); n != 3 {
+				t.Errorf("%d free boxes, want 3", n)
+			}
+			if !bytes.HasSuffix(clean, samples) || !bytes.HasPrefix(clean, data[:bytes.Index(data, []byte("udta"))-4]) {
+				t.Error("sample data or headers moved")
+			}
+		})
+	}
+}
+
+func TestCleanVideoRejects(t *testing.T) {
+	ftyp := mp4Box("ftyp", []byte("isom"))
+	overrun := mp4Box("moov", mvhd0(1000, 1))
+	binary.BigEndian.PutUint32(overrun, uint32(len(overrun)+100))
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"no ftyp", concat(mp4Box("moov", mvhd0(1000, 1)), ftyp)},
+		{"no moov", concat(ftyp, mdat64([]byte("x")))},
+		{"overrun", concat(ftyp, overrun)},
+		{"truncated header", concat(ftyp, []byte{0, 0, 0})},
+		{"zero timescale", concat(ftyp, mp4Box("moov", mvhd0(0, 1)))},
+		{"short mvhd", concat(ftyp, mp4Box("moov", mp4Box("mvhd", make([]byte, 8))))},
+	}
+	for _, tt := range tests {
+		f := tempFile(t, tt.data)
+		if _, err := CleanVideo(f, int64(len(tt.data)), TypeMP4); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", tt.name, err)
+		}
+	}
+}
+
+// testJPEG encodes a 16x8 image, red on the left half and blue on the right
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			if x < 8 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withMetadata puts the segments a phone writes after the SOI of plain
+// (EXIF with the orientation, XMP, IPTC, MPF, an ICC profile and a
+// comment) and appends a second JPEG with its own EXIF after the EOI, the
+// way MPF previews and motion photos are stored
+func withMetadata(plain []byte, orientation int) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint16(tiff[18:], uint16(orientation))
+	out := []byte{0xff, 0xd8}
+	out = append(out, segment(0xe1, append([]byte("Exif\x00\x00"), tiff...))...)
+	out = append(out, segment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00<exif:GPSLatitude>52,31N</exif:GPSLatitude>"))...)
+	out = append(out, segment(0xed, []byte("Photoshop 3.0\x008BIM"))...)
+	out = append(out, segment(0xe2, []byte("MPF\x00II*\x00"))...)
+	out = append(out, segment(0xe2, []byte("ICC_PROFILE\x00\x01\x01profile"))...)
+	out = append(out, segment(0xfe, []byte("secret comment"))...)
+	out = append(out, plain[2:]...)
+	out = append(out, 0xff, 0xd8)
+	out = append(out, segment(0xe1, []byte("Exif\x00\x00GPSLatitude"))...)
+	out = append(out, []byte("motion photo")...)
+	return append(out, 0xff, 0xd9)
+}
+
+// withSize rewrites the dimensions in the SOF0 header of plain, leaving
+// the scan as it is
+func withSize(t *testing.T, plain []byte, w, h int) []byte {
+	t.Helper()
+	out := bytes.Clone(plain)
+	i := bytes.Index(out, []byte{0xff, 0xc0})
+	if i < 0 {
+		t.Fatal("no SOF0 marker")
+	}
+	binary.BigEndian.PutUint16(out[i+5:], uint16(h))
+	binary.BigEndian.PutUint16(out[i+7:], uint16(w))
+	return out
+}
+
+func segment(marker byte, body []byte) []byte {
+	out := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(out[2:], uint16(len(body)+2))
+	return append(out, body...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xc000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r < 0x4000 && g < 0x4000 && b > 0xc000
+}
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(out, typ...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[4:]))
+}
+
+func webpChunk(fourcc string, data []byte) []byte {
+	out := append([]byte(fourcc), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(data)))
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := concat(payload...)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, typ...), body...)
+}
+
+// mdat64 uses the 64-bit box size, as large recordings do
+func mdat64(samples []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, 1)
+	out = append(out, "mdat"...)
+	out = binary.BigEndian.AppendUint64(out, uint64(16+len(samples)))
+	return append(out, samples...)
+}
+
+func mvhd0(timescale, duration uint32) []byte {
+	body := make([]byte, 100)
+	binary.BigEndian.PutUint32(body[12:], timescale)
+	binary.BigEndian.PutUint32(body[16:], duration)
+	return mp4Box("mvhd", body)
+}
+
+func mvhd1(timescale uint32, duration uint64) []byte {
+	body := make([]byte, 112)
+	body[0] = 1
+	binary.BigEndian.PutUint32(body[20:], timescale)
+	binary.BigEndian.PutUint64(body[24:], duration)
+	return mp4Box("mvhd", body)
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func tempFile(t *testing.T, data []byte) *os.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
@@ -0,0 +1,159 @@
+package media
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// File is what CleanVideo needs from the spooled upload
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// box is an ISO base media (MP4/QuickTime) box spanning [start, end);
+// its payload starts at body
+type box struct {
+	typ              string
+	start, body, end int64
+}
+
+// CleanVideo blanks the user data and metadata boxes of an MP4 or
+// QuickTime file in place: moov/udta, moov/meta, the same boxes of each
+// track, and top-level meta and uuid (XMP) boxes. They become "free" boxes
+// of the same size, so no sample offset moves. It returns the duration
+// from the movie header.
+func CleanVideo(f File, size int64, contentType string) (Info, error) {
+	top, err := readBoxes(f, 0, size)
+	if err != nil {
+		return Info{}, err
+	}
+	if len(top) == 0 || top[0].typ != "ftyp" {
+		return Info{}, invalid("video: missing ftyp box")
+	}
+	info := Info{ContentType: contentType, Kind: KindVideo}
+	foundMoov := false
+	for _, b := range top {
+		switch b.typ {
+		case "meta", "uuid", "udta":
+			if err := blank(f, b); err != nil {
+				return Info{}, err
+			}
+		case "moov":
+			foundMoov = true
+			if info.Duration, err = cleanMoov(f, b); err != nil {
+				return Info{}, err
+			}
+		}
+	}
+	if !foundMoov {
+		return Info{}, invalid("video: missing moov box")
+	}
+	return info, nil
+}
+
+func cleanMoov(f File, moov box) (time.Duration, error) {
+	children, err := readBoxes(f, moov.body, moov.end)
+	if err != nil {
+		return 0, err
+	}
+	var duration time.Duration
+	for _, b := range children {
+		switch b.typ {
+		case "mvhd":
+			if duration, err = movieDuration(f, b); err != nil {
+				return 0, err
+			}
+		case "udta", "meta":
+			if err := blank(f, b); err != nil {
+				return 0, err
+			}
+		case "trak":
+			tracks, err := readBoxes(f, b.body, b.end)
+			if err != nil {
+				return 0, err
+			}
+			for _, t := range tracks {
+				if t.typ == "udta" || t.typ == "meta" {
+					if err := blank(f, t); err != nil {
+						return 0, err
+					}
+				}
+			}
+		}
+	}
+	return duration, nil
+}
+
+// readBoxes lists the boxes between start and end
+func readBoxes(f File, start, end int64) ([]box, error) {
+	var out []box
+	var hdr [16]byte
+	for pos := start; pos < end; {
+		if end-pos < 8 {
+			return nil, invalid("video: truncated box")
+		}
+		if _, err := f.ReadAt(hdr[:8], pos); err != nil {
+			return nil, invalid("video: %v", err)
+		}
+		b := box{typ: string(hdr[4:8]), start: pos, body: pos + 8}
+		switch n := int64(binary.BigEndian.Uint32(hdr[:4])); n {
+		case 0: // extends to the end of the file
+			b.end = end
+		case 1: // 64-bit size follows the type
+			if _, err := f.ReadAt(hdr[8:16], pos+8); err != nil {
+				return nil, invalid("video: %v", err)
+			}
+			b.body += 8
+			b.end = pos + int64(binary.BigEndian.Uint64(hdr[8:16]))
+		default:
+			b.end = pos + n
+		}
+		if b.end < b.body || b.end > end {
+			return nil, invalid("video: box %q overruns its parent", b.typ)
+		}
+		out = append(out, b)
+		pos = b.end
+	}
+	return out, nil
+}
+
+// blank turns b into a zero-filled free box of the same size
+func blank(f File, b box) error {
+	if _, err := f.WriteAt([]byte("free"), b.start+4); err != nil {
+		return err
+	}
+	zeros := make([]byte, 32<<10)
+	for pos := b.body; pos < b.end; pos += int64(len(zeros)) {
+		n := min(int64(len(zeros)), b.end-pos)
+		if _, err := f.WriteAt(zeros[:n], pos); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// movieDuration reads the timescale and duration of an mvhd box
+func movieDuration(f File, b box) (time.Duration, error) {
+	var buf [32]byte
+	n := min(int64(len(buf)), b.end-b.body)
+	if _, err := f.ReadAt(buf[:n], b.body); err != nil && err != io.EOF {
+		return 0, invalid("video: %v", err)
+	}
+	var timescale, units uint64
+	switch {
+	case n >= 20 && buf[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(buf[12:16]))
+		units = uint64(binary.BigEndian.Uint32(buf[16:20]))
+	case n >= 32 && buf[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(buf[20:24]))
+		units = binary.BigEndian.Uint64(buf[24:32])
+	default:
+		return 0, invalid("video: bad movie header")
+	}
+	if timescale == 0 {
+		return 0, invalid("video: bad movie header")
+	}
+	return time.Duration(float64(units) / float64(timescale) * float64(time.Second)), nil
+}
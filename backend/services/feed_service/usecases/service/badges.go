@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"feed_service/domain"
+)
+
+// Badges lists the achievements a user has earned
+func (s *feedService) Badges(ctx context.Context, userID string) ([]domain.BadgeResponse, error) {
+	return s.badges.Badges(ctx, userID)
+}
+
+// EvaluateBadges awards what the user's activity has earned so far
+func (s *feedService) EvaluateBadges(ctx context.Context, userID string) ([]domain.BadgeResponse, error) {
+	return s.badges.Evaluate(ctx, userID)
+}
+
+// evaluateBadges runs the achievements engine after an activity. Badges are
+// a bonus, so a failure is logged rather than failing the activity.
+func (s *feedService) evaluateBadges(ctx context.Context, userID string) {
+	awarded, err := s.badges.Evaluate(ctx, userID)
+	if err != nil {
+		log.Printf("evaluateBadges: user %s: %v", userID, err)
+		return
+	}
+	for _, b := range awarded {
+		log.Printf("evaluateBadges: user %s earned %q", userID, b.ID)
+	}
+}
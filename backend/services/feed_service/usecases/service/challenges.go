@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+	"feed_service/usecases"
+)
+
+// entryClockSkew tolerates client clocks slightly ahead of ours
+const entryClockSkew = 5 * time.Minute
+
+// CreateChallenge creates a challenge; the creator joins it right away
+func (s *feedService) CreateChallenge(ctx context.Context, userID string, req domain.ChallengeRequest) (domain.ChallengeResponse, error) {
+	c := req.ToChallenge(userID)
+	if err := s.challenges.CreateChallenge(ctx, c); err != nil {
+		return domain.ChallengeResponse{}, err
+	}
+	out := c.ToResponse(time.Now())
+	out.Participants, out.Joined = 1, true
+	return out, nil
+}
+
+// getChallenge maps a missing challenge to usecases.ErrNotFound
+func (s *feedService) getChallenge(ctx context.Context, challengeID string) (*domain.Challenge, error) {
+	c, err := s.challenges.GetChallenge(ctx, challengeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrNotFound
+	}
+	return c, err
+}
+
+// GetChallenge returns a challenge with its participant count
+func (s *feedService) GetChallenge(ctx context.Context, challengeID, viewerID string) (domain.ChallengeResponse, error) {
+	c, err := s.getChallenge(ctx, challengeID)
+	if err != nil {
+		return domain.ChallengeResponse{}, err
+	}
+	out := []domain.ChallengeResponse{c.ToResponse(time.Now())}
+	if err := s.withParticipation(ctx, viewerID, out); err != nil {
+		return domain.ChallengeResponse{}, err
+	}
+	return out[0], nil
+}
+
+// ListChallenges returns a page of challenges, newest first, optionally
+// only the upcoming, active or finished ones
+func (s *feedService) ListChallenges(ctx context.Context, status, viewerID string, page domain.PageRequest) (domain.Page[domain.ChallengeResponse], error) {
+	now := time.Now()
+	challenges, err := s.challenges.ListChallenges(ctx, status, now, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.ChallengeResponse]{}, err
+	}
+	out := domain.Page[domain.ChallengeResponse]{Items: make([]domain.ChallengeResponse, 0, min(len(challenges), page.Limit))}
+	if len(challenges) > page.Limit {
+		challenges = challenges[:page.Limit]
+		last := challenges[page.Limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, c := range challenges {
+		out.Items = append(out.Items, c.ToResponse(now))
+	}
+	if err := s.withParticipation(ctx, viewerID, out.Items); err != nil {
+		return domain.Page[domain.ChallengeResponse]{}, err
+	}
+	return out, nil
+}
+
+// withParticipation fills participant counts and joined flags, two queries per page
+func (s *feedService) withParticipation(ctx context.Context, viewerID string, items []domain.ChallengeResponse) error {
+	ids := make([]string, len(items))
+	for i, c := range items {
+		ids[i] = c.ChallengeID
+	}
+	counts, err := s.challenges.CountParticipants(ctx, ids)
+	if err != nil {
+		return err
+	}
+	joined, err := s.challenges.JoinedChallenges(ctx, viewerID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Participants = counts[items[i].ChallengeID]
+		items[i].Joined = joined[items[i].ChallengeID]
+	}
+	return nil
+}
+
+// DeleteChallenge removes a challenge with its entries
+func (s *feedService) DeleteChallenge(ctx context.Context, challengeID string) error {
+	return s.challenges.DeleteChallenge(ctx, challengeID)
+}
+
+// JoinChallenge adds the user to a challenge that has not finished yet,
+// checking the join code when the policy asks for one. Joining twice is a no-op.
+func (s *feedService) JoinChallenge(ctx context.Context, challengeID, userID string, req domain.JoinChallengeRequest) (domain.ChallengeResponse, error) {
+	c, err := s.getChallenge(ctx, challengeID)
+	if err != nil {
+		return domain.ChallengeResponse{}, err
+	}
+	joined, err := s.challenges.IsParticipant(ctx, challengeID, userID)
+	if err != nil {
+		return domain.ChallengeResponse{}, err
+	}
+	if !joined {
+		now := time.Now()
+		if c.JoinPolicy == domain.JoinClosed || c.Status(now) == domain.ChallengeFinished {
+			return domain.ChallengeResponse{}, usecases.ErrChallengeClosed
+		}
+		if c.JoinPolicy == domain.JoinCode && subtle.ConstantTimeCompare([]byte(req.Code), []byte(c.JoinCode)) != 1 {
+			return domain.ChallengeResponse{}, usecases.ErrWrongJoinCode
+		}
+		err := s.challenges.Join(ctx, &domain.ChallengeParticipant{
+			ChallengeID: challengeID,
+			UserID:      userID,
+			JoinedAt:    now,
+		})
+		if err != nil {
+			return domain.ChallengeResponse{}, err
+		}
+	}
+	return s.GetChallenge(ctx, challengeID, userID)
+}
+
+// LeaveChallenge removes the user and their entries from the leaderboard
+func (s *feedService) LeaveChallenge(ctx context.Context, challengeID, userID string) (domain.ChallengeResponse, error) {
+	if _, err := s.getChallenge(ctx, challengeID); err != nil {
+		return domain.ChallengeResponse{}, err
+	}
+	if err := s.challenges.Leave(ctx, challengeID, userID); err != nil {
+		return domain.ChallengeResponse{}, err
+	}
+	return s.GetChallenge(ctx, challengeID, userID)
+}
+
+// AddChallengeEntry records a participant's value; it must fall within the
+// challenge dates and not lie in the future
+func (s *feedService) AddChallengeEntry(ctx context.Context, challengeID, userID string, req domain.ChallengeEntryRequest) (domain.ChallengeEntryResponse, error) {
+	c, err := s.getChallenge(ctx, challengeID)
+	if err != nil {
+		return domain.ChallengeEntryResponse{}, err
+	}
+	joined, err := s.challenges.IsParticipant(ctx, challengeID, userID)
+	if err != nil {
+		return domain.ChallengeEntryResponse{}, err
+	}
+	if !joined {
+		return domain.ChallengeEntryResponse{}, usecases.ErrNotParticipant
+	}
+
+	now := time.Now()
+	recordedAt := now
+	if req.RecordedAt != nil {
+		recordedAt = *req.RecordedAt
+	}
+	if recordedAt.Before(c.StartsAt) || !recordedAt.Before(c.EndsAt) || recordedAt.After(now.Add(entryClockSkew)) {
+		return domain.ChallengeEntryResponse{}, usecases.ErrOutsideWindow
+	}
+
+	e := &domain.ChallengeEntry{
+		EntryID:     domain.NewUUID(),
+		ChallengeID: challengeID,
+		UserID:      userID,
+		Value:       req.Value,
+		RecordedAt:  recordedAt,
+	}
+	if err := s.challenges.AddEntry(ctx, e); err != nil {
+		return domain.ChallengeEntryResponse{}, err
+	}
+	return e.ToResponse(), nil
+}
+
+// DeleteChallengeEntry takes an entry back
+func (s *feedService) DeleteChallengeEntry(ctx context.Context, challengeID, entryID, userID string, moderator bool) error {
+	e, err := s.challenges.GetEntry(ctx, entryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return usecases.ErrNotFound
+		}
+		return err
+	}
+	if e.ChallengeID != challengeID {
+		return usecases.ErrNotFound
+	}
+	if e.UserID != userID && !moderator {
+		return usecases.ErrForbidden
+	}
+	return s.challenges.DeleteEntry(ctx, entryID)
+}
+
+// Leaderboard returns a page of standings and the viewer's own row
+func (s *feedService) Leaderboard(ctx context.Context, challengeID, viewerID string, page domain.OffsetPage) (domain.Leaderboard, error) {
+	c, err := s.getChallenge(ctx, challengeID)
+	if err != nil {
+		return domain.Leaderboard{}, err
+	}
+	rows, err := s.challenges.Leaderboard(ctx, c, page.Offset, page.Limit+1)
+	if err != nil {
+		return domain.Leaderboard{}, err
+	}
+	out := domain.Leaderboard{Items: rows}
+	if len(rows) > page.Limit {
+		out.Items = rows[:page.Limit]
+		out.NextCursor = page.NextCursor()
+	}
+	if out.Items == nil {
+		out.Items = []domain.LeaderboardRow{}
+	}
+	if viewerID != "" {
+		if out.Me, err = s.challenges.LeaderboardRow(ctx, c, viewerID); err != nil {
+			return domain.Leaderboard{}, err
+		}
+	}
+	return out, nil
+}
+
+// ListChallengePublications is the challenge's own feed, newest first
+func (s *feedService) ListChallengePublications(ctx context.Context, challengeID, viewerID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error) {
+	if _, err := s.getChallenge(ctx, challengeID); err != nil {
+		return domain.Page[domain.PublicationResponse]{}, err
+	}
+	filter.ViewerID = viewerID
+	pubs, err := s.challenges.ListChallengePublications(ctx, challengeID, filter, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.PublicationResponse]{}, err
+	}
+	out := publicationPage(pubs, page.Limit)
+	if err := s.decoratePublications(ctx, viewerID, out.Items); err != nil {
+		return domain.Page[domain.PublicationResponse]{}, err
+	}
+	return out, nil
+}
+
+// resolveChallengeTag checks that the author takes part in the challenge
+// a publication is tagged to
+func (s *feedService) resolveChallengeTag(ctx context.Context, authorID string, challengeID *string) (*string, error) {
+	if challengeID == nil {
+		return nil, nil
+	}
+	joined, err := s.challenges.IsParticipant(ctx, *challengeID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if !joined {
+		return nil, usecases.ErrNotParticipant
+	}
+	return challengeID, nil
+}
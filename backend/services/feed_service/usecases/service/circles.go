@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+	"feed_service/usecases"
+)
+
+// CreateCircle creates a circle; every seeded member must have a profile
+func (s *feedService) CreateCircle(ctx context.Context, userID string, req domain.CircleRequest) (domain.CircleResponse, error) {
+	members := make([]string, 0, len(req.MemberIDs))
+	for _, id := range req.MemberIDs {
+		if id == userID || slices.Contains(members, id) {
+			continue
+		}
+		if _, err := s.profileName(ctx, id); err != nil {
+			return domain.CircleResponse{}, err
+		}
+		members = append(members, id)
+	}
+	c := &domain.Circle{CircleID: domain.NewUUID(), UserID: userID, Name: req.Name}
+	if err := s.circles.CreateCircle(ctx, c, members); err != nil {
+		return domain.CircleResponse{}, err
+	}
+	out := c.ToResponse()
+	out.MemberCount = int64(len(members))
+	out.MemberIDs = members
+	return out, nil
+}
+
+// getCircle maps a missing circle to usecases.ErrNotFound
+func (s *feedService) getCircle(ctx context.Context, circleID string) (*domain.Circle, error) {
+	c, err := s.circles.GetCircle(ctx, circleID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrNotFound
+	}
+	return c, err
+}
+
+// GetCircle returns a circle with its members
+func (s *feedService) GetCircle(ctx context.Context, circleID string) (domain.CircleResponse, error) {
+	c, err := s.getCircle(ctx, circleID)
+	if err != nil {
+		return domain.CircleResponse{}, err
+	}
+	out := c.ToResponse()
+	if out.MemberIDs, err = s.circles.ListMembers(ctx, circleID); err != nil {
+		return domain.CircleResponse{}, err
+	}
+	if out.MemberIDs == nil {
+		out.MemberIDs = []string{}
+	}
+	out.MemberCount = int64(len(out.MemberIDs))
+	return out, nil
+}
+
+// ListCircles returns the user's circles by name with their sizes
+func (s *feedService) ListCircles(ctx context.Context, userID string) ([]domain.CircleResponse, error) {
+	circles, err := s.circles.ListCircles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(circles))
+	for i, c := range circles {
+		ids[i] = c.CircleID
+	}
+	counts, err := s.circles.CountMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.CircleResponse, 0, len(circles))
+	for _, c := range circles {
+		resp := c.ToResponse()
+		resp.MemberCount = counts[c.CircleID]
+		out = append(out, resp)
+	}
+	return out, nil
+}
+
+// RenameCircle changes the name of a circle
+func (s *feedService) RenameCircle(ctx context.Context, circleID, name string) (domain.CircleResponse, error) {
+	if err := s.circles.RenameCircle(ctx, circleID, name); err != nil {
+		return domain.CircleResponse{}, err
+	}
+	return s.GetCircle(ctx, circleID)
+}
+
+// DeleteCircle removes a circle; its publications become private
+func (s *feedService) DeleteCircle(ctx context.Context, circleID string) error {
+	return s.circles.DeleteCircle(ctx, circleID)
+}
+
+// AddCircleMember puts a user with a profile in the circle
+func (s *feedService) AddCircleMember(ctx context.Context, circleID, memberID string) (domain.CircleResponse, error) {
+	c, err := s.getCircle(ctx, circleID)
+	if err != nil {
+		return domain.CircleResponse{}, err
+	}
+	if memberID == c.UserID {
+		return domain.CircleResponse{}, usecases.ErrSelfCircle
+	}
+	counts, err := s.circles.CountMembers(ctx, []string{circleID})
+	if err != nil {
+		return domain.CircleResponse{}, err
+	}
+	if counts[circleID] >= domain.MaxCircleMembers {
+		return domain.CircleResponse{}, usecases.ErrCircleFull
+	}
+	if _, err := s.profileName(ctx, memberID); err != nil {
+		return domain.CircleResponse{}, err
+	}
+	if err := s.circles.AddMember(ctx, circleID, memberID); err != nil {
+		return domain.CircleResponse{}, err
+	}
+	return s.GetCircle(ctx, circleID)
+}
+
+// RemoveCircleMember takes a user out of the circle
+func (s *feedService) RemoveCircleMember(ctx context.Context, circleID, memberID string) (domain.CircleResponse, error) {
+	if err := s.circles.RemoveMember(ctx, circleID, memberID); err != nil {
+		return domain.CircleResponse{}, err
+	}
+	return s.GetCircle(ctx, circleID)
+}
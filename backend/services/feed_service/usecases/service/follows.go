@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+
+	"feed_service/domain"
+	"feed_service/usecases"
+)
+
+// Follow makes followerID follow followeeID, who must have a profile
+func (s *feedService) Follow(ctx context.Context, followerID, followeeID string) (domain.FollowCounts, error) {
+	if followerID == followeeID {
+		return domain.FollowCounts{}, usecases.ErrSelfFollow
+	}
+	if _, err := s.profileName(ctx, followeeID); err != nil {
+		return domain.FollowCounts{}, err
+	}
+	if err := s.follows.Follow(ctx, followerID, followeeID); err != nil {
+		return domain.FollowCounts{}, err
+	}
+	return s.FollowCounts(ctx, followeeID, followerID)
+}
+
+// Unfollow removes the edge if there is one
+func (s *feedService) Unfollow(ctx context.Context, followerID, followeeID string) (domain.FollowCounts, error) {
+	if err := s.follows.Unfollow(ctx, followerID, followeeID); err != nil {
+		return domain.FollowCounts{}, err
+	}
+	return s.FollowCounts(ctx, followeeID, followerID)
+}
+
+// FollowCounts returns follower and following counts of userID as seen by viewerID
+func (s *feedService) FollowCounts(ctx context.Context, userID, viewerID string) (domain.FollowCounts, error) {
+	out := domain.FollowCounts{UserID: userID}
+	var err error
+	if out.Followers, err = s.follows.CountFollowers(ctx, userID); err != nil {
+		return domain.FollowCounts{}, err
+	}
+	if out.Following, err = s.follows.CountFollowing(ctx, userID); err != nil {
+		return domain.FollowCounts{}, err
+	}
+	if viewerID != "" && viewerID != userID {
+		if out.FollowedByMe, err = s.follows.IsFollowing(ctx, viewerID, userID); err != nil {
+			return domain.FollowCounts{}, err
+		}
+	}
+	return out, nil
+}
+
+// ListFollowers returns a page of the users following userID, newest first
+func (s *feedService) ListFollowers(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.FollowResponse], error) {
+	edges, err := s.follows.ListFollowers(ctx, userID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.FollowResponse]{}, err
+	}
+	return followPage(edges, page.Limit, func(f domain.Follow) string { return f.FollowerID }), nil
+}
+
+// ListFollowing returns a page of the users userID follows, newest first
+func (s *feedService) ListFollowing(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.FollowResponse], error) {
+	edges, err := s.follows.ListFollowing(ctx, userID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.FollowResponse]{}, err
+	}
+	return followPage(edges, page.Limit, func(f domain.Follow) string { return f.FolloweeID }), nil
+}
+
+// followPage trims the look-ahead row; other picks the user shown for each edge
+func followPage(edges []domain.Follow, limit int, other func(domain.Follow) string) domain.Page[domain.FollowResponse] {
+	out := domain.Page[domain.FollowResponse]{Items: make([]domain.FollowResponse, 0, min(len(edges), limit))}
+	if len(edges) > limit {
+		edges = edges[:limit]
+		last := edges[limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, f := range edges {
+		out.Items = append(out.Items, domain.FollowResponse{UserID: other(f), FollowedAt: f.CreatedAt})
+	}
+	return out
+}
+
+// Timeline returns the caller's home feed: their own publications and
+// those of everyone they follow, newest first
+func (s *feedService) Timeline(ctx context.Context, userID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error) {
+	filter.ViewerID = userID
+	pubs, err := s.follows.Timeline(ctx, userID, filter, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.PublicationResponse]{}, err
+	}
+	out := publicationPage(pubs, page.Limit)
+	if err := s.decoratePublications(ctx, userID, out.Items); err != nil {
+		return domain.Page[domain.PublicationResponse]{}, err
+	}
+	return out, nil
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+	"feed_service/usecases"
+)
+
+// CreateGoal starts a new active goal
+func (s *feedService) CreateGoal(ctx context.Context, userID string, req domain.GoalRequest) (domain.GoalResponse, error) {
+	g := req.ToGoal(userID)
+	if err := s.goals.CreateGoal(ctx, g); err != nil {
+		return domain.GoalResponse{}, err
+	}
+	return g.ToResponse(), nil
+}
+
+// getGoal maps a missing goal to usecases.ErrNotFound
+func (s *feedService) getGoal(ctx context.Context, goalID string) (*domain.Goal, error) {
+	g, err := s.goals.GetGoal(ctx, goalID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrNotFound
+	}
+	return g, err
+}
+
+// GetGoal returns a goal with its progress
+func (s *feedService) GetGoal(ctx context.Context, goalID string) (domain.GoalResponse, error) {
+	g, err := s.getGoal(ctx, goalID)
+	if err != nil {
+		return domain.GoalResponse{}, err
+	}
+	return g.ToResponse(), nil
+}
+
+// ListGoals returns a page of a user's goals, newest first
+func (s *feedService) ListGoals(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.GoalResponse], error) {
+	goals, err := s.goals.ListGoals(ctx, userID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.GoalResponse]{}, err
+	}
+	out := domain.Page[domain.GoalResponse]{Items: make([]domain.GoalResponse, 0, min(len(goals), page.Limit))}
+	if len(goals) > page.Limit {
+		goals = goals[:page.Limit]
+		last := goals[page.Limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, g := range goals {
+		out.Items = append(out.Items, g.ToResponse())
+	}
+	return out, nil
+}
+
+// UpdateGoal changes the title, target, deadline or status of a goal. A
+// new target settles the status against the progress so far, unless the
+// request sets the status too.
+func (s *feedService) UpdateGoal(ctx context.Context, goalID string, req domain.UpdateGoalRequest) (domain.GoalResponse, error) {
+	g, err := s.getGoal(ctx, goalID)
+	if err != nil {
+		return domain.GoalResponse{}, err
+	}
+	if req.Title != nil {
+		g.Title = *req.Title
+	}
+	if req.TargetValue != nil && *req.TargetValue != g.TargetValue {
+		g.Retarget(*req.TargetValue, time.Now())
+	}
+	if req.Deadline != nil {
+		g.Deadline = req.Deadline
+	}
+	if req.Status != nil && *req.Status != g.Status {
+		g.Status = *req.Status
+		g.CompletedAt = nil
+		if g.Status == domain.GoalCompleted {
+			now := time.Now()
+			g.CompletedAt = &now
+		}
+	}
+	if err := s.goals.UpdateGoal(ctx, g); err != nil {
+		return domain.GoalResponse{}, err
+	}
+	s.evaluateBadges(ctx, g.UserID)
+	return g.ToResponse(), nil
+}
+
+// DeleteGoal removes a goal with its check-ins
+func (s *feedService) DeleteGoal(ctx context.Context, goalID string) error {
+	return s.goals.DeleteGoal(ctx, goalID)
+}
+
+// AddCheckIn records progress; an active goal that reaches its target is
+// marked completed
+func (s *feedService) AddCheckIn(ctx context.Context, goalID string, req domain.CheckInRequest) (domain.CheckInResult, error) {
+	g, err := s.getGoal(ctx, goalID)
+	if err != nil {
+		return domain.CheckInResult{}, err
+	}
+	if g.Status == domain.GoalAbandoned {
+		return domain.CheckInResult{}, usecases.ErrGoalAbandoned
+	}
+
+	c := &domain.CheckIn{
+		CheckInID:  domain.NewUUID(),
+		GoalID:     g.GoalID,
+		UserID:     g.UserID,
+		Note:       req.Note,
+		RecordedAt: time.Now(),
+	}
+	switch {
+	case req.Value != nil:
+		c.Value = *req.Value
+	case g.Type == domain.GoalHabit:
+		c.Value = 1
+	default:
+		return domain.CheckInResult{}, usecases.ErrValueRequired
+	}
+	if req.RecordedAt != nil {
+		c.RecordedAt = *req.RecordedAt
+	}
+	if err := s.goals.AddCheckIn(ctx, c); err != nil {
+		return domain.CheckInResult{}, err
+	}
+
+	if err := s.refreshProgress(ctx, g); err != nil {
+		return domain.CheckInResult{}, err
+	}
+	s.evaluateBadges(ctx, g.UserID)
+	return domain.CheckInResult{CheckIn: c.ToResponse(), Goal: g.ToResponse()}, nil
+}
+
+// ListCheckIns returns a page of a goal's check-ins, newest first
+func (s *feedService) ListCheckIns(ctx context.Context, goalID string, page domain.PageRequest) (domain.Page[domain.CheckInResponse], error) {
+	checkIns, err := s.goals.ListCheckIns(ctx, goalID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.CheckInResponse]{}, err
+	}
+	out := domain.Page[domain.CheckInResponse]{Items: make([]domain.CheckInResponse, 0, min(len(checkIns), page.Limit))}
+	if len(checkIns) > page.Limit {
+		checkIns = checkIns[:page.Limit]
+		last := checkIns[page.Limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, c := range checkIns {
+		out.Items = append(out.Items, c.ToResponse())
+	}
+	return out, nil
+}
+
+// DeleteCheckIn removes a check-in and returns the goal with recomputed progress
+func (s *feedService) DeleteCheckIn(ctx context.Context, goalID, checkInID string) (domain.GoalResponse, error) {
+	g, err := s.getGoal(ctx, goalID)
+	if err != nil {
+		return domain.GoalResponse{}, err
+	}
+	c, err := s.goals.GetCheckIn(ctx, checkInID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.GoalResponse{}, usecases.ErrNotFound
+		}
+		return domain.GoalResponse{}, err
+	}
+	if c.GoalID != g.GoalID {
+		return domain.GoalResponse{}, usecases.ErrNotFound
+	}
+	if err := s.goals.DeleteCheckIn(ctx, checkInID); err != nil {
+		return domain.GoalResponse{}, err
+	}
+	if err := s.refreshProgress(ctx, g); err != nil {
+		return domain.GoalResponse{}, err
+	}
+	return g.ToResponse(), nil
+}
+
+// refreshProgress recomputes CurrentValue from the check-ins. It is
+// derived from all rows each time, so concurrent check-ins cannot drift.
+func (s *feedService) refreshProgress(ctx context.Context, g *domain.Goal) error {
+	value, ok, err := s.goals.GoalProgress(ctx, g.GoalID, g.Cumulative())
+	if err != nil {
+		return err
+	}
+	g.CurrentValue = g.StartValue
+	if ok {
+		g.CurrentValue = value
+	}
+	if g.Status == domain.GoalActive && g.Percent() >= 100 {
+		now := time.Now()
+		g.Status = domain.GoalCompleted
+		g.CompletedAt = &now
+	}
+	return s.goals.UpdateGoal(ctx, g)
+}
+
+// resolveAttachment checks that the goal and check-in named in req belong
+// to the author. A check-in alone also attaches its goal.
+func (s *feedService) resolveAttachment(ctx context.Context, authorID string, req domain.PublicationRequest) (goalID, checkInID *string, err error) {
+	if req.CheckInID != nil {
+		c, err := s.goals.GetCheckIn(ctx, *req.CheckInID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, usecases.ErrBadAttachment
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if c.UserID != authorID || (req.GoalID != nil && *req.GoalID != c.GoalID) {
+			return nil, nil, usecases.ErrBadAttachment
+		}
+		return &c.GoalID, &c.CheckInID, nil
+	}
+	if req.GoalID != nil {
+		g, err := s.goals.GetGoal(ctx, *req.GoalID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, usecases.ErrBadAttachment
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if g.UserID != authorID {
+			return nil, nil, usecases.ErrBadAttachment
+		}
+		return &g.GoalID, nil, nil
+	}
+	return nil, nil, nil
+}
+
+// withGoals embeds attached goals and check-ins, two queries per page
+func (s *feedService) withGoals(ctx context.Context, items []domain.PublicationResponse) error {
+	var goalIDs, checkInIDs []string
+	for _, p := range items {
+		if p.GoalID != nil {
+			goalIDs = append(goalIDs, *p.GoalID)
+		}
+		if p.CheckInID != nil {
+			checkInIDs = append(checkInIDs, *p.CheckInID)
+		}
+	}
+	goals, err := s.goals.GetGoals(ctx, goalIDs)
+	if err != nil {
+		return err
+	}
+	checkIns, err := s.goals.GetCheckIns(ctx, checkInIDs)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].GoalID != nil {
+			if g, ok := goals[*items[i].GoalID]; ok {
+				resp := g.ToResponse()
+				items[i].Goal = &resp
+			}
+		}
+		if items[i].CheckInID != nil {
+			if c, ok := checkIns[*items[i].CheckInID]; ok {
+				resp := c.ToResponse()
+				items[i].CheckIn = &resp
+			}
+		}
+	}
+	return nil
+}
+
+// decoratePublications fills in everything a publication shows besides its
+// own columns: reactions for the viewer, attached goal progress and tracks
+func (s *feedService) decoratePublications(ctx context.Context, viewerID string, items []domain.PublicationResponse) error {
+	if err := s.withPublicationReactions(ctx, viewerID, items); err != nil {
+		return err
+	}
+	return s.withAttachments(ctx, items)
+}
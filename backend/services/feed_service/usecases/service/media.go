@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+	"feed_service/usecases"
+	"feed_service/usecases/media"
+)
+
+// AttachMedia checks an uploaded photo or video, strips its metadata and
+// stores it as an attachment of the publication. The content type is
+// sniffed from the file, never taken from the client.
+func (s *feedService) AttachMedia(ctx context.Context, postID, userID string, file io.Reader) (domain.MediaResponse, error) {
+	br := bufio.NewReaderSize(file, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return domain.MediaResponse{}, err
+	}
+	contentType, kind, err := media.Sniff(head)
+	if err != nil {
+		return domain.MediaResponse{}, fmt.Errorf("%w: %v", usecases.ErrUnsupportedMedia, err)
+	}
+
+	count, err := s.media.CountPostMedia(ctx, postID)
+	if err != nil {
+		return domain.MediaResponse{}, err
+	}
+	if count >= domain.MaxMediaPerPublication {
+		return domain.MediaResponse{}, usecases.ErrTooManyMedia
+	}
+
+	m := &domain.Media{
+		MediaID:     domain.NewUUID(),
+		PostID:      postID,
+		UserID:      userID,
+		Kind:        kind,
+		ContentType: contentType,
+	}
+	m.Key = "media/" + m.MediaID + media.Extension(contentType)
+	if kind == media.KindImage {
+		err = s.storeImage(ctx, m, br)
+	} else {
+		err = s.storeVideo(ctx, m, br)
+	}
+	if err != nil {
+		return domain.MediaResponse{}, err
+	}
+	if err := s.media.CreateMedia(ctx, m); err != nil {
+		s.deleteBlob(ctx, m.Key)
+		return domain.MediaResponse{}, err
+	}
+	return s.mediaResponse(m), nil
+}
+
+// readLimited reads r whole, failing with ErrMediaTooLarge past limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: images are limited to %d MB", usecases.ErrMediaTooLarge, limit>>20)
+	}
+	return data, nil
+}
+
+// storeImage cleans an image in memory and writes it to the blob store
+func (s *feedService) storeImage(ctx context.Context, m *domain.Media, r io.Reader) error {
+	data, err := readLimited(r, s.mediaCfg.MaxImageBytes)
+	if err != nil {
+		return err
+	}
+	clean, info, err := media.CleanImage(data, m.ContentType)
+	if err != nil {
+		return fmt.Errorf("%w: %v", usecases.ErrUnsupportedMedia, err)
+	}
+	m.Size, m.Width, m.Height = int64(len(clean)), info.Width, info.Height
+	return s.blobs.Put(ctx, m.Key, m.ContentType, bytes.NewReader(clean), m.Size)
+}
+
+// storeVideo spools a video to a temporary file, blanks its metadata boxes
+// in place and writes it to the blob store
+func (s *feedService) storeVideo(ctx context.Context, m *domain.Media, r io.Reader) error {
+	tmp, err := os.CreateTemp("", "media-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, s.mediaCfg.MaxVideoBytes+1))
+	if err != nil {
+		return err
+	}
+	if size > s.mediaCfg.MaxVideoBytes {
+		return fmt.Errorf("%w: videos are limited to %d MB", usecases.ErrMediaTooLarge, s.mediaCfg.MaxVideoBytes>>20)
+	}
+	info, err := media.CleanVideo(tmp, size, m.ContentType)
+	if err != nil {
+		return fmt.Errorf("%w: %v", usecases.ErrUnsupportedMedia, err)
+	}
+	if info.Duration > s.mediaCfg.MaxVideo {
+		return fmt.Errorf("%w: videos are limited to %v", usecases.ErrMediaTooLarge, s.mediaCfg.MaxVideo)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	m.Size, m.DurationMs = size, info.Duration.Milliseconds()
+	return s.blobs.Put(ctx, m.Key, m.ContentType, tmp, size)
+}
+
+// GetMedia returns the record of an attachment
+func (s *feedService) GetMedia(ctx context.Context, mediaID string) (domain.MediaResponse, error) {
+	m, err := s.getMedia(ctx, mediaID)
+	if err != nil {
+		return domain.MediaResponse{}, err
+	}
+	return s.mediaResponse(m), nil
+}
+
+// OpenMedia returns an attachment with a reader over its file when the
+// viewer may see its publication; the caller closes it
+func (s *feedService) OpenMedia(ctx context.Context, mediaID, viewerID string) (domain.MediaResponse, io.ReadCloser, error) {
+	m, err := s.getMedia(ctx, mediaID)
+	if err != nil {
+		return domain.MediaResponse{}, nil, err
+	}
+	if _, err := s.visiblePublication(ctx, m.PostID, viewerID); err != nil {
+		return domain.MediaResponse{}, nil, err
+	}
+	body, err := s.blobs.Get(ctx, m.Key)
+	if errors.Is(err, repository.ErrBlobNotFound) {
+		return domain.MediaResponse{}, nil, usecases.ErrNotFound
+	}
+	if err != nil {
+		return domain.MediaResponse{}, nil, err
+	}
+	return s.mediaResponse(m), body, nil
+}
+
+// DeleteMedia removes an attachment and its file
+func (s *feedService) DeleteMedia(ctx context.Context, mediaID string) error {
+	m, err := s.getMedia(ctx, mediaID)
+	if err != nil {
+		return err
+	}
+	if err := s.media.DeleteMedia(ctx, mediaID); err != nil {
+		return err
+	}
+	s.deleteBlob(ctx, m.Key)
+	return nil
+}
+
+// deletePostMedia removes the attachments of a deleted publication
+func (s *feedService) deletePostMedia(ctx context.Context, postID string) error {
+	removed, err := s.media.DeletePostMedia(ctx, postID)
+	if err != nil {
+		return err
+	}
+	for _, m := range removed {
+		s.deleteBlob(ctx, m.Key)
+	}
+	return nil
+}
+
+// deleteBlob only logs failures: an orphaned file is harmless and the
+// record it belonged to is already gone
+func (s *feedService) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		log.Printf("deleteBlob: %s: %v", key, err)
+	}
+}
+
+// getMedia maps a missing attachment to usecases.ErrNotFound
+func (s *feedService) getMedia(ctx context.Context, mediaID string) (*domain.Media, error) {
+	m, err := s.media.GetMedia(ctx, mediaID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrNotFound
+	}
+	return m, err
+}
+
+func (s *feedService) mediaResponse(m *domain.Media) domain.MediaResponse {
+	out := m.ToResponse()
+	out.URL = s.mediaCfg.URLPrefix + "/" + m.MediaID
+	return out
+}
+
+// withMedia embeds the attachments of a page of publications
+func (s *feedService) withMedia(ctx context.Context, items []domain.PublicationResponse) error {
+	ids := make([]string, 0, len(items))
+	for _, p := range items {
+		ids = append(ids, p.PostID)
+	}
+	found, err := s.media.ListPostMedia(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		for _, m := range found[items[i].PostID] {
+			items[i].Media = append(items[i].Media, s.mediaResponse(&m))
+		}
+	}
+	return nil
+}
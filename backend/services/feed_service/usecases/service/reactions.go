@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"feed_service/domain"
+	"feed_service/usecases"
+)
+
+// ReactionTypes returns the configured reaction types
+func (s *feedService) ReactionTypes() []string {
+	return s.reactions
+}
+
+// AddReaction leaves a reaction; repeating it changes nothing
+func (s *feedService) AddReaction(ctx context.Context, targetType, targetID, userID, reactionType string) ([]domain.ReactionCount, error) {
+	if err := s.checkReactionTarget(ctx, targetType, targetID, userID, reactionType); err != nil {
+		return nil, err
+	}
+	err := s.repository.AddReaction(ctx, &domain.Reaction{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     userID,
+		Type:       reactionType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.evaluateBadges(ctx, userID)
+	return s.reactionSummary(ctx, targetType, targetID, userID)
+}
+
+// RemoveReaction takes a reaction back; removing a missing one changes nothing
+func (s *feedService) RemoveReaction(ctx context.Context, targetType, targetID, userID, reactionType string) ([]domain.ReactionCount, error) {
+	if err := s.checkReactionTarget(ctx, targetType, targetID, userID, reactionType); err != nil {
+		return nil, err
+	}
+	if err := s.repository.RemoveReaction(ctx, targetType, targetID, userID, reactionType); err != nil {
+		return nil, err
+	}
+	return s.reactionSummary(ctx, targetType, targetID, userID)
+}
+
+// checkReactionTarget validates the type and that the target exists and
+// is visible to the user
+func (s *feedService) checkReactionTarget(ctx context.Context, targetType, targetID, userID, reactionType string) error {
+	if !slices.Contains(s.reactions, reactionType) {
+		return usecases.ErrUnknownReaction
+	}
+	var err error
+	switch targetType {
+	case domain.KindPublication:
+		_, err = s.visiblePublication(ctx, targetID, userID)
+	case domain.KindComment:
+		_, err = s.visibleComment(ctx, targetID, userID)
+	default:
+		return usecases.ErrNotFound
+	}
+	return err
+}
+
+func (s *feedService) reactionSummary(ctx context.Context, targetType, targetID, viewerID string) ([]domain.ReactionCount, error) {
+	counts, err := s.repository.CountReactions(ctx, targetType, []string{targetID}, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if counts[targetID] == nil {
+		return []domain.ReactionCount{}, nil
+	}
+	return counts[targetID], nil
+}
+
+// withPublicationReactions fills Reactions of all items with one query
+func (s *feedService) withPublicationReactions(ctx context.Context, viewerID string, items []domain.PublicationResponse) error {
+	ids := make([]string, len(items))
+	for i, p := range items {
+		ids[i] = p.PostID
+	}
+	counts, err := s.repository.CountReactions(ctx, domain.KindPublication, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if c, ok := counts[items[i].PostID]; ok {
+			items[i].Reactions = c
+		}
+	}
+	return nil
+}
+
+// withCommentReactions fills Reactions of all items with one query
+func (s *feedService) withCommentReactions(ctx context.Context, viewerID string, items []domain.CommentResponse) error {
+	ids := make([]string, len(items))
+	for i, c := range items {
+		ids[i] = c.CommentID
+	}
+	counts, err := s.repository.CountReactions(ctx, domain.KindComment, ids, viewerID)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if c, ok := counts[items[i].CommentID]; ok {
+			items[i].Reactions = c
+		}
+	}
+	return nil
+}
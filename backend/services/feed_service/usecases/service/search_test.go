@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"feed_service/domain"
+	"feed_service/repository/memory"
+	"feed_service/usecases/media"
+)
+
+func newSearchService(repo *memory.SearchRepo) *feedService {
+	return NewFeedService(nil, nil, nil, nil, nil, nil, nil, nil, media.Config{}, nil, repo, "", nil).(*feedService)
+}
+
+// search runs a query through NewSearchQuery, as the handler does, and
+// follows the cursors to the end
+func search(t *testing.T, s *feedService, viewer string, params map[string]string) (ids []string, pages int) {
+	t.Helper()
+	cursor := ""
+	for {
+		q, err := domain.NewSearchQuery(params["q"], params["type"], params["author"], params["from"], params["to"], params["limit"], cursor)
+		if err != nil {
+			t.Fatalf("%v: %v", params, err)
+		}
+		q.ViewerID = viewer
+		page, err := s.Search(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Items == nil {
+			t.Fatal("nil items; want an empty list")
+		}
+		pages++
+		for _, h := range page.Items {
+			ids = append(ids, h.ID)
+		}
+		if page.NextCursor == "" || pages > 10 {
+			return ids, pages
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	repo := memory.NewSearchRepo()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, p := range []struct{ id, title, content string }{
+		{"a", "Morning run", "an easy run by the river"}, // rank 3
+		{"b", "Rest day", "no run today"},                // rank 1
+		{"c", "Run run run", ""},                         // rank 6
+		{"d", "Long run", "slow"},                        // rank 2
+		{"e", "Swim", "run later"},                       // rank 1, newer than b
+		{"f", "Yoga", "stretching"},
+	} {
+		pub := domain.Publication{PostID: p.id, UserID: author, Title: p.title, Content: p.content}
+		pub.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		repo.IndexPublication(pub)
+	}
+	s := newSearchService(repo)
+
+	want := []string{"c", "a", "d", "e", "b"}
+	for _, tt := range []struct {
+		limit string
+		pages int
+	}{{"2", 3}, {"5", 1}, {"1", 5}, {"100", 1}} {
+		ids, pages := search(t, s, stranger, map[string]string{"q": "run", "limit": tt.limit})
+		if !slices.Equal(ids, want) || pages != tt.pages {
+			t.Errorf("limit %s: %q in %d pages, want %q in %d", tt.limit, ids, pages, want, tt.pages)
+		}
+	}
+	if ids, _ := search(t, s, stranger, map[string]string{"q": "marathon"}); len(ids) != 0 {
+		t.Errorf("no match found %q", ids)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	repo := memory.NewSearchRepo()
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	add := func(id, user string, at time.Time) {
+		p := domain.Publication{PostID: id, UserID: user, Title: "ride"}
+		p.CreatedAt = at
+		repo.IndexPublication(p)
+		c := domain.Comment{CommentID: id + "-c", PostID: id, UserID: user, Content: "nice ride"}
+		c.CreatedAt = at
+		repo.IndexComment(c)
+	}
+	add("p1", "ann", day(1))
+	add("p2", "bob", day(2))
+	add("p3", "ann", day(3))
+	s := newSearchService(repo)
+
+	tests := []struct {
+		params map[string]string
+		want   []string
+	}{
+		{map[string]string{"type": "publication"}, []string{"p1", "p2", "p3"}},
+		{map[string]string{"type": "comment"}, []string{"p1-c", "p2-c", "p3-c"}},
+		{map[string]string{"author": "ann"}, []string{"p1", "p1-c", "p3", "p3-c"}},
+		// to is exclusive, and a date means its midnight
+		{map[string]string{"from": "2024-05-02", "to": "2024-05-03"}, []string{"p2", "p2-c"}},
+		{map[string]string{"from": "2024-05-02T12:00:00Z"}, []string{"p2", "p2-c", "p3", "p3-c"}},
+		{map[string]string{"author": "bob", "type": "comment", "to": "2024-05-02"}, nil},
+	}
+	for _, tt := range tests {
+		tt.params["q"] = "ride"
+		ids, _ := search(t, s, "", tt.params)
+		slices.Sort(ids)
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%v: %q, want %q", tt.params, ids, tt.want)
+		}
+	}
+}
+
+func TestSearchVisibility(t *testing.T) {
+	repo := memory.NewSearchRepo()
+	circle := circleID
+	for _, v := range []string{domain.VisibilityPublic, domain.VisibilityFollowers, domain.VisibilityCircle, domain.VisibilityPrivate} {
+		p := domain.Publication{PostID: v, UserID: author, Visibility: v, Title: "hike"}
+		if v == domain.VisibilityCircle {
+			p.CircleID = &circle
+		}
+		repo.IndexPublication(p)
+		repo.IndexComment(domain.Comment{CommentID: v + "-c", PostID: v, UserID: stranger, Content: "great hike"})
+	}
+	repo.Follow(follower, author)
+	repo.AddCircleMember(circleID, member)
+	s := newSearchService(repo)
+
+	visible := map[string][]string{
+		author:   {domain.VisibilityCircle, domain.VisibilityFollowers, domain.VisibilityPrivate, domain.VisibilityPublic},
+		follower: {domain.VisibilityFollowers, domain.VisibilityPublic},
+		member:   {domain.VisibilityCircle, domain.VisibilityPublic},
+		stranger: {domain.VisibilityPublic},
+		"":       {domain.VisibilityPublic},
+	}
+	for viewer, posts := range visible {
+		var want []string
+		for _, p := range posts {
+			want = append(want, p, p+"-c")
+		}
+		// the stranger wrote every comment, yet only sees those under
+		// publications they can see
+		ids, _ := search(t, s, viewer, map[string]string{"q": "hike"})
+		slices.Sort(ids)
+		if !slices.Equal(ids, want) {
+			t.Errorf("%q finds %q, want %q", viewer, ids, want)
+		}
+	}
+}
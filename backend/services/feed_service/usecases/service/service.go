@@ -6,11 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"sort"
 
 	"feed_service/domain"
 	repository "feed_service/repository"
 	"feed_service/usecases"
+	"feed_service/usecases/achievements"
+	"feed_service/usecases/media"
 )
 
 // feedService implements usecases.FeedService
@@ -19,14 +20,37 @@ import (
 
 type feedService struct {
 	repository repository.FeedRepository
+	follows    repository.FollowRepository
+	circles    repository.CircleRepository
+	goals      repository.GoalRepository
+	challenges repository.ChallengeRepository
+	tracks     repository.TrackRepository
+	media      repository.MediaRepository
+	blobs      repository.BlobStore
+	mediaCfg   media.Config
+	badges     *achievements.Engine
+	search     repository.SearchRepository
+	reactions  []string
 	profileURL string
 	httpClient *http.Client
 }
 
 // NewFeedService creates a new FeedService
-func NewFeedService(repo repository.FeedRepository, profileUrl string) usecases.FeedService {
+// reactionTypes is the configured set of allowed reaction types.
+func NewFeedService(repo repository.FeedRepository, follows repository.FollowRepository, circles repository.CircleRepository, goals repository.GoalRepository, challenges repository.ChallengeRepository, tracks repository.TrackRepository, mediaRepo repository.MediaRepository, blobs repository.BlobStore, mediaCfg media.Config, badges *achievements.Engine, search repository.SearchRepository, profileUrl string, reactionTypes []string) usecases.FeedService {
 	return &feedService{
 		repository: repo,
+		follows:    follows,
+		circles:    circles,
+		goals:      goals,
+		challenges: challenges,
+		tracks:     tracks,
+		media:      mediaRepo,
+		blobs:      blobs,
+		mediaCfg:   mediaCfg,
+		badges:     badges,
+		search:     search,
+		reactions:  reactionTypes,
 		profileURL: profileUrl,
 		httpClient: http.DefaultClient,
 	}
@@ -39,73 +63,112 @@ func (s *feedService) Health(ctx context.Context) error {
 
 // CreatePublication creates a new post
 func (s *feedService) CreatePublication(ctx context.Context, userID string, req domain.PublicationRequest) (domain.PublicationResponse, error) {
+	name, err := s.profileName(ctx, userID)
+	if err != nil {
+		return domain.PublicationResponse{}, err
+	}
+
+	pub := &domain.Publication{
+		PostID:     domain.NewUUID(),
+		UserID:     userID,
+		Name:       name,
+		Visibility: domain.VisibilityPublic,
+	}
+	if err := s.resolveCircle(ctx, userID, req); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	if pub.TrackID, err = s.resolveTrack(ctx, userID, pub.PostID, &req); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	req.ApplyTo(pub)
+	if pub.GoalID, pub.CheckInID, err = s.resolveAttachment(ctx, userID, req); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	if pub.ChallengeID, err = s.resolveChallengeTag(ctx, userID, req.ChallengeID); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	if err := s.repository.CreatePublication(pub); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	s.evaluateBadges(ctx, userID)
+	out := []domain.PublicationResponse{pub.ToResponse()}
+	if err := s.withAttachments(ctx, out); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	return out[0], nil
+}
+
+// profileName asks profile_service for the display name of userID
+func (s *feedService) profileName(ctx context.Context, userID string) (string, error) {
 	profileURL := fmt.Sprintf("%s/profile", s.profileURL)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, profileURL, nil)
 	if err != nil {
-		return domain.PublicationResponse{}, fmt.Errorf("build profile request: %w", err)
+		return "", fmt.Errorf("build profile request: %w", err)
 	}
 	httpReq.Header.Set("X-User-ID", userID)
 
 	resp, err := s.httpClient.Do(httpReq)
 	if err != nil {
-		return domain.PublicationResponse{}, fmt.Errorf("call profile service: %w", err)
+		return "", fmt.Errorf("call profile service: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return "", usecases.ErrUserNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		return domain.PublicationResponse{}, fmt.Errorf(
-			"profile service returned %d", resp.StatusCode,
-		)
+		return "", fmt.Errorf("profile service returned %d", resp.StatusCode)
 	}
 
 	var pr struct {
 		Name string `json:"name"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
-		return domain.PublicationResponse{}, fmt.Errorf("decode profile response: %w", err)
+		return "", fmt.Errorf("decode profile response: %w", err)
 	}
+	return pr.Name, nil
+}
 
-	pub := &domain.Publication{
-		PostID:  domain.NewUUID(),
-		UserID:  userID,
-		Name:    pr.Name,
-		Title:   req.Title,
-		Content: req.Content,
+// GetPublication returns a post by ID if the viewer may see it
+func (s *feedService) GetPublication(ctx context.Context, postID, viewerID string) (domain.PublicationResponse, error) {
+	pub, err := s.visiblePublication(ctx, postID, viewerID)
+	if err != nil {
+		return domain.PublicationResponse{}, err
 	}
-	if err := s.repository.CreatePublication(pub); err != nil {
+	out := []domain.PublicationResponse{pub.ToResponse()}
+	if err := s.decoratePublications(ctx, viewerID, out); err != nil {
 		return domain.PublicationResponse{}, err
 	}
-	return pub.ToResponse(), nil
+	return out[0], nil
 }
 
-// GetPublication returns a post by ID
-func (s *feedService) GetPublication(ctx context.Context, postID string) (domain.PublicationResponse, error) {
-	pub, err := s.repository.GetPublication(postID)
+// ListPublications returns a page of posts, newest first
+func (s *feedService) ListPublications(ctx context.Context, viewerID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error) {
+	// one extra row tells whether there is a next page
+	filter.ViewerID = viewerID
+	pubs, err := s.repository.ListPublications(ctx, filter, page.After, page.Limit+1)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return domain.PublicationResponse{}, usecases.ErrNotFound
-		}
-		return domain.PublicationResponse{}, err
+		return domain.Page[domain.PublicationResponse]{}, err
 	}
-	return pub.ToResponse(), nil
+	out := publicationPage(pubs, page.Limit)
+	if err := s.decoratePublications(ctx, viewerID, out.Items); err != nil {
+		return domain.Page[domain.PublicationResponse]{}, err
+	}
+	return out, nil
 }
 
-// ListPublications lists all posts sorted by newest first
-func (s *feedService) ListPublications(ctx context.Context) ([]domain.PublicationResponse, error) {
-	pubs, err := s.repository.ListPublications()
-	if err != nil {
-		return nil, err
+// publicationPage trims the look-ahead row and derives next_cursor from the last item
+func publicationPage(pubs []domain.Publication, limit int) domain.Page[domain.PublicationResponse] {
+	out := domain.Page[domain.PublicationResponse]{Items: make([]domain.PublicationResponse, 0, min(len(pubs), limit))}
+	if len(pubs) > limit {
+		pubs = pubs[:limit]
+		last := pubs[limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
 	}
-	// most fresh publications first
-	sort.Slice(pubs, func(i, j int) bool {
-		return pubs[i].CreatedAt.After(pubs[j].CreatedAt)
-	})
-
-	out := make([]domain.PublicationResponse, len(pubs))
-	for i, p := range pubs {
-		out[i] = p.ToResponse()
+	for _, p := range pubs {
+		out.Items = append(out.Items, p.ToResponse())
 	}
-	return out, nil
+	return out
 }
 
 // UpdatePublication updates post fields
@@ -114,84 +177,109 @@ func (s *feedService) UpdatePublication(ctx context.Context, postID string, req
 	if err != nil {
 		return domain.PublicationResponse{}, err
 	}
-	pub.Title = req.Title
-	pub.Content = req.Content
+	if err := s.resolveCircle(ctx, pub.UserID, req); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	if pub.TrackID, err = s.resolveTrack(ctx, pub.UserID, pub.PostID, &req); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	req.ApplyTo(pub)
+	if pub.GoalID, pub.CheckInID, err = s.resolveAttachment(ctx, pub.UserID, req); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	if pub.ChallengeID, err = s.resolveChallengeTag(ctx, pub.UserID, req.ChallengeID); err != nil {
+		return domain.PublicationResponse{}, err
+	}
 	if err := s.repository.UpdatePublication(pub); err != nil {
 		return domain.PublicationResponse{}, err
 	}
-	return pub.ToResponse(), nil
+	out := []domain.PublicationResponse{pub.ToResponse()}
+	if err := s.withAttachments(ctx, out); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	return out[0], nil
 }
 
-// DeletePublication permanently deletes a post
+// DeletePublication permanently deletes a post along with its media
 func (s *feedService) DeletePublication(ctx context.Context, postID string) error {
-	return s.repository.DeletePublication(postID)
+	if err := s.repository.DeletePublication(postID); err != nil {
+		return err
+	}
+	return s.deletePostMedia(ctx, postID)
 }
 
-// CreateComment adds a new comment to a post
+// CreateComment adds a new comment to a post the user can see
 func (s *feedService) CreateComment(ctx context.Context, userID string, req domain.PostCommentRequest) (domain.CommentResponse, error) {
-	profileURL := fmt.Sprintf("%s/profile", s.profileURL)
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, profileURL, nil)
-	if err != nil {
-		return domain.CommentResponse{}, fmt.Errorf("build profile request: %w", err)
-	}
-	httpReq.Header.Set("X-User-ID", userID)
-
-	resp, err := s.httpClient.Do(httpReq)
-	if err != nil {
-		return domain.CommentResponse{}, fmt.Errorf("call profile service: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return domain.CommentResponse{}, fmt.Errorf(
-			"profile service returned %d", resp.StatusCode,
-		)
-	}
-
-	var pr struct {
-		Name string `json:"name"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
-		return domain.CommentResponse{}, fmt.Errorf("decode profile response: %w", err)
+	if _, err := s.visiblePublication(ctx, req.PostID, userID); err != nil {
+		return domain.CommentResponse{}, err
 	}
 	comment := &domain.Comment{
 		CommentID: domain.NewUUID(),
 		PostID:    req.PostID,
 		UserID:    userID,
-		Name:      pr.Name,
 		Content:   req.Content,
 	}
+	if req.ParentCommentID != nil {
+		if err := s.attachToParent(comment, *req.ParentCommentID); err != nil {
+			return domain.CommentResponse{}, err
+		}
+	}
+
+	name, err := s.profileName(ctx, userID)
+	if err != nil {
+		return domain.CommentResponse{}, err
+	}
+	comment.Name = name
 	if err := s.repository.CreateComment(comment); err != nil {
 		return domain.CommentResponse{}, err
 	}
+	s.evaluateBadges(ctx, userID)
 	return comment.ToResponse(), nil
 }
 
-// GetComment returns a comment by ID
-func (s *feedService) GetComment(ctx context.Context, commentID string) (domain.CommentResponse, error) {
-	c, err := s.repository.GetComment(commentID)
+// GetComment returns a comment by ID; comments share the visibility of
+// their publication
+func (s *feedService) GetComment(ctx context.Context, commentID, viewerID string) (domain.CommentResponse, error) {
+	c, err := s.visibleComment(ctx, commentID, viewerID)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return domain.CommentResponse{}, usecases.ErrNotFound
-		}
 		return domain.CommentResponse{}, err
 	}
-	return c.ToResponse(), nil
+	out := []domain.CommentResponse{c.ToResponse()}
+	if err := s.withCommentReactions(ctx, viewerID, out); err != nil {
+		return domain.CommentResponse{}, err
+	}
+	return out[0], nil
 }
 
-// ListComments lists comments for a post
-func (s *feedService) ListComments(ctx context.Context, postID string) ([]domain.CommentResponse, error) {
-	comments, err := s.repository.ListComments(postID)
+// ListComments returns a page of comments for a post, newest first
+func (s *feedService) ListComments(ctx context.Context, postID, viewerID string, page domain.PageRequest) (domain.Page[domain.CommentResponse], error) {
+	if _, err := s.visiblePublication(ctx, postID, viewerID); err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	comments, err := s.repository.ListComments(ctx, postID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	out := domain.Page[domain.CommentResponse]{Items: make([]domain.CommentResponse, 0, min(len(comments), page.Limit))}
+	if len(comments) > page.Limit {
+		comments = comments[:page.Limit]
+		last := comments[page.Limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	ids := make([]string, 0, len(comments))
+	for _, c := range comments {
+		out.Items = append(out.Items, c.ToResponse())
+		ids = append(ids, c.CommentID)
+	}
+	if err := s.withCommentReactions(ctx, viewerID, out.Items); err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	replies, err := s.repository.CountReplies(ctx, ids)
 	if err != nil {
-		return nil, err
+		return domain.Page[domain.CommentResponse]{}, err
 	}
-	//most fresh comments
-	sort.Slice(comments, func(i, j int) bool {
-		return comments[i].CreatedAt.After(comments[j].CreatedAt)
-	})
-	out := make([]domain.CommentResponse, len(comments))
-	for i, c := range comments {
-		out[i] = c.ToResponse()
+	for i := range out.Items {
+		out.Items[i].ReplyCount = replies[out.Items[i].CommentID]
 	}
 	return out, nil
 }
@@ -202,6 +290,9 @@ func (s *feedService) UpdateComment(ctx context.Context, req domain.PutCommentRe
 	if err != nil {
 		return domain.CommentResponse{}, err
 	}
+	if c.Deleted {
+		return domain.CommentResponse{}, usecases.ErrNotFound
+	}
 	c.Content = req.Content
 	if err := s.repository.UpdateComment(c); err != nil {
 		return domain.CommentResponse{}, err
@@ -214,22 +305,67 @@ func (s *feedService) DeleteComment(ctx context.Context, commentID string) error
 	return s.repository.DeleteComment(commentID)
 }
 
-// ListPublicationsByUser returns all publications for a given user, newest first.
-func (s *feedService) ListPublicationsByUser(ctx context.Context, userID string) ([]domain.PublicationResponse, error) {
+// ListPublicationsByUser returns a page of a user's publications, newest first.
+func (s *feedService) ListPublicationsByUser(ctx context.Context, userID, viewerID string, filter domain.PublicationFilter, page domain.PageRequest) (domain.Page[domain.PublicationResponse], error) {
 	// Retrieve domain publications from the repository
-	pubs, err := s.repository.ListPublicationsByUser(ctx, userID)
+	filter.ViewerID = viewerID
+	pubs, err := s.repository.ListPublicationsByUser(ctx, userID, filter, page.After, page.Limit+1)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, usecases.ErrNotFound
+			return domain.Page[domain.PublicationResponse]{}, usecases.ErrNotFound
 		}
-		return nil, err
+		return domain.Page[domain.PublicationResponse]{}, err
+	}
+	out := publicationPage(pubs, page.Limit)
+	if err := s.decoratePublications(ctx, viewerID, out.Items); err != nil {
+		return domain.Page[domain.PublicationResponse]{}, err
 	}
+	return out, nil
+}
+
+// RenameAuthor updates the author name shown on existing posts and comments
+func (s *feedService) RenameAuthor(ctx context.Context, userID string, req domain.RenameAuthorRequest) error {
+	return s.repository.RenameAuthor(ctx, userID, req.Name)
+}
 
-	// Map domain.Publication to PublicationResponse
-	out := make([]domain.PublicationResponse, len(pubs))
-	for i, p := range pubs {
-		out[i] = p.ToResponse()
+// ImportWorkout posts an imported workout once per ExternalID. The author
+// name comes with the request, as profile_service is the caller. Badges
+// are left to EvaluateBadges at the end of the import: an export can hold
+// years of workouts.
+func (s *feedService) ImportWorkout(ctx context.Context, userID string, req domain.ImportedWorkoutRequest) (domain.PublicationResponse, bool, error) {
+	pub := req.ToPublication(userID)
+	created, err := s.repository.CreateImportedPublication(ctx, pub)
+	if err != nil {
+		return domain.PublicationResponse{}, false, err
+	}
+	if !created {
+		if pub, err = s.repository.GetPublicationByExternalID(ctx, userID, req.ExternalID); err != nil {
+			return domain.PublicationResponse{}, false, err
+		}
 	}
+	out := []domain.PublicationResponse{pub.ToResponse()}
+	if err := s.withAttachments(ctx, out); err != nil {
+		return domain.PublicationResponse{}, false, err
+	}
+	return out[0], created, nil
+}
 
+// Search runs a full-text query, fetching one extra hit to know whether
+// another page exists
+func (s *feedService) Search(ctx context.Context, q domain.SearchQuery) (domain.Page[domain.SearchHit], error) {
+	probe := q
+	probe.Limit = q.Limit + 1
+	hits, err := s.search.Search(ctx, probe)
+	if err != nil {
+		return domain.Page[domain.SearchHit]{}, err
+	}
+	out := domain.Page[domain.SearchHit]{Items: hits}
+	if len(hits) > q.Limit {
+		out.Items = hits[:q.Limit]
+		out.NextCursor = q.NextCursor()
+	}
+	if out.Items == nil {
+		out.Items = []domain.SearchHit{}
+	}
 	return out, nil
 }
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+	"feed_service/usecases"
+)
+
+// attachToParent places c under parentID, enforcing that the parent lives
+// on the same publication, is not a tombstone, and is not nested too deep
+func (s *feedService) attachToParent(c *domain.Comment, parentID string) error {
+	parent, err := s.repository.GetComment(parentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return usecases.ErrInvalidParent
+		}
+		return err
+	}
+	if parent.PostID != c.PostID || parent.Deleted {
+		return usecases.ErrInvalidParent
+	}
+	if parent.Depth+1 > domain.MaxCommentDepth {
+		return usecases.ErrThreadTooDeep
+	}
+
+	root := parent.CommentID
+	if parent.RootCommentID != nil {
+		root = *parent.RootCommentID
+	}
+	c.ParentCommentID = &parent.CommentID
+	c.RootCommentID = &root
+	c.Depth = parent.Depth + 1
+	return nil
+}
+
+// ListCommentTree returns a page of top-level comments, newest first, each
+// with its first domain.TreeReplies replies nested oldest first. A thread
+// with more replies carries a cursor for ListReplies.
+func (s *feedService) ListCommentTree(ctx context.Context, postID, viewerID string, page domain.PageRequest) (domain.Page[domain.CommentResponse], error) {
+	if _, err := s.visiblePublication(ctx, postID, viewerID); err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	roots, err := s.repository.ListRootComments(ctx, postID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	out := domain.Page[domain.CommentResponse]{Items: make([]domain.CommentResponse, 0, min(len(roots), page.Limit))}
+	if len(roots) > page.Limit {
+		roots = roots[:page.Limit]
+		last := roots[page.Limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	rootIDs := make([]string, len(roots))
+	for i, c := range roots {
+		rootIDs[i] = c.CommentID
+	}
+	// one reply past the limit tells whether a thread goes on
+	replies, err := s.repository.ListThreadReplies(ctx, rootIDs, domain.TreeReplies+1)
+	if err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	shown := make(map[string]int, len(roots))
+	last := make(map[string]domain.Cursor, len(roots))
+	more := make(map[string]string)
+	kept := replies[:0]
+	for _, c := range replies {
+		root := *c.RootCommentID
+		if shown[root] == domain.TreeReplies {
+			more[root] = last[root].Encode()
+			continue
+		}
+		shown[root]++
+		last[root] = domain.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+		kept = append(kept, c)
+	}
+	replies = kept
+
+	// reactions and reply counts for the whole page in one query each, then nest
+	flat := make([]domain.CommentResponse, 0, len(roots)+len(replies))
+	ids := make([]string, 0, cap(flat))
+	for _, c := range roots {
+		flat = append(flat, c.ToResponse())
+		ids = append(ids, c.CommentID)
+	}
+	for _, c := range replies {
+		flat = append(flat, c.ToResponse())
+		ids = append(ids, c.CommentID)
+	}
+	if err := s.withCommentReactions(ctx, viewerID, flat); err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	counts, err := s.repository.CountReplies(ctx, ids)
+	if err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+
+	children := make(map[string][]int)
+	for i := len(roots); i < len(flat); i++ {
+		parent := *flat[i].ParentCommentID
+		children[parent] = append(children[parent], i)
+	}
+	var build func(i int) domain.CommentResponse
+	build = func(i int) domain.CommentResponse {
+		node := flat[i]
+		kids := children[node.CommentID]
+		node.ReplyCount = counts[node.CommentID]
+		if len(kids) > 0 {
+			node.Replies = make([]domain.CommentResponse, 0, len(kids))
+			for _, j := range kids {
+				node.Replies = append(node.Replies, build(j))
+			}
+		}
+		return node
+	}
+	for i := range roots {
+		node := build(i)
+		node.RepliesCursor = more[node.CommentID]
+		out.Items = append(out.Items, node)
+	}
+	return out, nil
+}
+
+// ListReplies returns a page of the replies under a top-level comment,
+// oldest first and flat; parent_comment_id places each one in the thread
+func (s *feedService) ListReplies(ctx context.Context, commentID, viewerID string, page domain.PageRequest) (domain.Page[domain.CommentResponse], error) {
+	root, err := s.visibleComment(ctx, commentID, viewerID)
+	if err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	if root.ParentCommentID != nil {
+		return domain.Page[domain.CommentResponse]{}, usecases.ErrNotTopLevel
+	}
+	replies, err := s.repository.ListReplies(ctx, root.CommentID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	out := domain.Page[domain.CommentResponse]{Items: make([]domain.CommentResponse, 0, min(len(replies), page.Limit))}
+	if len(replies) > page.Limit {
+		replies = replies[:page.Limit]
+		last := replies[page.Limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	ids := make([]string, 0, len(replies))
+	for _, c := range replies {
+		out.Items = append(out.Items, c.ToResponse())
+		ids = append(ids, c.CommentID)
+	}
+	if err := s.withCommentReactions(ctx, viewerID, out.Items); err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	counts, err := s.repository.CountReplies(ctx, ids)
+	if err != nil {
+		return domain.Page[domain.CommentResponse]{}, err
+	}
+	for i := range out.Items {
+		out.Items[i].ReplyCount = counts[out.Items[i].CommentID]
+	}
+	return out, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"feed_service/domain"
+	"feed_service/usecases"
+)
+
+// fakeThreadRepo answers the thread queries from the comments of
+// fakeFeedRepo, ordered and capped like the database
+type fakeThreadRepo struct {
+	*fakeFeedRepo
+}
+
+func (r fakeThreadRepo) sorted(keep func(c *domain.Comment) bool) []domain.Comment {
+	var out []domain.Comment
+	for _, c := range r.comments {
+		if keep(c) {
+			out = append(out, *c)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Comment) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return int(a.ID) - int(b.ID)
+	})
+	return out
+}
+
+func (r fakeThreadRepo) ListRootComments(ctx context.Context, postID string, after *domain.Cursor, limit int) ([]domain.Comment, error) {
+	roots := r.sorted(func(c *domain.Comment) bool { return c.PostID == postID && c.ParentCommentID == nil })
+	slices.Reverse(roots)
+	return roots[:min(limit, len(roots))], nil
+}
+
+func (r fakeThreadRepo) ListThreadReplies(ctx context.Context, rootIDs []string, perThread int) ([]domain.Comment, error) {
+	var out []domain.Comment
+	seen := map[string]int{}
+	for _, c := range r.sorted(func(c *domain.Comment) bool {
+		return c.RootCommentID != nil && slices.Contains(rootIDs, *c.RootCommentID)
+	}) {
+		if seen[*c.RootCommentID] < perThread {
+			seen[*c.RootCommentID]++
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r fakeThreadRepo) ListReplies(ctx context.Context, rootID string, after *domain.Cursor, limit int) ([]domain.Comment, error) {
+	replies := r.sorted(func(c *domain.Comment) bool {
+		return c.RootCommentID != nil && *c.RootCommentID == rootID &&
+			(after == nil || c.CreatedAt.After(after.CreatedAt) || c.CreatedAt.Equal(after.CreatedAt) && c.ID > after.ID)
+	})
+	return replies[:min(limit, len(replies))], nil
+}
+
+func (r fakeThreadRepo) CountReplies(ctx context.Context, commentIDs []string) (map[string]int, error) {
+	out := map[string]int{}
+	for _, c := range r.comments {
+		if c.ParentCommentID != nil && slices.Contains(commentIDs, *c.ParentCommentID) {
+			out[*c.ParentCommentID]++
+		}
+	}
+	return out, nil
+}
+
+// newThreadService sets up a public post with two threads: "long" holds
+// more replies than a tree shows, the first of them answered once;
+// "short" holds two
+func newThreadService() *feedService {
+	repo := &fakeFeedRepo{
+		pubs:     map[string]*domain.Publication{"p": {PostID: "p", UserID: author, Visibility: domain.VisibilityPublic}},
+		comments: map[string]*domain.Comment{},
+	}
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var id uint
+	add := func(commentID string, parent *domain.Comment) *domain.Comment {
+		id++
+		c := &domain.Comment{CommentID: commentID, PostID: "p", UserID: stranger}
+		c.ID, c.CreatedAt = id, base.Add(time.Duration(id)*time.Minute)
+		if parent != nil {
+			root := parent.CommentID
+			if parent.RootCommentID != nil {
+				root = *parent.RootCommentID
+			}
+			c.ParentCommentID, c.RootCommentID, c.Depth = &parent.CommentID, &root, parent.Depth+1
+		}
+		repo.comments[commentID] = c
+		return c
+	}
+	long := add("long", nil)
+	first := add("long-0", long)
+	add("long-0-0", first)
+	for i := 1; i < domain.TreeReplies+3; i++ {
+		add(fmt.Sprintf("long-%d", i), long)
+	}
+	short := add("short", nil)
+	add("short-0", short)
+	add("short-1", short)
+
+	s := newVisibilityService()
+	s.repository = fakeThreadRepo{repo}
+	return s
+}
+
+func TestCommentTreeCapsThreads(t *testing.T) {
+	s := newThreadService()
+	page, err := s.ListCommentTree(context.Background(), "p", stranger, domain.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].CommentID != "short" || page.Items[1].CommentID != "long" {
+		t.Fatalf("roots = %+v", page.Items)
+	}
+	short, long := page.Items[0], page.Items[1]
+	if short.ReplyCount != 2 || len(short.Replies) != 2 || short.RepliesCursor != "" {
+		t.Errorf("short thread: %d replies, %d shown, cursor %q", short.ReplyCount, len(short.Replies), short.RepliesCursor)
+	}
+
+	// the nested reply counts towards the cap; the counts are not capped
+	if long.ReplyCount != domain.TreeReplies+3 || len(long.Replies) != domain.TreeReplies-1 || long.RepliesCursor == "" {
+		t.Fatalf("long thread: %d replies, %d shown, cursor %q", long.ReplyCount, len(long.Replies), long.RepliesCursor)
+	}
+	if r := long.Replies[0]; r.CommentID != "long-0" || r.ReplyCount != 1 || len(r.Replies) != 1 || r.Replies[0].CommentID != "long-0-0" {
+		t.Errorf("first reply = %+v", r)
+	}
+	if last := long.Replies[len(long.Replies)-1]; last.CommentID != fmt.Sprintf("long-%d", domain.TreeReplies-2) {
+		t.Errorf("last reply shown is %s", last.CommentID)
+	}
+
+	// the cursor picks the thread up where the tree stopped
+	var rest []string
+	req, err := domain.NewPageRequest("2", long.RepliesCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		replies, err := s.ListReplies(context.Background(), "long", stranger, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range replies.Items {
+			rest = append(rest, r.CommentID)
+		}
+		if replies.NextCursor == "" || len(rest) > domain.TreeReplies {
+			break
+		}
+		if req, err = domain.NewPageRequest("2", replies.NextCursor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var want []string
+	for i := domain.TreeReplies - 1; i < domain.TreeReplies+3; i++ {
+		want = append(want, fmt.Sprintf("long-%d", i))
+	}
+	if !slices.Equal(rest, want) {
+		t.Errorf("rest of the thread = %q, want %q", rest, want)
+	}
+}
+
+func TestListReplies(t *testing.T) {
+	s := newThreadService()
+	ctx := context.Background()
+	page, err := s.ListReplies(ctx, "short", "", domain.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].CommentID != "short-0" || page.Items[1].CommentID != "short-1" || page.NextCursor != "" {
+		t.Errorf("replies = %+v", page)
+	}
+	if _, err := s.ListReplies(ctx, "long-0", "", domain.PageRequest{Limit: 10}); err != usecases.ErrNotTopLevel {
+		t.Errorf("replies of a reply: got %v, want ErrNotTopLevel", err)
+	}
+	if _, err := s.ListReplies(ctx, "missing", "", domain.PageRequest{Limit: 10}); err != usecases.ErrNotFound {
+		t.Errorf("missing comment: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+	"feed_service/usecases"
+	"feed_service/usecases/tracks"
+)
+
+// ImportTrack parses an uploaded recording, stores its summary and
+// simplified path as a draft track and prefills a workout publication
+func (s *feedService) ImportTrack(ctx context.Context, userID, fileName string, file io.Reader) (domain.TrackImport, error) {
+	format, err := tracks.FormatFromName(fileName)
+	if err != nil {
+		return domain.TrackImport{}, fmt.Errorf("%w: %v", usecases.ErrUnreadableTrack, err)
+	}
+	parsed, err := tracks.Parse(format, file)
+	if err != nil {
+		return domain.TrackImport{}, fmt.Errorf("%w: %v", usecases.ErrUnreadableTrack, err)
+	}
+	sum := tracks.Analyze(parsed)
+	if sum.Duration <= 0 {
+		return domain.TrackImport{}, fmt.Errorf("%w: track has no timestamps", usecases.ErrUnreadableTrack)
+	}
+
+	t := &domain.Track{
+		TrackID:         domain.NewUUID(),
+		UserID:          userID,
+		Format:          format,
+		Name:            truncate(parsed.Name, 100),
+		Activity:        parsed.Activity,
+		StartedAt:       sum.StartedAt,
+		DistanceMeters:  sum.Distance,
+		DurationSeconds: int(sum.Duration.Seconds()),
+		ElevationGain:   sum.ElevationGain,
+		Pace:            sum.Pace,
+		Polyline:        tracks.Polyline(parsed),
+		Splits:          make(domain.Splits, 0, len(sum.Splits)),
+	}
+	if t.Activity == "" {
+		t.Activity = domain.ActivityOther
+	}
+	if hr := sum.HeartRate; hr != nil {
+		t.HeartRateAvg, t.HeartRateMax, t.HeartRateMin = &hr.Avg, &hr.Max, &hr.Min
+	}
+	for _, sp := range sum.Splits {
+		t.Splits = append(t.Splits, domain.TrackSplit{
+			DistanceMeters:  sp.Distance,
+			DurationSeconds: int(sp.Duration.Seconds()),
+			Pace:            sp.Pace,
+			ElevationGain:   sp.ElevationGain,
+		})
+	}
+	if err := s.tracks.CreateTrack(ctx, t); err != nil {
+		return domain.TrackImport{}, err
+	}
+	return domain.TrackImport{Track: t.ToResponse(), Draft: t.Draft()}, nil
+}
+
+// truncate cuts s to at most n runes
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// getTrack maps a missing track to usecases.ErrNotFound
+func (s *feedService) getTrack(ctx context.Context, trackID string) (*domain.Track, error) {
+	t, err := s.tracks.GetTrack(ctx, trackID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrNotFound
+	}
+	return t, err
+}
+
+// GetTrack returns a track with the publication it is attached to, if any
+func (s *feedService) GetTrack(ctx context.Context, trackID string) (domain.TrackResponse, error) {
+	t, err := s.getTrack(ctx, trackID)
+	if err != nil {
+		return domain.TrackResponse{}, err
+	}
+	out := []domain.TrackResponse{t.ToResponse()}
+	if err := s.withTrackPosts(ctx, out); err != nil {
+		return domain.TrackResponse{}, err
+	}
+	return out[0], nil
+}
+
+// ListTracks returns a page of a user's tracks, newest first
+func (s *feedService) ListTracks(ctx context.Context, userID string, page domain.PageRequest) (domain.Page[domain.TrackResponse], error) {
+	list, err := s.tracks.ListTracks(ctx, userID, page.After, page.Limit+1)
+	if err != nil {
+		return domain.Page[domain.TrackResponse]{}, err
+	}
+	out := domain.Page[domain.TrackResponse]{Items: make([]domain.TrackResponse, 0, min(len(list), page.Limit))}
+	if len(list) > page.Limit {
+		list = list[:page.Limit]
+		last := list[page.Limit-1]
+		out.NextCursor = domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	for _, t := range list {
+		out.Items = append(out.Items, t.ToResponse())
+	}
+	if err := s.withTrackPosts(ctx, out.Items); err != nil {
+		return domain.Page[domain.TrackResponse]{}, err
+	}
+	return out, nil
+}
+
+// DeleteTrack removes a track; its publication stays without the map
+func (s *feedService) DeleteTrack(ctx context.Context, trackID string) error {
+	return s.tracks.DeleteTrack(ctx, trackID)
+}
+
+// withTrackPosts fills in the publication each track is attached to
+func (s *feedService) withTrackPosts(ctx context.Context, items []domain.TrackResponse) error {
+	ids := make([]string, 0, len(items))
+	for _, t := range items {
+		ids = append(ids, t.TrackID)
+	}
+	posts, err := s.tracks.TrackPosts(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if postID, ok := posts[items[i].TrackID]; ok {
+			items[i].PostID = &postID
+		}
+	}
+	return nil
+}
+
+// resolveTrack checks that the track named in req belongs to the author
+// and is not attached to another publication, and fills in the workout
+// details from the track when the request leaves them out
+func (s *feedService) resolveTrack(ctx context.Context, authorID, postID string, req *domain.PublicationRequest) (*string, error) {
+	if req.TrackID == nil {
+		return nil, nil
+	}
+	t, err := s.tracks.GetTrack(ctx, *req.TrackID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrBadTrack
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.UserID != authorID {
+		return nil, usecases.ErrBadTrack
+	}
+	posts, err := s.tracks.TrackPosts(ctx, []string{t.TrackID})
+	if err != nil {
+		return nil, err
+	}
+	if other, ok := posts[t.TrackID]; ok && other != postID {
+		return nil, usecases.ErrBadTrack
+	}
+	if req.Workout == nil {
+		req.Workout = t.Workout()
+	}
+	return &t.TrackID, nil
+}
+
+// withTracks embeds attached tracks, one query per page
+func (s *feedService) withTracks(ctx context.Context, items []domain.PublicationResponse) error {
+	var ids []string
+	for _, p := range items {
+		if p.TrackID != nil {
+			ids = append(ids, *p.TrackID)
+		}
+	}
+	found, err := s.tracks.GetTracks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if items[i].TrackID == nil {
+			continue
+		}
+		if t, ok := found[*items[i].TrackID]; ok {
+			resp := t.ToResponse()
+			resp.PostID = &items[i].PostID
+			items[i].Track = &resp
+		}
+	}
+	return nil
+}
+
+// withAttachments embeds the goal, check-in, track and media of each publication
+func (s *feedService) withAttachments(ctx context.Context, items []domain.PublicationResponse) error {
+	if err := s.withGoals(ctx, items); err != nil {
+		return err
+	}
+	if err := s.withTracks(ctx, items); err != nil {
+		return err
+	}
+	return s.withMedia(ctx, items)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"feed_service/domain"
+	repository "feed_service/repository"
+	"feed_service/usecases"
+)
+
+// canView tells whether viewerID may see the publication. List queries
+// apply the same rules in SQL (see repository/db.visibleTo).
+func (s *feedService) canView(ctx context.Context, p *domain.Publication, viewerID string) (bool, error) {
+	if viewerID != "" && p.UserID == viewerID {
+		return true, nil
+	}
+	switch p.Visibility {
+	case "", domain.VisibilityPublic:
+		return true, nil
+	case domain.VisibilityFollowers:
+		if viewerID == "" {
+			return false, nil
+		}
+		return s.follows.IsFollowing(ctx, viewerID, p.UserID)
+	case domain.VisibilityCircle:
+		if viewerID == "" || p.CircleID == nil {
+			return false, nil
+		}
+		return s.circles.IsMember(ctx, *p.CircleID, viewerID)
+	}
+	return false, nil
+}
+
+// visiblePublication loads a publication the viewer may see; hidden ones
+// are reported as missing so their existence does not leak
+func (s *feedService) visiblePublication(ctx context.Context, postID, viewerID string) (*domain.Publication, error) {
+	pub, err := s.repository.GetPublication(postID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.canView(ctx, pub, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, usecases.ErrNotFound
+	}
+	return pub, nil
+}
+
+// visibleComment loads a comment whose publication the viewer may see
+func (s *feedService) visibleComment(ctx context.Context, commentID, viewerID string) (*domain.Comment, error) {
+	c, err := s.repository.GetComment(commentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, usecases.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.visiblePublication(ctx, c.PostID, viewerID); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetPublicationForModeration returns a publication whatever its visibility,
+// with its attachments but without the viewer's reactions
+func (s *feedService) GetPublicationForModeration(ctx context.Context, postID string) (domain.PublicationResponse, error) {
+	pub, err := s.repository.GetPublication(postID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return domain.PublicationResponse{}, usecases.ErrNotFound
+	}
+	if err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	out := []domain.PublicationResponse{pub.ToResponse()}
+	if err := s.withAttachments(ctx, out); err != nil {
+		return domain.PublicationResponse{}, err
+	}
+	return out[0], nil
+}
+
+// GetCommentForModeration returns a comment whatever the visibility of
+// its publication
+func (s *feedService) GetCommentForModeration(ctx context.Context, commentID string) (domain.CommentResponse, error) {
+	c, err := s.repository.GetComment(commentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return domain.CommentResponse{}, usecases.ErrNotFound
+	}
+	if err != nil {
+		return domain.CommentResponse{}, err
+	}
+	return c.ToResponse(), nil
+}
+
+// resolveCircle checks that a circle-visible publication names one of
+// the author's circles
+func (s *feedService) resolveCircle(ctx context.Context, authorID string, req domain.PublicationRequest) error {
+	if req.Visibility != domain.VisibilityCircle {
+		return nil
+	}
+	c, err := s.circles.GetCircle(ctx, *req.CircleID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return usecases.ErrBadCircle
+	}
+	if err != nil {
+		return err
+	}
+	if c.UserID != authorID {
+		return usecases.ErrBadCircle
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"feed_service/domain"
+	"feed_service/repository"
+	"feed_service/usecases"
+	"feed_service/usecases/media"
+)
+
+// The fakes embed the repository interfaces, so calling a method a test
+// does not set up panics instead of passing silently.
+
+type fakeFeedRepo struct {
+	repository.FeedRepository
+	pubs     map[string]*domain.Publication
+	comments map[string]*domain.Comment
+}
+
+func (r *fakeFeedRepo) GetPublication(postID string) (*domain.Publication, error) {
+	if p, ok := r.pubs[postID]; ok {
+		return p, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeFeedRepo) GetComment(commentID string) (*domain.Comment, error) {
+	if c, ok := r.comments[commentID]; ok {
+		return c, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeFeedRepo) ListComments(ctx context.Context, postID string, after *domain.Cursor, limit int) ([]domain.Comment, error) {
+	var out []domain.Comment
+	for _, c := range r.comments {
+		if c.PostID == postID {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeFeedRepo) CountReplies(ctx context.Context, commentIDs []string) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func (r *fakeFeedRepo) CountReactions(ctx context.Context, targetType string, targetIDs []string, viewerID string) (map[string][]domain.ReactionCount, error) {
+	return map[string][]domain.ReactionCount{}, nil
+}
+
+type fakeFollows struct {
+	repository.FollowRepository
+	edges map[[2]string]bool // follower, followee
+}
+
+func (f *fakeFollows) IsFollowing(ctx context.Context, followerID, followeeID string) (bool, error) {
+	return f.edges[[2]string{followerID, followeeID}], nil
+}
+
+type fakeCircles struct {
+	repository.CircleRepository
+	members map[[2]string]bool // circle, member
+}
+
+func (f *fakeCircles) IsMember(ctx context.Context, circleID, userID string) (bool, error) {
+	return f.members[[2]string{circleID, userID}], nil
+}
+
+// attachment repos that hold nothing
+type noGoals struct{ repository.GoalRepository }
+
+func (noGoals) GetGoals(ctx context.Context, goalIDs []string) (map[string]domain.Goal, error) {
+	return map[string]domain.Goal{}, nil
+}
+
+func (noGoals) GetCheckIns(ctx context.Context, checkInIDs []string) (map[string]domain.CheckIn, error) {
+	return map[string]domain.CheckIn{}, nil
+}
+
+type noTracks struct{ repository.TrackRepository }
+
+func (noTracks) GetTracks(ctx context.Context, trackIDs []string) (map[string]domain.Track, error) {
+	return map[string]domain.Track{}, nil
+}
+
+type noMedia struct{ repository.MediaRepository }
+
+func (noMedia) ListPostMedia(ctx context.Context, postIDs []string) (map[string][]domain.Media, error) {
+	return map[string][]domain.Media{}, nil
+}
+
+const (
+	author    = "author"
+	follower  = "follower"
+	member    = "member"
+	stranger  = "stranger"
+	moderator = "moderator"
+	circleID  = "circle"
+)
+
+// newVisibilityService sets up one post and one comment per visibility,
+// written by author. follower follows author, member is in author's
+// circle; stranger and moderator have no tie to author.
+func newVisibilityService() *feedService {
+	repo := &fakeFeedRepo{pubs: map[string]*domain.Publication{}, comments: map[string]*domain.Comment{}}
+	circle := circleID
+	for _, v := range []string{domain.VisibilityPublic, domain.VisibilityFollowers, domain.VisibilityCircle, domain.VisibilityPrivate} {
+		p := &domain.Publication{PostID: v, UserID: author, Visibility: v}
+		if v == domain.VisibilityCircle {
+			p.CircleID = &circle
+		}
+		repo.pubs[v] = p
+		repo.comments[v] = &domain.Comment{CommentID: v, PostID: v, UserID: stranger, Content: "hi"}
+	}
+	follows := &fakeFollows{edges: map[[2]string]bool{{follower, author}: true}}
+	circles := &fakeCircles{members: map[[2]string]bool{{circleID, member}: true}}
+	return NewFeedService(repo, follows, circles, noGoals{}, nil, noTracks{}, noMedia{}, nil, media.Config{}, nil, nil, "", nil).(*feedService)
+}
+
+// TestVisibilityMatrix checks who sees a post, and the comments under it,
+// for each visibility. Moderators read like anyone else; they only pass
+// the rules when they act on a post through the moderation lookups.
+func TestVisibilityMatrix(t *testing.T) {
+	s := newVisibilityService()
+	ctx := context.Background()
+
+	visible := map[string]map[string]bool{
+		author:    {domain.VisibilityPublic: true, domain.VisibilityFollowers: true, domain.VisibilityCircle: true, domain.VisibilityPrivate: true},
+		follower:  {domain.VisibilityPublic: true, domain.VisibilityFollowers: true},
+		member:    {domain.VisibilityPublic: true, domain.VisibilityCircle: true},
+		stranger:  {domain.VisibilityPublic: true},
+		moderator: {domain.VisibilityPublic: true},
+		"":        {domain.VisibilityPublic: true},
+	}
+	for viewer, want := range visible {
+		for _, v := range []string{domain.VisibilityPublic, domain.VisibilityFollowers, domain.VisibilityCircle, domain.VisibilityPrivate} {
+			_, pubErr := s.GetPublication(ctx, v, viewer)
+			_, commentErr := s.GetComment(ctx, v, viewer)
+			_, listErr := s.ListComments(ctx, v, viewer, domain.PageRequest{Limit: 10})
+			for what, err := range map[string]error{"publication": pubErr, "comment": commentErr, "comments": listErr} {
+				switch {
+				case want[v] && err != nil:
+					t.Errorf("%q cannot see the %s of a %s post: %v", viewer, what, v, err)
+				case !want[v] && err != usecases.ErrNotFound:
+					t.Errorf("%q reading the %s of a %s post got %v, want ErrNotFound", viewer, what, v, err)
+				}
+			}
+		}
+	}
+}
+
+func TestModerationLookupSkipsVisibility(t *testing.T) {
+	s := newVisibilityService()
+	ctx := context.Background()
+	for _, v := range []string{domain.VisibilityPublic, domain.VisibilityFollowers, domain.VisibilityCircle, domain.VisibilityPrivate} {
+		if p, err := s.GetPublicationForModeration(ctx, v); err != nil || p.UserID != author {
+			t.Errorf("moderation lookup of a %s post = %+v, %v", v, p, err)
+		}
+		if c, err := s.GetCommentForModeration(ctx, v); err != nil || c.UserID != stranger {
+			t.Errorf("moderation lookup of a comment on a %s post = %+v, %v", v, c, err)
+		}
+	}
+	if _, err := s.GetPublicationForModeration(ctx, "missing"); err != usecases.ErrNotFound {
+		t.Errorf("missing post: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestCircleWithoutIDIsHidden(t *testing.T) {
+	s := newVisibilityService()
+	p := &domain.Publication{PostID: "broken", UserID: author, Visibility: domain.VisibilityCircle}
+	if ok, err := s.canView(context.Background(), p, member); ok || err != nil {
+		t.Errorf("circle post without a circle is visible: %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,264 @@
+package tracks
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	earthRadius = 6371008.8 // metres
+	// elevationThreshold filters GPS altitude noise: a climb only counts
+	// once it rises this many metres above the last low point
+	elevationThreshold = 3.0
+	// simplifyTolerance is how far, in metres, the stored polyline may
+	// stray from the recorded path
+	simplifyTolerance = 5.0
+)
+
+// Summary is what a recording adds up to
+type Summary struct {
+	StartedAt     *time.Time
+	Distance      float64 // metres
+	Duration      time.Duration
+	ElevationGain float64 // metres
+	Pace          float64 // seconds per kilometre, 0 without distance or time
+	HeartRate     *HeartRate
+	Splits        []Split
+}
+
+// HeartRate sums up the heart rate samples in beats per minute
+type HeartRate struct {
+	Avg, Max, Min int
+}
+
+// Split covers one kilometre; the last split may be shorter
+type Split struct {
+	Distance      float64 // metres
+	Duration      time.Duration
+	Pace          float64 // seconds per kilometre
+	ElevationGain float64
+}
+
+// Analyze computes the summary of a track. Distances come from the
+// device's odometer when every sample has one, from the GPS positions
+// otherwise.
+func Analyze(t *Track) Summary {
+	var s Summary
+	pts := t.Points
+	if len(pts) == 0 {
+		return s
+	}
+	if !pts[0].Time.IsZero() {
+		start := pts[0].Time
+		s.StartedAt = &start
+	}
+	last := pts[len(pts)-1]
+	if s.StartedAt != nil && !last.Time.IsZero() && last.Time.After(*s.StartedAt) {
+		s.Duration = last.Time.Sub(*s.StartedAt)
+	}
+
+	dist := cumulativeDistance(pts)
+	s.Distance = round1(dist[len(dist)-1])
+	if s.Distance > 0 && s.Duration > 0 {
+		s.Pace = round1(s.Duration.Seconds() / (s.Distance / 1000))
+	}
+
+	total := &climb{}
+	var (
+		hrSum, hrN int
+		hr         = HeartRate{Min: math.MaxInt}
+	)
+	split := Split{}
+	splitStart := 0
+	splitClimb := &climb{}
+	for i, p := range pts {
+		if p.Elevation != nil {
+			total.add(*p.Elevation)
+			splitClimb.add(*p.Elevation)
+		}
+		if p.HeartRate != nil && *p.HeartRate > 0 {
+			hrSum += *p.HeartRate
+			hrN++
+			hr.Max = max(hr.Max, *p.HeartRate)
+			hr.Min = min(hr.Min, *p.HeartRate)
+		}
+		// close a split every full kilometre
+		if s.Duration > 0 && (dist[i]-dist[splitStart] >= 1000 || i == len(pts)-1) {
+			split.Distance = round1(dist[i] - dist[splitStart])
+			split.Duration = p.Time.Sub(pts[splitStart].Time)
+			split.ElevationGain = round1(splitClimb.gain)
+			if split.Distance > 0 {
+				split.Pace = round1(split.Duration.Seconds() / (split.Distance / 1000))
+			}
+			if split.Distance > 0 || len(s.Splits) == 0 {
+				s.Splits = append(s.Splits, split)
+			}
+			split, splitStart = Split{}, i
+			splitClimb = &climb{}
+			if p.Elevation != nil {
+				splitClimb.add(*p.Elevation)
+			}
+		}
+	}
+	s.ElevationGain = round1(total.gain)
+	if hrN > 0 {
+		hr.Avg = hrSum / hrN
+		s.HeartRate = &hr
+	}
+	return s
+}
+
+// cumulativeDistance returns the distance covered at each point
+func cumulativeDistance(pts []Point) []float64 {
+	out := make([]float64, len(pts))
+	odometer := true
+	for _, p := range pts {
+		if p.Distance == nil {
+			odometer = false
+			break
+		}
+	}
+	if odometer {
+		for i, p := range pts {
+			out[i] = *p.Distance
+			if i > 0 && out[i] < out[i-1] {
+				out[i] = out[i-1]
+			}
+		}
+		return out
+	}
+	var prev *Point
+	for i := range pts {
+		if i > 0 {
+			out[i] = out[i-1]
+		}
+		p := &pts[i]
+		if p.Lat == nil || p.Lon == nil {
+			continue
+		}
+		if prev != nil {
+			out[i] += haversine(*prev.Lat, *prev.Lon, *p.Lat, *p.Lon)
+		}
+		prev = p
+	}
+	return out
+}
+
+// climb adds up elevation gain with a noise threshold
+type climb struct {
+	low  float64
+	seen bool
+	gain float64
+}
+
+func (c *climb) add(ele float64) {
+	switch {
+	case !c.seen:
+		c.low, c.seen = ele, true
+	case ele < c.low:
+		c.low = ele
+	case ele-c.low >= elevationThreshold:
+		c.gain += ele - c.low
+		c.low = ele
+	}
+}
+
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dφ := φ2 - φ1
+	dλ := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dφ/2)*math.Sin(dφ/2) + math.Cos(φ1)*math.Cos(φ2)*math.Sin(dλ/2)*math.Sin(dλ/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(1, a)))
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// Polyline simplifies the positioned points of t with Douglas-Peucker and
+// encodes them in Google's encoded polyline format (precision 5)
+func Polyline(t *Track) string {
+	var coords [][2]float64
+	for _, p := range t.Points {
+		if p.Lat != nil && p.Lon != nil {
+			coords = append(coords, [2]float64{*p.Lat, *p.Lon})
+		}
+	}
+	return encodePolyline(simplify(coords, simplifyTolerance))
+}
+
+// simplify keeps the points that stray more than tolerance metres from the
+// line between their kept neighbours. It uses an explicit stack so long
+// recordings cannot overflow the call stack.
+func simplify(coords [][2]float64, tolerance float64) [][2]float64 {
+	if len(coords) < 3 {
+		return coords
+	}
+	keep := make([]bool, len(coords))
+	keep[0], keep[len(coords)-1] = true, true
+	stack := [][2]int{{0, len(coords) - 1}}
+	for len(stack) > 0 {
+		seg := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		first, last := seg[0], seg[1]
+		worst, index := 0.0, -1
+		for i := first + 1; i < last; i++ {
+			if d := offset(coords[i], coords[first], coords[last]); d > worst {
+				worst, index = d, i
+			}
+		}
+		if index >= 0 && worst > tolerance {
+			keep[index] = true
+			stack = append(stack, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+	out := make([][2]float64, 0, len(coords)/4)
+	for i, c := range coords {
+		if keep[i] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// offset is the distance in metres from p to the segment a-b, on a local
+// equirectangular projection
+func offset(p, a, b [2]float64) float64 {
+	cos := math.Cos(a[0] * math.Pi / 180)
+	project := func(c [2]float64) (x, y float64) {
+		return (c[1] - a[1]) * math.Pi / 180 * earthRadius * cos, (c[0] - a[0]) * math.Pi / 180 * earthRadius
+	}
+	px, py := project(p)
+	bx, by := project(b)
+	l2 := bx*bx + by*by
+	if l2 == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/l2))
+	return math.Hypot(px-t*bx, py-t*by)
+}
+
+func encodePolyline(coords [][2]float64) string {
+	var b strings.Builder
+	var prevLat, prevLon int64
+	for _, c := range coords {
+		lat, lon := int64(math.Round(c[0]*1e5)), int64(math.Round(c[1]*1e5))
+		encodeValue(&b, lat-prevLat)
+		encodeValue(&b, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return b.String()
+}
+
+func encodeValue(b *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		b.WriteByte(byte(0x20|(u&0x1f)) + 63)
+		u >>= 5
+	}
+	b.WriteByte(byte(u) + 63)
+}
@@ -0,0 +1,281 @@
+package tracks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// FIT global message numbers and field numbers used here
+const (
+	fitMsgSession = 18
+	fitMsgRecord  = 20
+
+	fitFieldTimestamp   = 253
+	fitFieldLat         = 0
+	fitFieldLon         = 1
+	fitFieldAltitude    = 2
+	fitFieldHeartRate   = 3
+	fitFieldDistance    = 5
+	fitFieldEnhancedAlt = 78
+	fitFieldSport       = 5 // in session messages
+)
+
+// fitEpoch is the FIT time origin, 1989-12-31T00:00:00Z
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// fitSports maps FIT sport codes to domain workout activities
+var fitSports = map[uint64]string{
+	1:  "run",
+	2:  "ride",
+	4:  "strength", // fitness equipment
+	5:  "swim",
+	10: "strength", // training
+	11: "walk",
+	17: "hike",
+}
+
+type fitField struct {
+	num  byte
+	size byte
+}
+
+type fitDefinition struct {
+	global   uint16
+	order    binary.ByteOrder
+	fields   []fitField
+	devBytes int
+}
+
+// fitReader reads the data records of a FIT file and keeps the CRC of
+// everything read so far
+type fitReader struct {
+	r    *bufio.Reader
+	left int // data bytes not yet read
+	crc  uint16
+}
+
+func (f *fitReader) read(n int) ([]byte, error) {
+	if n > f.left {
+		return nil, invalid("fit: record runs past the data section")
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(f.r, b); err != nil {
+		return nil, invalid("fit: %v", err)
+	}
+	f.left -= n
+	f.crc = fitCRC(f.crc, b)
+	return b, nil
+}
+
+// parseFIT walks the records of a FIT activity file, keeping record
+// messages (samples) and the sport of the session message. Other
+// messages and developer fields are skipped by size.
+func parseFIT(r io.Reader) (*Track, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(12)
+	if err != nil || !bytes.Equal(head[8:12], []byte(".FIT")) {
+		return nil, invalid("fit: missing .FIT header")
+	}
+	size := int(head[0])
+	if size != 12 && size != 14 {
+		return nil, invalid("fit: unexpected header size %d", size)
+	}
+	header := make([]byte, size)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, invalid("fit: %v", err)
+	}
+	f := &fitReader{r: br, left: int(binary.LittleEndian.Uint32(header[4:8])), crc: fitCRC(0, header)}
+
+	t := &Track{}
+	var (
+		defs          [16]*fitDefinition
+		lastTimestamp uint32
+	)
+	for f.left > 0 {
+		hdr, err := f.read(1)
+		if err != nil {
+			return nil, err
+		}
+		h := hdr[0]
+		var (
+			local      byte
+			compressed bool
+			offset     uint32
+		)
+		switch {
+		case h&0x80 != 0: // compressed timestamp header
+			local, compressed, offset = (h>>5)&0x03, true, uint32(h&0x1f)
+		case h&0x40 != 0: // definition message
+			def, err := f.readDefinition(h&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			defs[h&0x0f] = def
+			continue
+		default:
+			local = h & 0x0f
+		}
+
+		def := defs[local]
+		if def == nil {
+			return nil, invalid("fit: data message without a definition")
+		}
+		values := make(map[byte]uint64, len(def.fields))
+		raw := make(map[byte][]byte, len(def.fields))
+		for _, fd := range def.fields {
+			b, err := f.read(int(fd.size))
+			if err != nil {
+				return nil, err
+			}
+			raw[fd.num] = b
+			if v, ok := fitUint(b, def.order); ok {
+				values[fd.num] = v
+			}
+		}
+		if _, err := f.read(def.devBytes); err != nil {
+			return nil, err
+		}
+
+		if ts, ok := values[fitFieldTimestamp]; ok {
+			lastTimestamp = uint32(ts)
+		} else if compressed {
+			// the header carries the low five bits of the timestamp
+			ts := lastTimestamp&^0x1f + offset
+			if offset < lastTimestamp&0x1f {
+				ts += 0x20
+			}
+			lastTimestamp = ts
+			values[fitFieldTimestamp] = uint64(ts)
+		}
+
+		switch def.global {
+		case fitMsgSession:
+			if sport, ok := values[fitFieldSport]; ok && t.Activity == "" {
+				t.Activity = fitSports[sport]
+			}
+		case fitMsgRecord:
+			ts, ok := values[fitFieldTimestamp]
+			if !ok {
+				continue
+			}
+			p := Point{Time: fitEpoch.Add(time.Duration(ts) * time.Second)}
+			lat, latOK := fitInt32(raw[fitFieldLat], def.order)
+			lon, lonOK := fitInt32(raw[fitFieldLon], def.order)
+			if latOK && lonOK {
+				p.Lat, p.Lon = ptr(semicircles(lat)), ptr(semicircles(lon))
+			}
+			if v, ok := values[fitFieldEnhancedAlt]; ok {
+				p.Elevation = ptr(float64(v)/5 - 500)
+			} else if v, ok := values[fitFieldAltitude]; ok {
+				p.Elevation = ptr(float64(v)/5 - 500)
+			}
+			if v, ok := values[fitFieldDistance]; ok {
+				p.Distance = ptr(float64(v) / 100)
+			}
+			if v, ok := values[fitFieldHeartRate]; ok {
+				p.HeartRate = ptr(int(v))
+			}
+			if err := t.add(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var trailer [2]byte
+	if _, err := io.ReadFull(br, trailer[:]); err != nil {
+		return nil, invalid("fit: missing CRC")
+	}
+	if binary.LittleEndian.Uint16(trailer[:]) != f.crc {
+		return nil, invalid("fit: CRC mismatch")
+	}
+	return t, nil
+}
+
+// readDefinition reads the body of a definition message
+func (f *fitReader) readDefinition(developer bool) (*fitDefinition, error) {
+	b, err := f.read(5)
+	if err != nil {
+		return nil, err
+	}
+	def := &fitDefinition{order: binary.ByteOrder(binary.LittleEndian)}
+	if b[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(b[2:4])
+	fields, err := f.read(3 * int(b[4]))
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fitField{num: fields[i], size: fields[i+1]})
+	}
+	if developer {
+		n, err := f.read(1)
+		if err != nil {
+			return nil, err
+		}
+		devFields, err := f.read(3 * int(n[0]))
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devBytes += int(devFields[i+1])
+		}
+	}
+	return def, nil
+}
+
+// fitUint decodes a 1, 2 or 4 byte unsigned field; all-ones is FIT's
+// "invalid" marker
+func fitUint(b []byte, order binary.ByteOrder) (uint64, bool) {
+	switch len(b) {
+	case 1:
+		return uint64(b[0]), b[0] != math.MaxUint8
+	case 2:
+		v := order.Uint16(b)
+		return uint64(v), v != math.MaxUint16
+	case 4:
+		v := order.Uint32(b)
+		return uint64(v), v != math.MaxUint32
+	}
+	return 0, false
+}
+
+// fitInt32 decodes a signed 4 byte field such as a position
+func fitInt32(b []byte, order binary.ByteOrder) (int32, bool) {
+	if len(b) != 4 {
+		return 0, false
+	}
+	v := int32(order.Uint32(b))
+	return v, v != math.MaxInt32
+}
+
+func semicircles(v int32) float64 {
+	return float64(v) * (180 / math.Exp2(31))
+}
+
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// fitCRC is the CRC-16 of the FIT protocol
+func fitCRC(crc uint16, b []byte) uint16 {
+	for _, c := range b {
+		tmp := fitCRCTable[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ fitCRCTable[c&0xf]
+		tmp = fitCRCTable[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ fitCRCTable[(c>>4)&0xf]
+	}
+	return crc
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
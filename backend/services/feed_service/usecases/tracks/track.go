@@ -0,0 +1,118 @@
+// Package tracks reads GPS recordings exported by watches and bike
+// computers (GPX, TCX and FIT) and summarizes them as workouts.
+package tracks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// supported file formats
+const (
+	FormatGPX = "gpx"
+	FormatTCX = "tcx"
+	FormatFIT = "fit"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("file must be a .gpx, .tcx or .fit recording")
+	ErrInvalidFile       = errors.New("invalid track file")
+	ErrNoPoints          = errors.New("track has no points")
+)
+
+// MaxPoints bounds the recording size; one point per second is over 55 hours
+const MaxPoints = 200000
+
+// Point is one sample of a recording. Lat/Lon are unset for indoor samples,
+// Distance is the device's cumulative distance in metres when it has one.
+type Point struct {
+	Time      time.Time
+	Lat, Lon  *float64
+	Elevation *float64
+	Distance  *float64
+	HeartRate *int
+}
+
+// Track is a parsed recording
+type Track struct {
+	Name     string
+	Activity string // one of the domain workout activities, "" if unknown
+	Points   []Point
+}
+
+// FormatFromName picks the format from a file name's extension
+func FormatFromName(name string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), ".")); ext {
+	case FormatGPX, FormatTCX, FormatFIT:
+		return ext, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Parse reads a whole recording in the given format. The file is streamed;
+// only the points are kept in memory.
+func Parse(format string, r io.Reader) (*Track, error) {
+	var (
+		t   *Track
+		err error
+	)
+	switch format {
+	case FormatGPX:
+		t, err = parseGPX(r)
+	case FormatTCX:
+		t, err = parseTCX(r)
+	case FormatFIT:
+		t, err = parseFIT(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Points) == 0 {
+		return nil, ErrNoPoints
+	}
+	return t, nil
+}
+
+// invalid wraps a parser error in ErrInvalidFile
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFile, fmt.Sprintf(format, args...))
+}
+
+// add appends p, failing once the track exceeds MaxPoints
+func (t *Track) add(p Point) error {
+	if len(t.Points) >= MaxPoints {
+		return invalid("more than %d points", MaxPoints)
+	}
+	t.Points = append(t.Points, p)
+	return nil
+}
+
+// activityAliases maps the sport names used by GPX <type>, TCX Sport and
+// FIT sport codes to the domain workout activities
+var activityAliases = map[string]string{
+	"running":  "run",
+	"run":      "run",
+	"walking":  "walk",
+	"walk":     "walk",
+	"biking":   "ride",
+	"cycling":  "ride",
+	"ride":     "ride",
+	"swimming": "swim",
+	"swim":     "swim",
+	"hiking":   "hike",
+	"hike":     "hike",
+	"training": "strength",
+	"strength": "strength",
+	"yoga":     "yoga",
+	"other":    "other",
+}
+
+func activity(sport string) string {
+	return activityAliases[strings.ToLower(strings.TrimSpace(sport))]
+}
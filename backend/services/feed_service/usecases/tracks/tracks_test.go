@@ -0,0 +1,268 @@
+package tracks
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// The fixtures in testdata are small recordings with known geometry:
+//
+//   - run.gpx: 21 points 0.001° of latitude apart (111.2 m), every 30 s,
+//     elevation cycling 30-34 m, heart rate 140-160
+//   - ride.tcx: 7 points with an odometer 500 m apart, every 60 s, the
+//     fourth one indoors without a position
+//   - run.fit: 6 records 250 m apart on the odometer, every 10 s; the last
+//     five use compressed timestamps that roll over the 5-bit offset, the
+//     first carries a developer field and the third an invalid heart rate
+func TestParseFixtures(t *testing.T) {
+	gpxStart := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	fitStart := fitEpoch.Add(1000000028 * time.Second)
+
+	tests := []struct {
+		file     string
+		name     string
+		activity string
+		points   int
+		started  time.Time
+		distance float64
+		duration time.Duration
+		pace     float64
+		gain     float64
+		hr       HeartRate
+		splits   []Split
+	}{
+		{
+			file:     "run.gpx",
+			name:     "Morning Run",
+			activity: "run",
+			points:   21,
+			started:  gpxStart,
+			distance: 2223.9,
+			duration: 10 * time.Minute,
+			pace:     269.8,
+			gain:     12,
+			hr:       HeartRate{Avg: 150, Max: 160, Min: 140},
+			splits: []Split{
+				{Distance: 1000.8, Duration: 270 * time.Second, Pace: 269.8, ElevationGain: 6},
+				{Distance: 1000.8, Duration: 270 * time.Second, Pace: 269.8, ElevationGain: 6},
+				{Distance: 222.4, Duration: time.Minute, Pace: 269.8},
+			},
+		},
+		{
+			file:     "ride.tcx",
+			activity: "ride",
+			points:   7,
+			started:  gpxStart,
+			distance: 3000,
+			duration: 6 * time.Minute,
+			pace:     120,
+			gain:     12,
+			hr:       HeartRate{Avg: 125, Max: 140, Min: 110},
+			splits: []Split{
+				{Distance: 1000, Duration: 2 * time.Minute, Pace: 120, ElevationGain: 4},
+				{Distance: 1000, Duration: 2 * time.Minute, Pace: 120, ElevationGain: 4},
+				{Distance: 1000, Duration: 2 * time.Minute, Pace: 120, ElevationGain: 4},
+			},
+		},
+		{
+			file:     "run.fit",
+			activity: "run",
+			points:   6,
+			started:  fitStart,
+			distance: 1250,
+			duration: 50 * time.Second,
+			pace:     40,
+			gain:     12,
+			hr:       HeartRate{Avg: 140, Max: 160, Min: 120},
+			splits: []Split{
+				{Distance: 1000, Duration: 40 * time.Second, Pace: 40, ElevationGain: 12},
+				{Distance: 250, Duration: 10 * time.Second, Pace: 40},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			track := parseFixture(t, tt.file)
+			if track.Name != tt.name || track.Activity != tt.activity {
+				t.Errorf("name, activity = %q, %q; want %q, %q", track.Name, track.Activity, tt.name, tt.activity)
+			}
+			if len(track.Points) != tt.points {
+				t.Fatalf("got %d points, want %d", len(track.Points), tt.points)
+			}
+
+			s := Analyze(track)
+			if s.StartedAt == nil || !s.StartedAt.Equal(tt.started) {
+				t.Errorf("started at %v, want %v", s.StartedAt, tt.started)
+			}
+			if s.Distance != tt.distance || s.Duration != tt.duration || s.Pace != tt.pace {
+				t.Errorf("distance, duration, pace = %v, %v, %v; want %v, %v, %v",
+					s.Distance, s.Duration, s.Pace, tt.distance, tt.duration, tt.pace)
+			}
+			if s.ElevationGain != tt.gain {
+				t.Errorf("elevation gain = %v, want %v", s.ElevationGain, tt.gain)
+			}
+			if s.HeartRate == nil || *s.HeartRate != tt.hr {
+				t.Errorf("heart rate = %+v, want %+v", s.HeartRate, tt.hr)
+			}
+			if len(s.Splits) != len(tt.splits) {
+				t.Fatalf("got %d splits %+v, want %d", len(s.Splits), s.Splits, len(tt.splits))
+			}
+			for i, sp := range s.Splits {
+				if sp != tt.splits[i] {
+					t.Errorf("split %d = %+v, want %+v", i, sp, tt.splits[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseFITFields(t *testing.T) {
+	track := parseFixture(t, "run.fit")
+	first, third := track.Points[0], track.Points[2]
+	if first.Lat == nil || math.Abs(*first.Lat-52) > 1e-6 || math.Abs(*first.Lon-13) > 1e-6 {
+		t.Errorf("first position = %v, %v; want 52, 13", first.Lat, first.Lon)
+	}
+	if first.Elevation == nil || *first.Elevation != 100 {
+		t.Errorf("first elevation = %v, want 100", first.Elevation)
+	}
+	if third.HeartRate != nil {
+		t.Errorf("invalid heart rate decoded as %d", *third.HeartRate)
+	}
+	for i, p := range track.Points {
+		if want := fitEpoch.Add(time.Duration(1000000028+10*i) * time.Second); !p.Time.Equal(want) {
+			t.Errorf("point %d at %v, want %v", i, p.Time, want)
+		}
+	}
+}
+
+func TestParseTCXIndoorPoint(t *testing.T) {
+	track := parseFixture(t, "ride.tcx")
+	if p := track.Points[3]; p.Lat != nil || p.Lon != nil {
+		t.Errorf("indoor point has a position: %v, %v", p.Lat, p.Lon)
+	}
+	if p := track.Points[4]; p.Lat == nil || *p.Lat != 48.02 {
+		t.Errorf("point 4 latitude = %v, want 48.02", p.Lat)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	fit, err := os.ReadFile(filepath.Join("testdata", "run.fit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipped := bytes.Clone(fit)
+	flipped[40] ^= 0x01
+	badHeader := bytes.Clone(fit)
+	badHeader[0] = 13
+
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+		want   error
+	}{
+		{"empty gpx", FormatGPX, readFixture(t, "empty.gpx"), ErrNoPoints},
+		{"truncated gpx", FormatGPX, readFixture(t, "truncated.gpx"), ErrInvalidFile},
+		{"bad tcx time", FormatTCX, readFixture(t, "badtime.tcx"), ErrInvalidFile},
+		{"gpx as tcx", FormatTCX, readFixture(t, "run.gpx"), ErrNoPoints},
+		{"gpx as fit", FormatFIT, readFixture(t, "run.gpx"), ErrInvalidFile},
+		{"fit crc mismatch", FormatFIT, flipped, ErrInvalidFile},
+		{"fit without crc", FormatFIT, fit[:len(fit)-2], ErrInvalidFile},
+		{"fit cut short", FormatFIT, fit[:100], ErrInvalidFile},
+		{"fit header size", FormatFIT, badHeader, ErrInvalidFile},
+		{"unknown format", "kml", readFixture(t, "run.gpx"), ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.format, bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Parse error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{"Morning_Run.gpx", FormatGPX, nil},
+		{"ride.TCX", FormatTCX, nil},
+		{"2024-05-01-06-00-00.fit", FormatFIT, nil},
+		{"route.kml", "", ErrUnsupportedFormat},
+		{"fit", "", ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		got, err := FormatFromName(tt.name)
+		if got != tt.want || err != tt.err {
+			t.Errorf("FormatFromName(%q) = %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestEncodePolyline(t *testing.T) {
+	// the example of Google's polyline documentation
+	coords := [][2]float64{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+	if got, want := encodePolyline(coords), "_p~iF~ps|U_ulLnnqC_mqNvxq`@"; got != want {
+		t.Errorf("encodePolyline = %q, want %q", got, want)
+	}
+}
+
+func TestPolylineSimplifies(t *testing.T) {
+	// run.gpx runs straight north, so only its ends are kept
+	track := parseFixture(t, "run.gpx")
+	want := encodePolyline([][2]float64{{52, 13}, {52.02, 13}})
+	if got := Polyline(track); got != want {
+		t.Errorf("Polyline = %q, want %q", got, want)
+	}
+
+	// a 1 m wobble is dropped, a 55 m detour is kept
+	wobble := [][2]float64{{0, 0}, {0.001, 0.00001}, {0.002, 0}}
+	if got := simplify(wobble, simplifyTolerance); len(got) != 2 {
+		t.Errorf("simplify kept the wobble: %v", got)
+	}
+	detour := [][2]float64{{0, 0}, {0.001, 0.0005}, {0.002, 0}}
+	if got := simplify(detour, simplifyTolerance); len(got) != 3 {
+		t.Errorf("simplify dropped the detour: %v", got)
+	}
+}
+
+func TestAnalyzeWithoutTimes(t *testing.T) {
+	lat1, lat2, lon := 10.0, 10.001, 20.0
+	s := Analyze(&Track{Points: []Point{{Lat: &lat1, Lon: &lon}, {Lat: &lat2, Lon: &lon}}})
+	if s.StartedAt != nil || s.Duration != 0 || s.Pace != 0 || len(s.Splits) != 0 {
+		t.Errorf("untimed track summary = %+v", s)
+	}
+	if s.Distance != 111.2 {
+		t.Errorf("distance = %v, want 111.2", s.Distance)
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func parseFixture(t *testing.T, name string) *Track {
+	t.Helper()
+	format, err := FormatFromName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	track, err := Parse(format, bytes.NewReader(readFixture(t, name)))
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return track
+}
@@ -0,0 +1,126 @@
+package tracks
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  *float64 `xml:"ele"`
+	Time string   `xml:"time"`
+	// Garmin's TrackPointExtension, the de facto way to store heart rate
+	HeartRate *int `xml:"extensions>TrackPointExtension>hr"`
+}
+
+// parseGPX decodes <trkpt> elements one at a time. Elements are matched
+// by local name so GPX 1.0 and 1.1 namespaces both work.
+func parseGPX(r io.Reader) (*Track, error) {
+	t := &Track{}
+	dec := xml.NewDecoder(r)
+	inTrk := false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return t, nil
+		}
+		if err != nil {
+			return nil, invalid("gpx: %v", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "trk":
+				inTrk = true
+			case "name", "type":
+				// track level metadata; waypoint and route names are ignored
+				if !inTrk {
+					continue
+				}
+				var s string
+				if err := dec.DecodeElement(&s, &el); err != nil {
+					return nil, invalid("gpx: %v", err)
+				}
+				if el.Name.Local == "name" && t.Name == "" {
+					t.Name = strings.TrimSpace(s)
+				} else if el.Name.Local == "type" && t.Activity == "" {
+					t.Activity = activity(s)
+				}
+			case "trkseg":
+				inTrk = false
+			case "trkpt":
+				var p gpxPoint
+				if err := dec.DecodeElement(&p, &el); err != nil {
+					return nil, invalid("gpx: %v", err)
+				}
+				pt := Point{Lat: &p.Lat, Lon: &p.Lon, Elevation: p.Ele, HeartRate: p.HeartRate}
+				if p.Time != "" {
+					if pt.Time, err = time.Parse(time.RFC3339, strings.TrimSpace(p.Time)); err != nil {
+						return nil, invalid("gpx: bad time %q", p.Time)
+					}
+				}
+				if err := t.add(pt); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+}
+
+type tcxPoint struct {
+	Time     string `xml:"Time"`
+	Position *struct {
+		Lat float64 `xml:"LatitudeDegrees"`
+		Lon float64 `xml:"LongitudeDegrees"`
+	} `xml:"Position"`
+	Altitude  *float64 `xml:"AltitudeMeters"`
+	Distance  *float64 `xml:"DistanceMeters"`
+	HeartRate *int     `xml:"HeartRateBpm>Value"`
+}
+
+// parseTCX decodes <Trackpoint> elements one at a time; the sport comes
+// from the Sport attribute of the first <Activity>
+func parseTCX(r io.Reader) (*Track, error) {
+	t := &Track{}
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return t, nil
+		}
+		if err != nil {
+			return nil, invalid("tcx: %v", err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch el.Name.Local {
+		case "Activity":
+			for _, a := range el.Attr {
+				if a.Name.Local == "Sport" && t.Activity == "" {
+					t.Activity = activity(a.Value)
+				}
+			}
+		case "Trackpoint":
+			var p tcxPoint
+			if err := dec.DecodeElement(&p, &el); err != nil {
+				return nil, invalid("tcx: %v", err)
+			}
+			pt := Point{Elevation: p.Altitude, Distance: p.Distance, HeartRate: p.HeartRate}
+			if p.Position != nil {
+				pt.Lat, pt.Lon = &p.Position.Lat, &p.Position.Lon
+			}
+			if pt.Time, err = time.Parse(time.RFC3339, strings.TrimSpace(p.Time)); err != nil {
+				return nil, invalid("tcx: bad time %q", p.Time)
+			}
+			if err := t.add(pt); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
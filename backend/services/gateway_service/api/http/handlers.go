@@ -12,17 +12,33 @@ func RegisterRoutes(r *gin.Engine, svc *service.GatewayService) {
 	// CORS middleware
 	r.Use(middleware.CORSMiddleware(svc.FrontURL))
 	// 1) public auth
+	// credential endpoints share one per-IP budget
+	limit := middleware.RateLimitMiddleware(svc.AuthRateLimit, svc.AuthRateWindow)
 	auth := r.Group("/auth")
 	{
 		auth.GET("/health", svc.AuthProxy())
-		auth.POST("/register", svc.AuthProxy())
-		auth.POST("/login", svc.AuthProxy())
+		auth.GET("/.well-known/jwks.json", svc.AuthProxy())
+		auth.POST("/register", limit, svc.AuthProxy())
+		auth.POST("/login", limit, svc.AuthProxy())
+		auth.POST("/login/mfa", limit, svc.AuthProxy())
+		auth.POST("/refresh", svc.AuthProxy())
+		auth.POST("/logout", svc.AuthProxy())
+		auth.POST("/password/forgot", limit, svc.AuthProxy())
+		auth.POST("/password/reset", svc.AuthProxy())
+		auth.GET("/verify", svc.AuthProxy())
+		auth.POST("/verify/resend", svc.AuthProxy())
+		auth.GET("/email/confirm", svc.AuthProxy())
 	}
 
 	// 2) protected JWT-middleware
-	protected := r.Group("/", middleware.JWTMiddleware(svc.JWTSecret, svc.AuthURL))
+	protected := r.Group("/", middleware.BlockInternalMiddleware(), middleware.JWTMiddleware(svc.Keys.Keyfunc, svc.Sessions))
 	{
+		protected.Any("/auth/me/*proxyPath", svc.AuthProxy())
+		protected.Any("/auth/sessions", svc.AuthProxy())
+		protected.Any("/auth/sessions/*proxyPath", svc.AuthProxy())
+		protected.Any("/auth/admin/*proxyPath", middleware.RequireRole("admin"), svc.AuthProxy())
 		protected.Any("/auth/user/*proxyPath", svc.AuthProxy())
+		protected.Any("/auth/mfa/*proxyPath", svc.AuthProxy())
 		protected.Any("/profile", svc.ProfileProxy())
 		protected.Any("/profile/*proxyPath", svc.ProfileProxy())
 		protected.Any("/feed", svc.FeedProxy())
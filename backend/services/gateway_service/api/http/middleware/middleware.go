@@ -1,18 +1,29 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
-	"time"
+
+	"gateway_service/usecases/sessions"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-func JWTMiddleware(secret, authServiceURL string) gin.HandlerFunc {
-	// создаём клиент с небольшим таймаутом
-	client := &http.Client{Timeout: 2 * time.Second}
+// accessClaims mirrors the claims issued by auth_service
+type accessClaims struct {
+	jwt.RegisteredClaims
+	EmailVerified bool   `json:"email_verified"`
+	SessionID     string `json:"sid"`
+}
+
+func JWTMiddleware(keyfunc jwt.Keyfunc, checker *sessions.Cache) gin.HandlerFunc {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}))
 
 	return func(c *gin.Context) {
 		// 1) Парсим и верифицируем JWT
@@ -23,45 +34,64 @@ func JWTMiddleware(secret, authServiceURL string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(parts[1], &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+		token, err := parser.ParseWithClaims(parts[1], &accessClaims{}, keyfunc)
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		claims, ok := token.Claims.(*jwt.RegisteredClaims)
-		if !ok || claims.Subject == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token contains no subject"})
+		claims, ok := token.Claims.(*accessClaims)
+		if !ok || claims.Subject == "" || claims.SessionID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token contains no subject or session"})
 			return
 		}
-		userID := claims.Subject
-		authURL := fmt.Sprintf("%s/auth/user/%s", authServiceURL, userID)
-		req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, authURL, nil)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+
+		// 2) Сессия не отозвана и пользователь существует (ответ кэшируется)
+		status, err := checker.Check(c.Request.Context(), claims.SessionID)
+		if errors.Is(err, sessions.ErrRevoked) || (err == nil && status.UserID != claims.Subject) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
 			return
 		}
-
-		resp, err := client.Do(req)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "cannot verify user"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "cannot verify session"})
 			return
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode == http.StatusNotFound {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
-			return
+		// 3) Всё ок, прокидываем userID дальше
+		c.Request.Header.Set("X-User-ID", status.UserID)
+		c.Request.Header.Set("X-Session-ID", status.ID)
+		c.Request.Header.Set("X-Email-Verified", strconv.FormatBool(status.EmailVerified))
+		// roles come from the session check rather than the token so a
+		// demotion applies without waiting for the token to expire
+		c.Request.Header.Set("X-User-Roles", strings.Join(status.Roles, ","))
+		c.Set("roles", status.Roles)
+		c.Next()
+	}
+}
+
+// RequireRole only lets through callers whose roles include role.
+// Must run after JWTMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, _ := c.Get("roles")
+		list, _ := roles.([]string)
+		for _, r := range list {
+			if r == role {
+				c.Next()
+				return
+			}
 		}
-		if resp.StatusCode != http.StatusOK {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user verification failed"})
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
+
+// BlockInternalMiddleware hides service-to-service routes from clients
+func BlockInternalMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.Contains(c.Request.URL.Path, "/internal/") {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
 			return
 		}
-
-		// 3) Всё ок, прокидываем userID дальше
-		c.Request.Header.Set("X-User-ID", userID)
 		c.Next()
 	}
 }
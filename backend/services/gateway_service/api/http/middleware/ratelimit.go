@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimitMiddleware allows at most limit requests per client IP in each
+// fixed window and answers 429 with Retry-After beyond that. State lives in
+// process memory; auth_service keeps the authoritative per-account counters.
+func RateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	if limit <= 0 || window <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	var (
+		mu      sync.Mutex
+		clients = map[string]*rateWindow{}
+		swept   = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// drop expired windows now and then so the map does not grow forever
+		if now.Sub(swept) > window {
+			for k, w := range clients {
+				if now.Sub(w.start) >= window {
+					delete(clients, k)
+				}
+			}
+			swept = now
+		}
+		w, ok := clients[ip]
+		if !ok || now.Sub(w.start) >= window {
+			w = &rateWindow{start: now}
+			clients[ip] = w
+		}
+		w.count++
+		over := w.count > limit
+		wait := w.start.Add(window).Sub(now)
+		mu.Unlock()
+
+		if over {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
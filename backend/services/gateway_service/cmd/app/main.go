@@ -3,7 +3,9 @@ package main
 import (
 	"gateway_service/api/http"
 	"gateway_service/cmd/config"
+	"gateway_service/usecases/jwks"
 	"gateway_service/usecases/service"
+	"gateway_service/usecases/sessions"
 
 	"log"
 
@@ -14,17 +16,26 @@ func main() {
 	// Config load
 	cfg := config.LoadConfig()
 
+	// Token verification keys published by the auth service
+	keys := jwks.NewCache(cfg.AuthServiceURL+"/auth/.well-known/jwks.json", cfg.JWKSCacheTTL)
+
 	// GatewayService
 	svc := service.NewGatewayService(
 		cfg.AuthServiceURL,
 		cfg.ProfileServiceURL,
 		cfg.FeedServiceURL,
 		cfg.FrontURL,
-		cfg.JWTSecret,
+		keys,
+		sessions.NewCache(cfg.AuthServiceURL, cfg.ServiceAuthToken, cfg.SessionCacheTTL),
+		cfg.AuthRateLimit,
+		cfg.AuthRateWindow,
 	)
 
 	// Gin
 	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 	http.RegisterRoutes(r, svc)
 
 	addr := ":8080"
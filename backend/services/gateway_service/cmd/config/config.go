@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type GatewayConfig struct {
@@ -10,7 +13,12 @@ type GatewayConfig struct {
 	ProfileServiceURL string
 	FeedServiceURL    string
 	FrontURL          string
-	JWTSecret         string
+	JWKSCacheTTL      time.Duration
+	ServiceAuthToken  string
+	SessionCacheTTL   time.Duration
+	AuthRateLimit     int
+	AuthRateWindow    time.Duration
+	TrustedProxies    []string
 }
 
 func LoadConfig() *GatewayConfig {
@@ -21,12 +29,38 @@ func LoadConfig() *GatewayConfig {
 		}
 		return v
 	}
+	durationEnv := func(key string, def time.Duration) time.Duration {
+		v, err := time.ParseDuration(os.Getenv(key))
+		if err != nil {
+			return def
+		}
+		return v
+	}
+	intEnv := func(key string, def int) int {
+		v, err := strconv.Atoi(os.Getenv(key))
+		if err != nil {
+			return def
+		}
+		return v
+	}
+	// the gateway is the edge: trust no forwarding headers unless told otherwise
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
 
 	return &GatewayConfig{
 		AuthServiceURL:    mustEnv("AUTH_SERVICE_URL"),
 		ProfileServiceURL: mustEnv("PROFILE_SERVICE_URL"),
 		FeedServiceURL:    mustEnv("FEED_SERVICE_URL"),
 		FrontURL:          mustEnv("FRONT_URL"),
-		JWTSecret:         mustEnv("JWT_SECRET"),
+		JWKSCacheTTL:      durationEnv("JWKS_CACHE_TTL", 10*time.Minute),
+		ServiceAuthToken:  mustEnv("GATEWAY_SERVICE_AUTH_TOKEN"),
+		SessionCacheTTL:   durationEnv("SESSION_CACHE_TTL", 30*time.Second),
+		AuthRateLimit:     intEnv("AUTH_RATE_LIMIT", 20),
+		AuthRateWindow:    durationEnv("AUTH_RATE_WINDOW", time.Minute),
+		TrustedProxies:    proxies,
 	}
 }
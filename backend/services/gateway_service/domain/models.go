@@ -74,9 +74,9 @@ func validateAvatarURL(fl validator.FieldLevel) bool {
 	u, err := url.Parse(avatarURL)
 	return err == nil &&
 		(u.Scheme == "http" || u.Scheme == "https") &&
-		strings.HasSuffix(u.Path, ".jpg") ||
-		strings.HasSuffix(u.Path, ".png") ||
-		strings.HasSuffix(u.Path, ".jpeg")
+		(strings.HasSuffix(u.Path, ".jpg") ||
+			strings.HasSuffix(u.Path, ".png") ||
+			strings.HasSuffix(u.Path, ".jpeg"))
 }
 
 // Validate main structure
@@ -19,6 +19,9 @@ func ReverseProxy(targetHost string) gin.HandlerFunc {
 		c.Request.URL.Scheme = target.Scheme
 		c.Request.URL.Host = target.Host
 		c.Request.Host = target.Host
+		// replace whatever the client sent with the address we resolved;
+		// the proxy appends our peer address after it
+		c.Request.Header.Set("X-Forwarded-For", c.ClientIP())
 		proxy.ServeHTTP(c.Writer, c.Request)
 	}
 }
@@ -0,0 +1,171 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minRefreshInterval limits how often unknown kids or a failing
+// endpoint can force a refetch
+const minRefreshInterval = 30 * time.Second
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+type entry struct {
+	alg string
+	key interface{}
+}
+
+// Cache fetches the auth service JWKS and keeps the keys for ttl.
+// A token signed with an unknown kid triggers an early refresh so that
+// newly rotated keys are picked up without waiting for the ttl.
+type Cache struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]entry
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewCache
+func NewCache(url string, ttl time.Duration) *Cache {
+	return &Cache{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 2 * time.Second},
+		keys:   map[string]entry{},
+	}
+}
+
+// Keyfunc resolves the verification key for a token by its kid header
+func (c *Cache) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid")
+	}
+
+	e, ok, needRefresh := c.lookup(kid)
+	if needRefresh {
+		if err := c.refresh(context.Background()); err != nil {
+			log.Printf("jwks: refresh failed: %v", err)
+		}
+		e, ok, _ = c.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if t.Method.Alg() != e.alg {
+		return nil, errors.New("algorithm does not match key")
+	}
+	return e.key, nil
+}
+
+func (c *Cache) lookup(kid string) (entry, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.keys[kid]
+	stale := time.Since(c.fetchedAt) > c.ttl
+	return e, ok, (!ok || stale) && time.Since(c.attemptedAt) > minRefreshInterval
+}
+
+func (c *Cache) refresh(ctx context.Context) error {
+	c.mu.Lock()
+	if time.Since(c.attemptedAt) <= minRefreshInterval {
+		c.mu.Unlock()
+		return nil
+	}
+	c.attemptedAt = time.Now()
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks endpoint returned %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]entry, len(set.Keys))
+	for _, k := range set.Keys {
+		e, err := parseJWK(k)
+		if err != nil {
+			log.Printf("jwks: skipping key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = e
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+func parseJWK(k jwk) (entry, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return entry{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return entry{}, err
+		}
+		pub := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return entry{alg: jwt.SigningMethodRS256.Alg(), key: pub}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return entry{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return entry{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return entry{}, errors.New("invalid Ed25519 key size")
+		}
+		return entry{alg: jwt.SigningMethodEdDSA.Alg(), key: ed25519.PublicKey(x)}, nil
+	default:
+		return entry{}, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -1,7 +1,11 @@
 package service
 
 import (
+	"time"
+
 	"gateway_service/usecases/helpers"
+	"gateway_service/usecases/jwks"
+	"gateway_service/usecases/sessions"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,17 +16,25 @@ type GatewayService struct {
 	ProfileURL string
 	FeedURL    string
 	FrontURL   string
-	JWTSecret  string
+	Keys       *jwks.Cache
+	Sessions   *sessions.Cache
+
+	// per-IP throttling of login, registration and password reset
+	AuthRateLimit  int
+	AuthRateWindow time.Duration
 }
 
 // NewGatewayService
-func NewGatewayService(authURL, profileURL, feedURL, frontURL, jwtSecret string) *GatewayService {
+func NewGatewayService(authURL, profileURL, feedURL, frontURL string, keys *jwks.Cache, sess *sessions.Cache, authRateLimit int, authRateWindow time.Duration) *GatewayService {
 	return &GatewayService{
-		AuthURL:    authURL,
-		ProfileURL: profileURL,
-		FeedURL:    feedURL,
-		FrontURL:   frontURL,
-		JWTSecret:  jwtSecret,
+		AuthURL:        authURL,
+		ProfileURL:     profileURL,
+		FeedURL:        feedURL,
+		FrontURL:       frontURL,
+		Keys:           keys,
+		Sessions:       sess,
+		AuthRateLimit:  authRateLimit,
+		AuthRateWindow: authRateWindow,
 	}
 }
 
@@ -0,0 +1,118 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrRevoked means the session was signed out, expired or its user deleted
+var ErrRevoked = errors.New("session revoked")
+
+// Status is auth_service's answer for a live session
+type Status struct {
+	ID            string   `json:"id"`
+	UserID        string   `json:"user_id"`
+	EmailVerified bool     `json:"email_verified"`
+	Roles         []string `json:"roles"`
+}
+
+type entry struct {
+	status  Status
+	revoked bool
+	expires time.Time
+}
+
+// Cache asks auth_service whether the session behind an access token is
+// still live and remembers the answer for ttl, so a remote sign-out takes
+// effect within ttl without a lookup on every request.
+type Cache struct {
+	baseURL string
+	token   string
+	ttl     time.Duration
+	client  *http.Client
+
+	mu      sync.Mutex
+	entries map[string]entry
+	swept   time.Time
+}
+
+// NewCache
+func NewCache(authURL, serviceToken string, ttl time.Duration) *Cache {
+	return &Cache{
+		baseURL: authURL,
+		token:   serviceToken,
+		ttl:     ttl,
+		client:  &http.Client{Timeout: 2 * time.Second},
+		entries: map[string]entry{},
+		swept:   time.Now(),
+	}
+}
+
+// Check returns the session status or ErrRevoked. Other errors mean
+// auth_service could not be asked and are not cached.
+func (c *Cache) Check(ctx context.Context, sessionID string) (Status, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[sessionID]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		if e.revoked {
+			return Status{}, ErrRevoked
+		}
+		return e.status, nil
+	}
+
+	status, err := c.fetch(ctx, sessionID)
+	if err != nil && !errors.Is(err, ErrRevoked) {
+		return Status{}, err
+	}
+
+	c.mu.Lock()
+	// drop stale entries now and then so the map does not grow forever
+	if now.Sub(c.swept) > c.ttl {
+		for k, old := range c.entries {
+			if now.After(old.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.swept = now
+	}
+	c.entries[sessionID] = entry{status: status, revoked: err != nil, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return status, err
+}
+
+func (c *Cache) fetch(ctx context.Context, sessionID string) (Status, error) {
+	u := fmt.Sprintf("%s/auth/internal/sessions/%s", c.baseURL, url.PathEscape(sessionID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Status{}, err
+	}
+	req.Header.Set("X-Service-Token", c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Status{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Status{}, ErrRevoked
+	default:
+		return Status{}, fmt.Errorf("session check returned %d", resp.StatusCode)
+	}
+
+	var s Status
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return Status{}, fmt.Errorf("decode session status: %w", err)
+	}
+	return s, nil
+}
@@ -24,6 +24,12 @@ func NewNotFound(msg string) APIError {
 func NewForbidden(msg string) APIError {
 	return APIError{Code: 403, Message: msg}
 }
+func NewConflict(msg string) APIError {
+	return APIError{Code: 409, Message: msg}
+}
+func NewTooLarge(msg string) APIError {
+	return APIError{Code: 413, Message: msg}
+}
 func NewInternal(err error) APIError {
 	return APIError{Code: 500, Message: "internal error", Err: err}
 }
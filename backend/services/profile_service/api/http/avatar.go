@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"profile_service/api/http/apierrors"
+	"profile_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadAvatar handles PUT /profile/avatar, a multipart upload with the
+// picture in the "file" part. It replaces the current avatar.
+func (h *ProfileHandler) UploadAvatar(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	tooLarge := fmt.Sprintf("avatars are limited to %d MB", h.limits.AvatarBytes>>20)
+	// leave room for the multipart framing around the file
+	limit := h.limits.AvatarBytes + 64<<10
+	if c.Request.ContentLength > limit {
+		return apierrors.NewTooLarge(tooLarge)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return apierrors.NewBadRequest("expected a multipart/form-data upload", err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return apierrors.NewBadRequest("missing file part", nil)
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return apierrors.NewTooLarge(tooLarge)
+			}
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		out, err := h.svc.UploadAvatar(c.Request.Context(), userID, part)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			switch {
+			case err == usecases.ErrNotFound:
+				return apierrors.NewNotFound(err.Error())
+			case errors.As(err, &maxErr):
+				return apierrors.NewTooLarge(tooLarge)
+			case errors.Is(err, usecases.ErrAvatarTooLarge):
+				return apierrors.NewTooLarge(err.Error())
+			case errors.Is(err, usecases.ErrBadAvatar):
+				return apierrors.NewBadRequest(err.Error(), err)
+			}
+			return apierrors.NewInternal(err)
+		}
+		c.JSON(http.StatusOK, out)
+		return nil
+	}
+}
+
+// DeleteAvatar handles DELETE /profile/avatar
+func (h *ProfileHandler) DeleteAvatar(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	out, err := h.svc.DeleteAvatar(c.Request.Context(), userID)
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// ServeAvatar handles GET /profile/avatars/:file
+// Each upload gets new file names, so clients may cache them for good.
+func (h *ProfileHandler) ServeAvatar(c *gin.Context) error {
+	etag := `"` + c.Param("file") + `"`
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return nil
+	}
+	contentType, body, err := h.svc.OpenAvatar(c.Request.Context(), c.Param("file"))
+	if err != nil {
+		if err == usecases.ErrAvatarNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	defer body.Close()
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, body, nil)
+	return nil
+}
@@ -13,12 +13,20 @@ import (
 // ProfileHandler handles HTTP requests for profiles
 // and delegates to the ProfileService business logic.
 type ProfileHandler struct {
-	svc usecases.ProfileService
+	svc    usecases.ProfileService
+	limits UploadLimits
+}
+
+// UploadLimits caps the size of uploaded files, in bytes
+type UploadLimits struct {
+	AvatarBytes int64
+	// ImportBytes caps a health app export, which may run to gigabytes
+	ImportBytes int64
 }
 
 // NewProfileHandler constructs a new ProfileHandler
-func NewProfileHandler(svc usecases.ProfileService) *ProfileHandler {
-	return &ProfileHandler{svc: svc}
+func NewProfileHandler(svc usecases.ProfileService, limits UploadLimits) *ProfileHandler {
+	return &ProfileHandler{svc: svc, limits: limits}
 }
 
 // RegisterRoutes registers profile routes on the Gin engine
@@ -30,6 +38,17 @@ func (h *ProfileHandler) RegisterRoutes(r *gin.Engine) {
 		grp.GET("", middleware.ErrorHandlerMiddleware(h.GetByID))
 		grp.PUT("", middleware.ErrorHandlerMiddleware(h.Update))
 		grp.DELETE("", middleware.ErrorHandlerMiddleware(h.Delete))
+		grp.PUT("/avatar", middleware.ErrorHandlerMiddleware(h.UploadAvatar))
+		grp.DELETE("/avatar", middleware.ErrorHandlerMiddleware(h.DeleteAvatar))
+		grp.GET("/avatars/:file", middleware.ErrorHandlerMiddleware(h.ServeAvatar))
+		grp.POST("/measurements", middleware.ErrorHandlerMiddleware(h.LogMeasurement))
+		grp.GET("/measurements", middleware.ErrorHandlerMiddleware(h.ListMeasurements))
+		grp.GET("/measurements/aggregate", middleware.ErrorHandlerMiddleware(h.AggregateMeasurements))
+		grp.GET("/measurements/:id", middleware.ErrorHandlerMiddleware(h.GetMeasurement))
+		grp.DELETE("/measurements/:id", middleware.ErrorHandlerMiddleware(h.DeleteMeasurement))
+		grp.POST("/imports", middleware.ErrorHandlerMiddleware(h.StartImport))
+		grp.GET("/imports", middleware.ErrorHandlerMiddleware(h.ListImports))
+		grp.GET("/imports/:id", middleware.ErrorHandlerMiddleware(h.GetImport))
 	}
 }
 
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"profile_service/api/http/apierrors"
+	"profile_service/domain"
+	"profile_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartImport handles POST /profile/imports?tz=, a multipart upload with
+// an Apple Health export.zip or a Google Takeout in the "file" part. The
+// import runs in the background; the response is the queued job.
+func (h *ProfileHandler) StartImport(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	tz := c.DefaultQuery("tz", "UTC")
+	if _, err := domain.LoadZone(tz); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	tooLarge := fmt.Sprintf("exports are limited to %d MB", h.limits.ImportBytes>>20)
+	// leave room for the multipart framing around the file
+	limit := h.limits.ImportBytes + 64<<10
+	if c.Request.ContentLength > limit {
+		return apierrors.NewTooLarge(tooLarge)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return apierrors.NewBadRequest("expected a multipart/form-data upload", err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return apierrors.NewBadRequest("missing file part", nil)
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return apierrors.NewTooLarge(tooLarge)
+			}
+			return apierrors.NewBadRequest(err.Error(), err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		out, err := h.svc.StartImport(c.Request.Context(), userID, tz, part)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			switch {
+			case err == usecases.ErrNotFound:
+				return apierrors.NewNotFound(err.Error())
+			case err == usecases.ErrImportInProgress:
+				return apierrors.NewConflict(err.Error())
+			case err == usecases.ErrUnsupportedImport:
+				return apierrors.NewBadRequest(err.Error(), err)
+			case errors.As(err, &maxErr):
+				return apierrors.NewTooLarge(tooLarge)
+			}
+			return apierrors.NewInternal(err)
+		}
+		c.JSON(http.StatusAccepted, out)
+		return nil
+	}
+}
+
+// ListImports handles GET /profile/imports, the latest imports first
+func (h *ProfileHandler) ListImports(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	out, err := h.svc.ListImports(c.Request.Context(), userID)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, gin.H{"imports": out})
+	return nil
+}
+
+// GetImport handles GET /profile/imports/:id, polled for progress
+func (h *ProfileHandler) GetImport(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	out, err := h.svc.GetImport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == usecases.ErrImportNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	if out.UserID != userID {
+		return apierrors.NewForbidden("unauthorized to view this import")
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"profile_service/api/http/apierrors"
+	"profile_service/domain"
+	"profile_service/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogMeasurement handles POST /profile/measurements
+func (h *ProfileHandler) LogMeasurement(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+
+	var req domain.MeasurementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	if err := req.Validate(); err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.LogMeasurement(c.Request.Context(), userID, req)
+	if err != nil {
+		if err == usecases.ErrNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusCreated, out)
+	return nil
+}
+
+// ListMeasurements handles GET /profile/measurements?metric=&from=&to=&limit=&cursor=
+// Readings come newest first.
+func (h *ProfileHandler) ListMeasurements(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	q, err := domain.NewMeasurementQuery(c.Query("metric"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+	page, err := domain.NewPageRequest(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.ListMeasurements(c.Request.Context(), userID, q, page)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// AggregateMeasurements handles
+// GET /profile/measurements/aggregate?metric=&interval=&from=&to=&tz=
+func (h *ProfileHandler) AggregateMeasurements(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+	q, err := domain.NewAggregateQuery(
+		c.Query("metric"), c.Query("interval"), c.Query("from"), c.Query("to"), c.Query("tz"), time.Now(),
+	)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error(), err)
+	}
+
+	out, err := h.svc.AggregateMeasurements(c.Request.Context(), userID, q)
+	if err != nil {
+		return apierrors.NewInternal(err)
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// GetMeasurement handles GET /profile/measurements/:id
+func (h *ProfileHandler) GetMeasurement(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	out, err := h.svc.GetMeasurement(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == usecases.ErrMeasurementNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	if out.UserID != userID {
+		return apierrors.NewForbidden("unauthorized to view this measurement")
+	}
+	c.JSON(http.StatusOK, out)
+	return nil
+}
+
+// DeleteMeasurement handles DELETE /profile/measurements/:id
+// Only the owner of the reading can delete it.
+func (h *ProfileHandler) DeleteMeasurement(c *gin.Context) error {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		return apierrors.NewBadRequest("missing X-User-ID header", nil)
+	}
+
+	// Fetch the reading to verify ownership
+	existing, err := h.svc.GetMeasurement(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == usecases.ErrMeasurementNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	if existing.UserID != userID {
+		return apierrors.NewForbidden("unauthorized to delete this measurement")
+	}
+
+	if err := h.svc.DeleteMeasurement(c.Request.Context(), existing.MeasurementID); err != nil {
+		if err == usecases.ErrMeasurementNotFound {
+			return apierrors.NewNotFound(err.Error())
+		}
+		return apierrors.NewInternal(err)
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
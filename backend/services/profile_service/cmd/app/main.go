@@ -9,9 +9,13 @@ import (
 	"syscall"
 	"time"
 
+	"blob"
 	handler "profile_service/api/http"
 	"profile_service/cmd/config"
+	"profile_service/repository"
 	"profile_service/repository/db"
+	"profile_service/usecases/avatar"
+	"profile_service/usecases/healthimport"
 	profileService "profile_service/usecases/service"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +25,8 @@ func main() {
 	// Config
 	dbCfg := config.LoadDBConfig()
 	svcCfg := config.LoafServiceCfg()
+	avatarCfg := config.LoadAvatarConfig()
+	importCfg := config.LoadImportConfig()
 
 	// DB init
 	gormDB, err := db.InitDB(dbCfg)
@@ -50,9 +56,45 @@ func main() {
 	router := gin.New()
 	router.Use(gin.Logger(), gin.Recovery())
 
+	// avatar files: local directory or S3-compatible bucket
+	var blobs repository.BlobStore
+	switch avatarCfg.Store {
+	case "s3":
+		blobs = blob.NewS3Store(blob.S3Config{
+			Endpoint:  avatarCfg.S3Endpoint,
+			Region:    avatarCfg.S3Region,
+			Bucket:    avatarCfg.S3Bucket,
+			AccessKey: avatarCfg.S3AccessKey,
+			SecretKey: avatarCfg.S3SecretKey,
+		})
+	case "fs":
+		if blobs, err = blob.NewFSStore(avatarCfg.Dir); err != nil {
+			log.Fatalf("failed to init avatar store: %v", err)
+		}
+	default:
+		log.Fatalf("unknown AVATAR_STORE %q", avatarCfg.Store)
+	}
+
 	repo := db.NewProfileRepo(gormDB)
-	svc := profileService.NewProfileService(repo, svcCfg.Auth_service_url, svcCfg.Feed_service_url, svcCfg.ProfileServiceAuthToken)
-	h := handler.NewProfileHandler(svc)
+	svc := profileService.NewProfileService(
+		repo,
+		db.NewMeasurementRepo(gormDB),
+		db.NewImportRepo(gormDB),
+		svcCfg.Auth_service_url,
+		svcCfg.Feed_service_url,
+		svcCfg.ProfileServiceAuthToken,
+		blobs,
+		avatar.Config{URLPrefix: avatarCfg.URLPrefix, MaxBytes: avatarCfg.MaxBytes},
+		healthimport.Config{Dir: importCfg.Dir, Workers: importCfg.Workers},
+	)
+	// imports cut short by the last shutdown cannot resume
+	if err := svc.RecoverImports(ctx); err != nil {
+		log.Printf("failed to recover imports: %v", err)
+	}
+	h := handler.NewProfileHandler(svc, handler.UploadLimits{
+		AvatarBytes: avatarCfg.MaxBytes,
+		ImportBytes: importCfg.MaxBytes,
+	})
 	h.RegisterRoutes(router)
 
 	srv := &http.Server{
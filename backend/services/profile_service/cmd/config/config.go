@@ -31,6 +31,48 @@ func LoafServiceCfg() ServiceConfig {
 	}
 }
 
+// AvatarConfig selects where avatars are stored and bounds their size
+type AvatarConfig struct {
+	Store       string // fs or s3
+	Dir         string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	URLPrefix   string
+	MaxBytes    int64
+}
+
+func LoadAvatarConfig() AvatarConfig {
+	return AvatarConfig{
+		Store:       getEnv("AVATAR_STORE", "fs"),
+		Dir:         getEnv("AVATAR_DIR", "./data/avatars"),
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Region:    os.Getenv("S3_REGION"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+		URLPrefix:   getEnv("AVATAR_URL_PREFIX", "/profile/avatars"),
+		MaxBytes:    int64(getEnvAsInt("MAX_AVATAR_BYTES", 5<<20)),
+	}
+}
+
+// ImportConfig bounds health app export imports
+type ImportConfig struct {
+	Dir      string
+	MaxBytes int64
+	Workers  int
+}
+
+func LoadImportConfig() ImportConfig {
+	return ImportConfig{
+		Dir:      getEnv("IMPORT_DIR", "./data/imports"),
+		MaxBytes: int64(getEnvAsInt("MAX_IMPORT_BYTES", 4<<30)),
+		Workers:  getEnvAsInt("IMPORT_WORKERS", 2),
+	}
+}
+
 func LoadDBConfig() DBConfig {
 	return DBConfig{
 		Host:            os.Getenv("DB_HOST"),
@@ -44,6 +86,13 @@ func LoadDBConfig() DBConfig {
 	}
 }
 
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	valueStr := os.Getenv(key)
 	if valueStr == "" {
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// import job states
+const (
+	ImportQueued  = "queued"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// workout activities known to feed_service
+const (
+	ActivityRun      = "run"
+	ActivityWalk     = "walk"
+	ActivityRide     = "ride"
+	ActivitySwim     = "swim"
+	ActivityHike     = "hike"
+	ActivityStrength = "strength"
+	ActivityYoga     = "yoga"
+	ActivityOther    = "other"
+)
+
+// ImportJob tracks the import of a health app export. The counters are
+// saved while the job runs, so polling its status shows progress.
+type ImportJob struct {
+	gorm.Model
+	ImportID string `gorm:"type:char(36);uniqueIndex"`
+	UserID   string `gorm:"type:char(36);not null;index"`
+	Source   string `gorm:"size:32;not null"` // apple_health or google_fit
+	Status   string `gorm:"size:16;not null;default:queued"`
+	// TZ decides the calendar day of daily totals when the export has no
+	// time zones of its own
+	TZ       string `gorm:"size:64;not null;default:UTC"`
+	FilePath string `gorm:"size:500"` // the upload, removed when the job ends
+	// BytesRead of BytesTotal bytes of health data have been parsed
+	BytesRead    int64
+	BytesTotal   int64
+	Records      int64
+	Measurements int64
+	Workouts     int64
+	Duplicates   int64
+	Skipped      int64
+	Error        string `gorm:"size:500"`
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+}
+
+// Active reports whether the job is waiting or running
+func (j *ImportJob) Active() bool {
+	return j.Status == ImportQueued || j.Status == ImportRunning
+}
+
+// ImportJobResponse is the status of an import as sent back to clients
+type ImportJobResponse struct {
+	ImportID string `json:"import_id"`
+	UserID   string `json:"user_id"`
+	Source   string `json:"source"`
+	Status   string `json:"status"`
+	// Progress is the share of the export parsed so far, from 0 to 1
+	Progress float64 `json:"progress"`
+	// Records counts the entries we know how to import; Measurements and
+	// Workouts those saved as new or changed, Duplicates those already
+	// imported before and Skipped those with implausible values
+	Records      int64      `json:"records"`
+	Measurements int64      `json:"measurements"`
+	Workouts     int64      `json:"workouts"`
+	Duplicates   int64      `json:"duplicates"`
+	Skipped      int64      `json:"skipped"`
+	Error        string     `json:"error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// ToResponse converts a job to its API form
+func (j *ImportJob) ToResponse() ImportJobResponse {
+	out := ImportJobResponse{
+		ImportID:     j.ImportID,
+		UserID:       j.UserID,
+		Source:       j.Source,
+		Status:       j.Status,
+		Records:      j.Records,
+		Measurements: j.Measurements,
+		Workouts:     j.Workouts,
+		Duplicates:   j.Duplicates,
+		Skipped:      j.Skipped,
+		Error:        j.Error,
+		CreatedAt:    j.CreatedAt,
+		StartedAt:    j.StartedAt,
+		FinishedAt:   j.FinishedAt,
+	}
+	switch {
+	case j.Status == ImportDone:
+		out.Progress = 1
+	case j.BytesTotal > 0:
+		out.Progress = min(float64(j.BytesRead)/float64(j.BytesTotal), 1)
+	}
+	return out
+}
+
+// ImportedWorkout mirrors feed_service's internal workout import request
+type ImportedWorkout struct {
+	ExternalID string         `json:"external_id"`
+	Name       string         `json:"name"`
+	Title      string         `json:"title"`
+	StartedAt  time.Time      `json:"started_at"`
+	Workout    WorkoutDetails `json:"workout"`
+}
+
+// WorkoutDetails mirrors feed_service's workout fields
+type WorkoutDetails struct {
+	Activity        string            `json:"activity"`
+	DurationSeconds int               `json:"duration_seconds"`
+	DistanceMeters  *float64          `json:"distance_m,omitempty"`
+	Calories        *int              `json:"calories,omitempty"`
+	HeartRate       *HeartRateSummary `json:"heart_rate,omitempty"`
+}
+
+// HeartRateSummary is the heart rate of a workout in beats per minute
+type HeartRateSummary struct {
+	Avg int  `json:"avg"`
+	Max *int `json:"max,omitempty"`
+	Min *int `json:"min,omitempty"`
+}
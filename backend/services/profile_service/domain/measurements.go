@@ -0,0 +1,437 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Metrics a user can log
+const (
+	MetricWeight           = "weight"
+	MetricRestingHeartRate = "resting_heart_rate"
+	MetricSleep            = "sleep"
+	MetricSteps            = "steps"
+	MetricWater            = "water"
+	MetricBloodPressure    = "blood_pressure"
+)
+
+// Aggregation intervals; weeks start on Monday
+const (
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+const (
+	// SourceManual marks readings entered through the API; imported ones
+	// carry the name of the app
+	SourceManual = "manual"
+	// maxBuckets bounds the date range of an aggregate query
+	maxBuckets = 400
+	// clockSkew tolerates client clocks running slightly ahead
+	clockSkew = 5 * time.Minute
+)
+
+// metricSpec describes how a metric is stored: every reading is converted
+// to Unit on the way in, so series never mix units
+type metricSpec struct {
+	Unit    string
+	Factors map[string]float64 // accepted unit -> multiplier to Unit
+	Min     float64            // plausible range in Unit
+	Max     float64
+	Whole   bool // only whole numbers make sense
+}
+
+var metricSpecs = map[string]metricSpec{
+	MetricWeight: {
+		Unit:    "kg",
+		Factors: map[string]float64{"kg": 1, "g": 0.001, "lb": 0.45359237, "st": 6.35029318},
+		Min:     1, Max: 700,
+	},
+	MetricRestingHeartRate: {
+		Unit:    "bpm",
+		Factors: map[string]float64{"bpm": 1},
+		Min:     20, Max: 250, Whole: true,
+	},
+	MetricSleep: {
+		Unit:    "h",
+		Factors: map[string]float64{"h": 1, "min": 1.0 / 60},
+		Min:     0, Max: 24,
+	},
+	MetricSteps: {
+		Unit:    "steps",
+		Factors: map[string]float64{"steps": 1, "count": 1},
+		Min:     0, Max: 200000, Whole: true,
+	},
+	MetricWater: {
+		Unit:    "ml",
+		Factors: map[string]float64{"ml": 1, "l": 1000, "fl_oz": 29.5735295625, "cup": 236.5882365},
+		Min:     0, Max: 20000,
+	},
+	// the systolic pressure; the diastolic one shares the unit
+	MetricBloodPressure: {
+		Unit:    "mmHg",
+		Factors: map[string]float64{"mmHg": 1, "kPa": 7.50061683},
+		Min:     40, Max: 300, Whole: true,
+	},
+}
+
+// Measurement is one timestamped reading of a metric, stored in the
+// metric's canonical unit. Blood pressure keeps the systolic value in
+// Value and the diastolic one in Value2.
+type Measurement struct {
+	gorm.Model
+	MeasurementID string    `gorm:"type:char(36);uniqueIndex"`
+	UserID        string    `gorm:"type:char(36);not null;index:idx_measurements_series,priority:1;uniqueIndex:idx_measurements_external,priority:1"`
+	Metric        string    `gorm:"size:24;not null;index:idx_measurements_series,priority:2"`
+	Value         float64   `gorm:"not null"`
+	Value2        *float64  `gorm:"column:value2"`
+	MeasuredAt    time.Time `gorm:"not null;index:idx_measurements_series,priority:3"`
+	Source        string    `gorm:"size:32;not null;default:manual"`
+	// ExternalID identifies a reading imported from a health app, so a
+	// re-import updates it instead of adding a copy
+	ExternalID *string `gorm:"size:64;uniqueIndex:idx_measurements_external,priority:2"`
+}
+
+// MeasurementRequest is the body of POST /profile/measurements. Unit
+// defaults to the metric's canonical one and MeasuredAt to now.
+type MeasurementRequest struct {
+	Metric     string     `json:"metric"`
+	Value      *float64   `json:"value"`
+	Diastolic  *float64   `json:"diastolic,omitempty"`
+	Unit       string     `json:"unit,omitempty"`
+	MeasuredAt *time.Time `json:"measured_at,omitempty"`
+}
+
+// Validate checks the metric, unit and that the converted values are plausible
+func (r *MeasurementRequest) Validate() error {
+	_, err := r.normalize(time.Now())
+	return err
+}
+
+// ToMeasurement converts a validated request to the canonical unit
+func (r *MeasurementRequest) ToMeasurement(userID string, now time.Time) (Measurement, error) {
+	m, err := r.normalize(now)
+	if err != nil {
+		return Measurement{}, err
+	}
+	m.MeasurementID = NewUUID()
+	m.UserID = userID
+	m.Source = SourceManual
+	return m, nil
+}
+
+func (r *MeasurementRequest) normalize(now time.Time) (Measurement, error) {
+	spec, ok := metricSpecs[r.Metric]
+	if !ok {
+		return Measurement{}, fmt.Errorf("metric must be one of %s", strings.Join(Metrics(), ", "))
+	}
+	if r.Value == nil {
+		return Measurement{}, errors.New("value is required")
+	}
+	unit := r.Unit
+	if unit == "" {
+		unit = spec.Unit
+	}
+	factor, ok := spec.Factors[unit]
+	if !ok {
+		return Measurement{}, fmt.Errorf("unit for %s must be one of %s", r.Metric, strings.Join(spec.units(), ", "))
+	}
+
+	m := Measurement{Metric: r.Metric, MeasuredAt: now.UTC()}
+	if r.MeasuredAt != nil {
+		if r.MeasuredAt.After(now.Add(clockSkew)) {
+			return Measurement{}, errors.New("measured_at is in the future")
+		}
+		m.MeasuredAt = r.MeasuredAt.UTC()
+	}
+	var err error
+	if m.Value, err = spec.convert("value", *r.Value, factor); err != nil {
+		return Measurement{}, err
+	}
+
+	switch {
+	case r.Metric == MetricBloodPressure && r.Diastolic == nil:
+		return Measurement{}, errors.New("diastolic is required for blood_pressure")
+	case r.Metric != MetricBloodPressure && r.Diastolic != nil:
+		return Measurement{}, errors.New("diastolic is only allowed for blood_pressure")
+	case r.Diastolic != nil:
+		// diastolic readings sit lower than the systolic range
+		dia := spec
+		dia.Min, dia.Max = 20, 200
+		d, err := dia.convert("diastolic", *r.Diastolic, factor)
+		if err != nil {
+			return Measurement{}, err
+		}
+		if d >= m.Value {
+			return Measurement{}, errors.New("diastolic must be below the systolic value")
+		}
+		m.Value2 = &d
+	}
+	return m, nil
+}
+
+// convert scales v to the canonical unit and checks it against the range
+func (s metricSpec) convert(field string, v, factor float64) (float64, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%s must be a number", field)
+	}
+	v *= factor
+	if s.Whole {
+		v = math.Round(v)
+	} else {
+		v = math.Round(v*1000) / 1000
+	}
+	if v < s.Min || v > s.Max {
+		return 0, fmt.Errorf("%s must be between %g and %g %s", field, s.Min, s.Max, s.Unit)
+	}
+	return v, nil
+}
+
+func (s metricSpec) units() []string {
+	units := make([]string, 0, len(s.Factors))
+	for u := range s.Factors {
+		units = append(units, u)
+	}
+	slices.Sort(units)
+	return units
+}
+
+// Metrics lists the known metric names
+func Metrics() []string {
+	names := make([]string, 0, len(metricSpecs))
+	for m := range metricSpecs {
+		names = append(names, m)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// MetricUnit is the canonical unit of a metric
+func MetricUnit(metric string) string {
+	return metricSpecs[metric].Unit
+}
+
+// MeasurementResponse is a reading as sent back to clients
+type MeasurementResponse struct {
+	MeasurementID string    `json:"measurement_id"`
+	UserID        string    `json:"user_id"`
+	Metric        string    `json:"metric"`
+	Value         float64   `json:"value"`
+	Diastolic     *float64  `json:"diastolic,omitempty"`
+	Unit          string    `json:"unit"`
+	MeasuredAt    time.Time `json:"measured_at"`
+	Source        string    `json:"source"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ToResponse converts a measurement to its API form
+func (m *Measurement) ToResponse() MeasurementResponse {
+	return MeasurementResponse{
+		MeasurementID: m.MeasurementID,
+		UserID:        m.UserID,
+		Metric:        m.Metric,
+		Value:         m.Value,
+		Diastolic:     m.Value2,
+		Unit:          MetricUnit(m.Metric),
+		MeasuredAt:    m.MeasuredAt,
+		Source:        m.Source,
+		CreatedAt:     m.CreatedAt,
+	}
+}
+
+// MeasurementQuery selects readings for GET /profile/measurements; an
+// empty Metric means all metrics, nil bounds are open
+type MeasurementQuery struct {
+	Metric string
+	From   *time.Time // inclusive
+	To     *time.Time // exclusive
+}
+
+// NewMeasurementQuery parses ?metric=&from=&to=. A date-only to includes
+// that whole day.
+func NewMeasurementQuery(metric, from, to string) (MeasurementQuery, error) {
+	q := MeasurementQuery{Metric: metric}
+	if _, ok := metricSpecs[metric]; metric != "" && !ok {
+		return MeasurementQuery{}, fmt.Errorf("metric must be one of %s", strings.Join(Metrics(), ", "))
+	}
+	var err error
+	if q.From, err = parseBound(from, time.UTC, false); err != nil {
+		return MeasurementQuery{}, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+	if q.To, err = parseBound(to, time.UTC, true); err != nil {
+		return MeasurementQuery{}, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return MeasurementQuery{}, errors.New("from must be before to")
+	}
+	return q, nil
+}
+
+// AggregateQuery asks for per-day or per-week statistics of one metric.
+// Buckets follow the calendar of TZ.
+type AggregateQuery struct {
+	Metric   string
+	Interval string
+	TZ       string
+	From     time.Time // inclusive
+	To       time.Time // exclusive
+}
+
+// NewAggregateQuery parses ?metric=&interval=&from=&to=&tz=. Without
+// bounds it covers the last 30 days or the last 12 weeks, today included.
+func NewAggregateQuery(metric, interval, from, to, tz string, now time.Time) (AggregateQuery, error) {
+	if _, ok := metricSpecs[metric]; !ok {
+		return AggregateQuery{}, fmt.Errorf("metric must be one of %s", strings.Join(Metrics(), ", "))
+	}
+	q := AggregateQuery{Metric: metric, Interval: interval, TZ: tz}
+	if q.Interval == "" {
+		q.Interval = IntervalDay
+	}
+	if q.TZ == "" {
+		q.TZ = "UTC"
+	}
+	loc, err := LoadZone(q.TZ)
+	if err != nil {
+		return AggregateQuery{}, err
+	}
+
+	var days int
+	switch q.Interval {
+	case IntervalDay:
+		days = 30
+	case IntervalWeek:
+		days = 12 * 7
+	default:
+		return AggregateQuery{}, errors.New("interval must be day or week")
+	}
+	lo, err := parseBound(from, loc, false)
+	if err != nil {
+		return AggregateQuery{}, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+	hi, err := parseBound(to, loc, true)
+	if err != nil {
+		return AggregateQuery{}, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+
+	local := now.In(loc)
+	q.To = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+	if hi != nil {
+		q.To = *hi
+	}
+	q.From = q.To.AddDate(0, 0, -days)
+	if q.Interval == IntervalWeek {
+		// start on a Monday so the first bucket is a whole week
+		q.From = q.From.AddDate(0, 0, -(int(q.From.Weekday())+6)%7)
+	}
+	if lo != nil {
+		q.From = *lo
+	}
+	if !q.From.Before(q.To) {
+		return AggregateQuery{}, errors.New("from must be before to")
+	}
+	span := q.To.Sub(q.From).Hours() / 24
+	if q.Interval == IntervalWeek {
+		span /= 7
+	}
+	if span > maxBuckets {
+		return AggregateQuery{}, fmt.Errorf("the range may cover at most %d %ss", maxBuckets, q.Interval)
+	}
+	return q, nil
+}
+
+// LoadZone resolves a ?tz= parameter
+func LoadZone(tz string) (*time.Location, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return nil, errors.New("tz must be an IANA time zone such as Europe/Berlin")
+	}
+	return loc, nil
+}
+
+// parseBound reads a date or RFC 3339 time; a date is midnight in loc, or
+// the following midnight when it closes a range
+func parseBound(s string, loc *time.Location, end bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, loc)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// MeasurementBucket holds the statistics of one day or week. The
+// diastolic columns are only set for blood pressure.
+type MeasurementBucket struct {
+	Start        time.Time
+	Count        int64
+	Avg          float64
+	Min          float64
+	Max          float64
+	Sum          float64
+	AvgDiastolic *float64
+	MinDiastolic *float64
+	MaxDiastolic *float64
+}
+
+// BucketStats summarises the readings of a bucket
+type BucketStats struct {
+	Avg float64 `json:"avg"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// MeasurementBucketResponse is one point of a downsampled series. Sum is
+// the daily or weekly total, meaningful for steps and water.
+type MeasurementBucketResponse struct {
+	Start string `json:"start"` // first day of the bucket, YYYY-MM-DD
+	Count int64  `json:"count"`
+	BucketStats
+	Sum       float64      `json:"sum"`
+	Diastolic *BucketStats `json:"diastolic,omitempty"`
+}
+
+// MeasurementSeries is the response of GET /profile/measurements/aggregate.
+// Buckets without readings are left out.
+type MeasurementSeries struct {
+	Metric   string                      `json:"metric"`
+	Unit     string                      `json:"unit"`
+	Interval string                      `json:"interval"`
+	TZ       string                      `json:"tz"`
+	Buckets  []MeasurementBucketResponse `json:"buckets"`
+}
+
+// ToResponse rounds the statistics for display
+func (b *MeasurementBucket) ToResponse() MeasurementBucketResponse {
+	out := MeasurementBucketResponse{
+		Start:       b.Start.Format(time.DateOnly),
+		Count:       b.Count,
+		BucketStats: BucketStats{Avg: round2(b.Avg), Min: round2(b.Min), Max: round2(b.Max)},
+		Sum:         round2(b.Sum),
+	}
+	if b.AvgDiastolic != nil && b.MinDiastolic != nil && b.MaxDiastolic != nil {
+		out.Diastolic = &BucketStats{
+			Avg: round2(*b.AvgDiastolic),
+			Min: round2(*b.MinDiastolic),
+			Max: round2(*b.MaxDiastolic),
+		}
+	}
+	return out
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,215 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMeasurementUnits(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name      string
+		req       MeasurementRequest
+		value     float64
+		diastolic float64
+	}{
+		{"kg by default", MeasurementRequest{Metric: MetricWeight, Value: f(80.25)}, 80.25, 0},
+		{"pounds", MeasurementRequest{Metric: MetricWeight, Value: f(150), Unit: "lb"}, 68.039, 0},
+		{"stone", MeasurementRequest{Metric: MetricWeight, Value: f(11), Unit: "st"}, 69.853, 0},
+		{"grams", MeasurementRequest{Metric: MetricWeight, Value: f(70500), Unit: "g"}, 70.5, 0},
+		{"upper bound", MeasurementRequest{Metric: MetricWeight, Value: f(700.0004)}, 700, 0},
+		{"sleep minutes", MeasurementRequest{Metric: MetricSleep, Value: f(450), Unit: "min"}, 7.5, 0},
+		{"no sleep", MeasurementRequest{Metric: MetricSleep, Value: f(0)}, 0, 0},
+		{"litres", MeasurementRequest{Metric: MetricWater, Value: f(2), Unit: "l"}, 2000, 0},
+		{"fluid ounces", MeasurementRequest{Metric: MetricWater, Value: f(8), Unit: "fl_oz"}, 236.588, 0},
+		{"cups", MeasurementRequest{Metric: MetricWater, Value: f(1), Unit: "cup"}, 236.588, 0},
+		{"steps are whole", MeasurementRequest{Metric: MetricSteps, Value: f(1234.6), Unit: "count"}, 1235, 0},
+		{"heart rate is whole", MeasurementRequest{Metric: MetricRestingHeartRate, Value: f(61.4)}, 61, 0},
+		{"blood pressure", MeasurementRequest{Metric: MetricBloodPressure, Value: f(121), Diastolic: f(79)}, 121, 79},
+		{"kilopascals", MeasurementRequest{Metric: MetricBloodPressure, Value: f(16), Diastolic: f(10.7), Unit: "kPa"}, 120, 80},
+	}
+	for _, tt := range tests {
+		m, err := tt.req.ToMeasurement("u1", now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if m.Value != tt.value {
+			t.Errorf("%s: value = %v, want %v", tt.name, m.Value, tt.value)
+		}
+		switch {
+		case tt.diastolic == 0 && m.Value2 != nil:
+			t.Errorf("%s: unexpected diastolic %v", tt.name, *m.Value2)
+		case tt.diastolic != 0 && (m.Value2 == nil || *m.Value2 != tt.diastolic):
+			t.Errorf("%s: diastolic = %v, want %v", tt.name, m.Value2, tt.diastolic)
+		}
+		if m.UserID != "u1" || m.Source != SourceManual || len(m.MeasurementID) != 36 || !m.MeasuredAt.Equal(now) {
+			t.Errorf("%s: measurement = %+v", tt.name, m)
+		}
+	}
+}
+
+func TestMeasurementRejects(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	f := func(v float64) *float64 { return &v }
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+	tests := []struct {
+		name string
+		req  MeasurementRequest
+		want string
+	}{
+		{"unknown metric", MeasurementRequest{Metric: "mood", Value: f(1)}, "metric must be one of"},
+		{"no value", MeasurementRequest{Metric: MetricWeight}, "value is required"},
+		{"unit of another metric", MeasurementRequest{Metric: MetricWeight, Value: f(1), Unit: "ml"}, "unit for weight must be one of g, kg, lb, st"},
+		{"below range", MeasurementRequest{Metric: MetricWeight, Value: f(0.5)}, "value must be between 1 and 700 kg"},
+		{"above range after conversion", MeasurementRequest{Metric: MetricWeight, Value: f(1600), Unit: "lb"}, "between 1 and 700"},
+		{"just above range", MeasurementRequest{Metric: MetricWeight, Value: f(700.001)}, "between 1 and 700"},
+		{"more sleep than a day", MeasurementRequest{Metric: MetricSleep, Value: f(1441), Unit: "min"}, "between 0 and 24"},
+		{"not a number", MeasurementRequest{Metric: MetricSteps, Value: f(math.NaN())}, "value must be a number"},
+		{"infinite", MeasurementRequest{Metric: MetricWater, Value: f(math.Inf(1))}, "value must be a number"},
+		{"no diastolic", MeasurementRequest{Metric: MetricBloodPressure, Value: f(120)}, "diastolic is required"},
+		{"diastolic on weight", MeasurementRequest{Metric: MetricWeight, Value: f(80), Diastolic: f(60)}, "only allowed for blood_pressure"},
+		{"diastolic above systolic", MeasurementRequest{Metric: MetricBloodPressure, Value: f(90), Diastolic: f(95)}, "below the systolic"},
+		{"diastolic out of range", MeasurementRequest{Metric: MetricBloodPressure, Value: f(120), Diastolic: f(15)}, "diastolic must be between 20 and 200"},
+		{"in the future", MeasurementRequest{Metric: MetricWeight, Value: f(80), MeasuredAt: at(6 * time.Minute)}, "in the future"},
+	}
+	for _, tt := range tests {
+		_, err := tt.req.ToMeasurement("u1", now)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	// a clock a few minutes ahead is tolerated, and times are kept in UTC
+	berlin := time.FixedZone("CEST", 2*3600)
+	ahead := now.Add(4 * time.Minute).In(berlin)
+	m, err := (&MeasurementRequest{Metric: MetricWeight, Value: f(80), MeasuredAt: &ahead}).ToMeasurement("u1", now)
+	if err != nil || !m.MeasuredAt.Equal(ahead) || m.MeasuredAt.Location() != time.UTC {
+		t.Errorf("reading 4 minutes ahead = %v, %v", m.MeasuredAt, err)
+	}
+}
+
+func TestNewAggregateQuery(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	newYork, _ := time.LoadLocation("America/New_York")
+	wednesday := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                   string
+		interval, from, to, tz string
+		now                    time.Time
+		wantFrom, wantTo       time.Time
+		wantInterval           string
+	}{
+		{
+			name: "last 30 days", now: wednesday,
+			wantFrom: time.Date(2024, 4, 16, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+			wantInterval: IntervalDay,
+		},
+		{
+			// 84 days before Thursday the 16th is a Thursday; the range
+			// goes back to the Monday before it
+			name: "last 12 weeks start on a Monday", interval: IntervalWeek, now: wednesday,
+			wantFrom: time.Date(2024, 2, 19, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+			wantInterval: IntervalWeek,
+		},
+		{
+			name: "today follows the zone", tz: "Europe/Berlin", now: time.Date(2024, 5, 15, 23, 30, 0, 0, time.UTC),
+			wantFrom: time.Date(2024, 4, 17, 0, 0, 0, 0, berlin), wantTo: time.Date(2024, 5, 17, 0, 0, 0, 0, berlin),
+			wantInterval: IntervalDay,
+		},
+		{
+			// the range crosses the switch to summer time; both ends stay
+			// on local midnight
+			name: "weeks across a DST change", interval: IntervalWeek, tz: "America/New_York", now: time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC),
+			wantFrom: time.Date(2023, 12, 25, 0, 0, 0, 0, newYork), wantTo: time.Date(2024, 3, 21, 0, 0, 0, 0, newYork),
+			wantInterval: IntervalWeek,
+		},
+		{
+			name: "a Sunday to ends on Monday", interval: IntervalWeek, to: "2024-05-12", now: wednesday,
+			wantFrom: time.Date(2024, 2, 19, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+			wantInterval: IntervalWeek,
+		},
+		{
+			name: "dates include the to day", from: "2024-05-01", to: "2024-05-07", tz: "Europe/Berlin", now: wednesday,
+			wantFrom: time.Date(2024, 5, 1, 0, 0, 0, 0, berlin), wantTo: time.Date(2024, 5, 8, 0, 0, 0, 0, berlin),
+			wantInterval: IntervalDay,
+		},
+		{
+			name: "a from is not moved to Monday", interval: IntervalWeek, from: "2024-05-01", now: wednesday,
+			wantFrom: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+			wantInterval: IntervalWeek,
+		},
+		{
+			name: "times are exact", from: "2024-05-01T06:00:00Z", to: "2024-05-02T06:00:00+02:00", now: wednesday,
+			wantFrom: time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 5, 2, 4, 0, 0, 0, time.UTC),
+			wantInterval: IntervalDay,
+		},
+		{
+			name: "400 days", from: "2023-01-01", to: "2024-02-04", now: wednesday,
+			wantFrom: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC),
+			wantInterval: IntervalDay,
+		},
+	}
+	for _, tt := range tests {
+		q, err := NewAggregateQuery(MetricWeight, tt.interval, tt.from, tt.to, tt.tz, tt.now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !q.From.Equal(tt.wantFrom) || !q.To.Equal(tt.wantTo) || q.Interval != tt.wantInterval {
+			t.Errorf("%s: [%v, %v) by %s, want [%v, %v) by %s", tt.name, q.From, q.To, q.Interval, tt.wantFrom, tt.wantTo, tt.wantInterval)
+		}
+		if tt.interval == IntervalWeek && tt.from == "" && q.From.Weekday() != time.Monday {
+			t.Errorf("%s: starts on %v", tt.name, q.From.Weekday())
+		}
+	}
+}
+
+func TestNewAggregateQueryRejects(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                           string
+		metric, interval, from, to, tz string
+		want                           string
+	}{
+		{"no metric", "", "", "", "", "", "metric must be one of"},
+		{"unknown metric", "mood", "", "", "", "", "metric must be one of"},
+		{"month", MetricWeight, "month", "", "", "", "interval must be day or week"},
+		{"unknown zone", MetricWeight, "", "", "", "Mars/Olympus", "tz must be an IANA time zone"},
+		{"server zone", MetricWeight, "", "", "", "Local", "tz must be an IANA time zone"},
+		{"bad from", MetricWeight, "", "May 1", "", "", "from must be a date"},
+		{"bad to", MetricWeight, "", "", "2024-13-01", "", "to must be a date"},
+		{"empty range", MetricWeight, "", "2024-05-02T00:00:00Z", "2024-05-02T00:00:00Z", "", "from must be before to"},
+		{"reversed", MetricWeight, "", "2024-05-03", "2024-05-01", "", "from must be before to"},
+		{"after the default end", MetricWeight, "", "2024-06-01", "", "", "from must be before to"},
+		{"401 days", MetricWeight, "", "2023-01-01", "2024-02-05", "", "at most 400 days"},
+		{"401 weeks", MetricWeight, IntervalWeek, "2016-01-04", "2023-09-04", "", "at most 400 weeks"},
+	}
+	for _, tt := range tests {
+		_, err := NewAggregateQuery(tt.metric, tt.interval, tt.from, tt.to, tt.tz, now)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestNewMeasurementQuery(t *testing.T) {
+	q, err := NewMeasurementQuery(MetricSteps, "2024-05-01", "2024-05-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.From.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || !q.To.Equal(time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("range = [%v, %v)", q.From, q.To)
+	}
+	if q, err := NewMeasurementQuery("", "", ""); err != nil || q.From != nil || q.To != nil || q.Metric != "" {
+		t.Errorf("open query = %+v, %v", q, err)
+	}
+	for _, bad := range [][3]string{{"mood", "", ""}, {"", "yesterday", ""}, {"", "", "2024-02-30"}, {"", "2024-05-08", "2024-05-07"}} {
+		if _, err := NewMeasurementQuery(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("NewMeasurementQuery%q succeeded", bad)
+		}
+	}
+}
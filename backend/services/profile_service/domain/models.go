@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -17,6 +18,10 @@ type Profile struct {
 	Name   string `gorm:"size:30" json:"name" validate:"name"`
 	Bio    string `gorm:"size:500" json:"bio,omitempty" validate:"max=500"`
 	Avatar string `json:"avatar,omitempty" validate:"avatar_url"`
+	// AvatarID names the uploaded picture, if any; a new upload gets a new
+	// ID so its URLs can be cached forever. AvatarExt is .jpg or .png.
+	AvatarID  string `gorm:"size:36" json:"-"`
+	AvatarExt string `gorm:"size:4" json:"-"`
 }
 
 // post/put requests
@@ -27,11 +32,13 @@ type ProfileRequest struct {
 }
 
 type PublicationResponse struct {
-	PostID    string    `json:"post_id"`
-	UserID    string    `json:"user_id"`
-	Title     string    `json:"title"` // Title of the post
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	PostID     string    `json:"post_id"`
+	UserID     string    `json:"user_id"`
+	Title      string    `json:"title"` // Title of the post
+	Content    string    `json:"content"`
+	Type       string    `json:"type"`       // text, workout or meal
+	Visibility string    `json:"visibility"` // public, followers, private or circle
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // ProfileResponse represents the profile data sent back to clients.
@@ -40,8 +47,21 @@ type ProfileResponse struct {
 	Name      string                `json:"name"`
 	Bio       string                `json:"bio"`
 	Avatar    string                `json:"avatar"`
+	Avatars   map[string]string     `json:"avatars,omitempty"` // thumbnail size -> URL, uploaded avatars only
 	CreatedAt time.Time             `json:"created_at"`
 	Posts     []PublicationResponse `json:"posts"`
+	// PostsNextCursor continues Posts via GET /feed/user/publications?cursor=
+	PostsNextCursor string `json:"posts_next_cursor,omitempty"`
+	// Badges are the achievements earned in feed_service
+	Badges []BadgeResponse `json:"badges"`
+}
+
+// BadgeResponse mirrors feed_service's badge
+type BadgeResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AwardedAt   time.Time `json:"awarded_at"`
 }
 
 // Converter
@@ -83,12 +103,17 @@ func validateAvatarURL(fl validator.FieldLevel) bool {
 	u, err := url.Parse(avatarURL)
 	return err == nil &&
 		(u.Scheme == "http" || u.Scheme == "https") &&
-		strings.HasSuffix(u.Path, ".jpg") ||
-		strings.HasSuffix(u.Path, ".png") ||
-		strings.HasSuffix(u.Path, ".jpeg")
+		(strings.HasSuffix(u.Path, ".jpg") ||
+			strings.HasSuffix(u.Path, ".png") ||
+			strings.HasSuffix(u.Path, ".jpeg"))
 }
 
 // Validate main structure
 func Validate(s interface{}) error {
 	return validate.Struct(s)
 }
+
+// NewUUID generates a new UUID string
+func NewUUID() string {
+	return uuid.New().String()
+}
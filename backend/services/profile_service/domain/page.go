@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the keyset position of the last item of a page. Measurements
+// are ordered by (measured_at, id) descending; id breaks ties between
+// readings taken in the same instant.
+type Cursor struct {
+	At time.Time
+	ID uint
+}
+
+// Encode makes the cursor opaque to clients
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.At.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a next_cursor value; an empty string means the first page
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{At: time.Unix(0, nanos).UTC(), ID: uint(n)}, nil
+}
+
+// PageRequest is a parsed ?limit=&cursor= pair
+type PageRequest struct {
+	Limit int
+	After *Cursor
+}
+
+// NewPageRequest validates query parameters, applying the default and max limit
+func NewPageRequest(limit, cursor string) (PageRequest, error) {
+	p := PageRequest{Limit: DefaultPageLimit}
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return PageRequest{}, errors.New("limit must be a positive integer")
+		}
+		p.Limit = min(n, MaxPageLimit)
+	}
+	after, err := DecodeCursor(cursor)
+	if err != nil {
+		return PageRequest{}, err
+	}
+	p.After = after
+	return p, nil
+}
+
+// Page is one slice of a list; NextCursor is empty on the last page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
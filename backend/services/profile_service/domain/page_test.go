@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, c := range []Cursor{
+		{At: time.Date(2024, 5, 15, 10, 0, 0, 123456789, time.UTC), ID: 42},
+		{At: time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC), ID: 0},
+		{At: time.Date(2024, 5, 15, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600)), ID: 1<<63 - 1},
+	} {
+		got, err := DecodeCursor(c.Encode())
+		if err != nil || got == nil || !got.At.Equal(c.At) || got.ID != c.ID || got.At.Location() != time.UTC {
+			t.Errorf("round trip of %+v = %+v, %v", c, got, err)
+		}
+	}
+	if c, err := DecodeCursor(""); c != nil || err != nil {
+		t.Errorf("empty cursor = %+v, %v; want the first page", c, err)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for name, s := range map[string]string{
+		"not base64":  "%%%",
+		"no colon":    enc("1715767200000000000"),
+		"bad time":    enc("yesterday:1"),
+		"bad id":      enc("1715767200000000000:x"),
+		"negative id": enc("1715767200000000000:-1"),
+		"empty parts": enc(":"),
+	} {
+		if c, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("%s: DecodeCursor = %+v, %v; want ErrInvalidCursor", name, c, err)
+		}
+	}
+}
+
+func TestNewPageRequest(t *testing.T) {
+	cursor := Cursor{At: time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC), ID: 7}
+	tests := []struct {
+		limit, cursor string
+		want          int
+		after         *Cursor
+	}{
+		{"", "", DefaultPageLimit, nil},
+		{"1", "", 1, nil},
+		{"120", cursor.Encode(), 120, &cursor},
+		{"100000", "", MaxPageLimit, nil},
+	}
+	for _, tt := range tests {
+		p, err := NewPageRequest(tt.limit, tt.cursor)
+		if err != nil {
+			t.Errorf("NewPageRequest(%q, %q): %v", tt.limit, tt.cursor, err)
+			continue
+		}
+		if p.Limit != tt.want || (p.After == nil) != (tt.after == nil) || p.After != nil && *p.After != *tt.after {
+			t.Errorf("NewPageRequest(%q, %q) = %+v, want limit %d after %+v", tt.limit, tt.cursor, p, tt.want, tt.after)
+		}
+	}
+	for _, limit := range []string{"0", "-5", "ten", "1.5"} {
+		if _, err := NewPageRequest(limit, ""); err == nil {
+			t.Errorf("limit %q accepted", limit)
+		}
+	}
+	if _, err := NewPageRequest("10", "garbage!"); err != ErrInvalidCursor {
+		t.Errorf("bad cursor: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -47,7 +47,17 @@ func (r *pgProfileRepo) GetByUserID(userID string) (*domain.Profile, error) {
 }
 
 func (r *pgProfileRepo) Update(p *domain.Profile) error {
-	return r.db.Save(p).Error
+	return r.db.Omit("avatar", "avatar_id", "avatar_ext").Save(p).Error
+}
+
+// ReplaceAvatar compares and sets in one statement, so of two concurrent
+// changes only one sees the avatar it read. Rows from before uploads
+// existed hold NULL.
+func (r *pgProfileRepo) ReplaceAvatar(userID, oldID, avatarID, ext, link string) (bool, error) {
+	res := r.db.Model(&domain.Profile{}).
+		Where("user_id = ? AND COALESCE(avatar_id, '') = ?", userID, oldID).
+		Updates(map[string]any{"avatar": link, "avatar_id": avatarID, "avatar_ext": ext})
+	return res.RowsAffected > 0, res.Error
 }
 
 func (r *pgProfileRepo) Delete(userID string) error {
@@ -98,7 +108,7 @@ func InitDB(dbConfig config.DBConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("%w: %v", repository.ErrDBConnection, err)
 	}
 
-	if err := db.AutoMigrate(&domain.Profile{}); err != nil {
+	if err := db.AutoMigrate(&domain.Profile{}, &domain.Measurement{}, &domain.ImportJob{}); err != nil {
 		return nil, fmt.Errorf("%w: %v", repository.ErrDBMigration, err)
 	}
 
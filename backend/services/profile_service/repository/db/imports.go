@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"profile_service/domain"
+	repository "profile_service/repository"
+
+	"gorm.io/gorm"
+)
+
+type pgImportRepo struct {
+	db *gorm.DB
+}
+
+func NewImportRepo(db *gorm.DB) repository.ImportRepository {
+	return &pgImportRepo{db: db}
+}
+
+func (r *pgImportRepo) Create(ctx context.Context, job *domain.ImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *pgImportRepo) Get(ctx context.Context, importID string) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	err := r.db.WithContext(ctx).Where("import_id = ?", importID).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrImportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Update writes every field of an existing job; unlike Save it never
+// inserts, so a job deleted with its profile stays deleted
+func (r *pgImportRepo) Update(ctx context.Context, job *domain.ImportJob) error {
+	return r.db.WithContext(ctx).Model(job).Select("*").Omit("created_at").Updates(job).Error
+}
+
+func (r *pgImportRepo) List(ctx context.Context, userID string, limit int) ([]domain.ImportJob, error) {
+	var jobs []domain.ImportJob
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *pgImportRepo) Unfinished(ctx context.Context) ([]domain.ImportJob, error) {
+	var jobs []domain.ImportJob
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []string{domain.ImportQueued, domain.ImportRunning}).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *pgImportRepo) DeleteByUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Where("user_id = ?", userID).
+		Delete(&domain.ImportJob{}).
+		Error
+}
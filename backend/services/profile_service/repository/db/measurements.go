@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"profile_service/domain"
+	repository "profile_service/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type pgMeasurementRepo struct {
+	db *gorm.DB
+}
+
+func NewMeasurementRepo(db *gorm.DB) repository.MeasurementRepository {
+	return &pgMeasurementRepo{db: db}
+}
+
+func (r *pgMeasurementRepo) Create(ctx context.Context, m *domain.Measurement) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *pgMeasurementRepo) Get(ctx context.Context, measurementID string) (*domain.Measurement, error) {
+	var m domain.Measurement
+	err := r.db.WithContext(ctx).Where("measurement_id = ?", measurementID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrMeasurementNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *pgMeasurementRepo) List(ctx context.Context, userID string, q domain.MeasurementQuery, page domain.PageRequest) ([]domain.Measurement, error) {
+	tx := r.series(ctx, userID, q.Metric, q.From, q.To)
+	if page.After != nil {
+		tx = tx.Where("(measured_at, id) < (?, ?)", page.After.At, page.After.ID)
+	}
+	var out []domain.Measurement
+	err := tx.Order("measured_at DESC, id DESC").Limit(page.Limit).Find(&out).Error
+	return out, err
+}
+
+func (r *pgMeasurementRepo) Aggregate(ctx context.Context, userID string, q domain.AggregateQuery) ([]domain.MeasurementBucket, error) {
+	var out []domain.MeasurementBucket
+	// measured_at is stored in UTC; shifting it to the caller's zone
+	// before truncating puts readings on their local calendar day
+	err := r.series(ctx, userID, q.Metric, &q.From, &q.To).
+		Select(`date_trunc(?, measured_at AT TIME ZONE ?) AS start,
+			count(*) AS count,
+			avg(value) AS avg, min(value) AS min, max(value) AS max, sum(value) AS sum,
+			avg(value2) AS avg_diastolic, min(value2) AS min_diastolic, max(value2) AS max_diastolic`,
+			q.Interval, q.TZ).
+		Group("1").
+		Order("1").
+		Scan(&out).Error
+	return out, err
+}
+
+// series narrows the measurements table to one user's readings of a
+// metric, or of all metrics, within [from, to)
+func (r *pgMeasurementRepo) series(ctx context.Context, userID, metric string, from, to *time.Time) *gorm.DB {
+	tx := r.db.WithContext(ctx).Model(&domain.Measurement{}).Where("user_id = ?", userID)
+	if metric != "" {
+		tx = tx.Where("metric = ?", metric)
+	}
+	if from != nil {
+		tx = tx.Where("measured_at >= ?", *from)
+	}
+	if to != nil {
+		tx = tx.Where("measured_at < ?", *to)
+	}
+	return tx
+}
+
+func (r *pgMeasurementRepo) Delete(ctx context.Context, measurementID string) error {
+	res := r.db.WithContext(ctx).
+		Unscoped().
+		Where("measurement_id = ?", measurementID).
+		Delete(&domain.Measurement{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repository.ErrMeasurementNotFound
+	}
+	return nil
+}
+
+func (r *pgMeasurementRepo) DeleteByUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Where("user_id = ?", userID).
+		Delete(&domain.Measurement{}).
+		Error
+}
+
+func (r *pgMeasurementRepo) Upsert(ctx context.Context, ms []domain.Measurement) (int64, error) {
+	if len(ms) == 0 {
+		return 0, nil
+	}
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "external_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "value2", "measured_at", "updated_at"}),
+			// rows that did not change are left alone and not counted
+			Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+				SQL: "measurements.value IS DISTINCT FROM excluded.value OR measurements.value2 IS DISTINCT FROM excluded.value2",
+			}}},
+		}).
+		Create(&ms)
+	return res.RowsAffected, res.Error
+}
@@ -1,9 +1,17 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+
+	"blob"
+)
 
 var (
-	ErrNotFound     = errors.New("Profile not found")
-	ErrDBConnection = errors.New("DB connection failed")
-	ErrDBMigration  = errors.New("DB migrations failed")
+	ErrNotFound            = errors.New("Profile not found")
+	ErrMeasurementNotFound = errors.New("measurement not found")
+	ErrImportNotFound      = errors.New("import not found")
+	ErrDBConnection        = errors.New("DB connection failed")
+	ErrDBMigration         = errors.New("DB migrations failed")
+	// ErrBlobNotFound is what BlobStore.Get returns for a missing key
+	ErrBlobNotFound = blob.ErrNotFound
 )
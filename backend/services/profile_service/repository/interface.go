@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"blob"
 	"context"
 	"profile_service/domain"
 )
@@ -8,8 +9,48 @@ import (
 type ProfileRepository interface {
 	Create(profile *domain.Profile) error
 	GetByUserID(userID string) (*domain.Profile, error)
+	// Update saves everything but the avatar, which only changes through
+	// ReplaceAvatar
 	Update(profile *domain.Profile) error
+	// ReplaceAvatar points the profile at an uploaded avatar (avatarID and
+	// ext) or a linked one (link), but only while its uploaded avatar is
+	// still oldID; it reports whether the profile was changed
+	ReplaceAvatar(userID, oldID, avatarID, ext, link string) (bool, error)
 	Delete(userID string) error
 	List() ([]domain.Profile, error)
 	Health(ctx context.Context) error
 }
+
+// MeasurementRepository stores health readings. Get returns
+// ErrMeasurementNotFound for an unknown ID.
+type MeasurementRepository interface {
+	Create(ctx context.Context, m *domain.Measurement) error
+	Get(ctx context.Context, measurementID string) (*domain.Measurement, error)
+	// List returns the user's readings newest first
+	List(ctx context.Context, userID string, q domain.MeasurementQuery, page domain.PageRequest) ([]domain.Measurement, error)
+	// Aggregate groups the user's readings into day or week buckets, oldest first
+	Aggregate(ctx context.Context, userID string, q domain.AggregateQuery) ([]domain.MeasurementBucket, error)
+	Delete(ctx context.Context, measurementID string) error
+	DeleteByUser(ctx context.Context, userID string) error
+	// Upsert stores imported readings keyed by (user_id, external_id),
+	// updating ones whose value changed; it returns how many rows were
+	// added or changed
+	Upsert(ctx context.Context, ms []domain.Measurement) (int64, error)
+}
+
+// ImportRepository stores import jobs. Get returns ErrImportNotFound for
+// an unknown ID.
+type ImportRepository interface {
+	Create(ctx context.Context, job *domain.ImportJob) error
+	Get(ctx context.Context, importID string) (*domain.ImportJob, error)
+	Update(ctx context.Context, job *domain.ImportJob) error
+	// List returns the user's latest jobs, newest first
+	List(ctx context.Context, userID string, limit int) ([]domain.ImportJob, error)
+	// Unfinished returns the queued and running jobs of all users
+	Unfinished(ctx context.Context) ([]domain.ImportJob, error)
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+// BlobStore keeps uploaded files by key, see the shared blob module.
+// Get returns ErrBlobNotFound for a missing key.
+type BlobStore = blob.Store
@@ -0,0 +1,195 @@
+// Package avatar turns an uploaded picture into the square thumbnails a
+// profile is shown with.
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Sizes are the edge lengths, in pixels, of the generated thumbnails;
+// the first one is the main picture
+var Sizes = []int{512, 256, 128, 64}
+
+// maxPixels guards against decompression bombs: small files that
+// declare huge dimensions
+const maxPixels = 50_000_000
+
+var (
+	ErrUnsupported = errors.New("only JPEG, PNG and WebP images are accepted")
+	ErrInvalid     = errors.New("invalid image")
+)
+
+// Config holds the upload limit and where avatars are served from
+type Config struct {
+	URLPrefix string
+	MaxBytes  int64
+}
+
+// Variant is one encoded thumbnail
+type Variant struct {
+	Size        int
+	ContentType string
+	Data        []byte
+}
+
+// Extension is the file extension of the variants, .jpg or .png
+func (v Variant) Extension() string {
+	if v.ContentType == "image/png" {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// Process decodes a JPEG, PNG or WebP picture, crops its centre to a
+// square, turns it upright according to its EXIF orientation and scales
+// it to every size in Sizes. Opaque pictures are encoded as JPEG, the
+// others as PNG to keep transparency. Metadata is not carried over.
+func Process(data []byte) ([]Variant, error) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/webp":
+	default:
+		return nil, ErrUnsupported
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrInvalid, cfg.Width, cfg.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	orientation := jpegOrientation(data)
+
+	// the centred square is the same whichever way the picture is turned
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0, y0 := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+
+	out := make([]Variant, 0, len(Sizes))
+	opaque := true
+	for i, size := range Sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, xdraw.Src, nil)
+		dst = orient(dst, orientation)
+		if i == 0 {
+			opaque = dst.Opaque()
+		}
+		var buf bytes.Buffer
+		v := Variant{Size: size, ContentType: "image/jpeg"}
+		if opaque {
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		} else {
+			v.ContentType = "image/png"
+			err = png.Encode(&buf, dst)
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.Data = buf.Bytes()
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// jpegOrientation reads the EXIF orientation of a JPEG, 1 (upright) for
+// other formats or when it is missing
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xff; {
+		marker := data[pos+1]
+		if marker == 0xda || marker == 0xd9 { // image data starts, no EXIF before it
+			return 1
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+		if end > len(data) {
+			return 1
+		}
+		if body := data[pos+4 : end]; marker == 0xe1 && bytes.HasPrefix(body, []byte("Exif\x00\x00")) {
+			return exifOrientation(body[6:])
+		}
+		pos = end
+	}
+	return 1
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of a TIFF-structured EXIF block
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 0 || ifd+2 > len(tiff) {
+		return 1
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orient applies an EXIF orientation to a square image
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation == 1 {
+		return src
+	}
+	n := src.Bounds().Dx()
+	dst := image.NewRGBA(src.Bounds())
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = n-1-x, y
+			case 3: // rotated 180
+				dx, dy = n-1-x, n-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, n-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = n-1-y, x
+			case 7: // transversed
+				dx, dy = n-1-y, n-1-x
+			case 8: // rotated 90 counter-clockwise
+				dx, dy = y, n-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,247 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+)
+
+// tinyWebP is a lossless 1x1 WebP
+var tinyWebP, _ = base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+func TestProcessCropsTheCentre(t *testing.T) {
+	// three bands across the long side; only the red middle one is square
+	wide := bands(300, 100, func(x, y int) int { return x / 100 })
+	tall := bands(100, 300, func(x, y int) int { return y / 100 })
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"wide jpeg", encodeJPEG(t, wide)},
+		{"tall png", encodePNG(t, tall)},
+	}
+	for _, tt := range tests {
+		variants, err := Process(tt.data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, v := range variants {
+			img := decode(t, v)
+			for _, p := range samplePoints(v.Size) {
+				if c := img.At(p.X, p.Y); !near(c, red) {
+					t.Errorf("%s, %dpx: %v at %v, want red", tt.name, v.Size, c, p)
+				}
+			}
+		}
+	}
+}
+
+func TestProcessSizes(t *testing.T) {
+	variants, err := Process(encodeJPEG(t, bands(640, 480, func(x, y int) int { return 1 })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != len(Sizes) {
+		t.Fatalf("got %d variants, want %d", len(variants), len(Sizes))
+	}
+	for i, v := range variants {
+		if v.Size != Sizes[i] || v.ContentType != "image/jpeg" || v.Extension() != ".jpg" {
+			t.Errorf("variant %d = %d %s %s, want %d image/jpeg .jpg", i, v.Size, v.ContentType, v.Extension(), Sizes[i])
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(v.Data))
+		if err != nil || format != "jpeg" || cfg.Width != v.Size || cfg.Height != v.Size {
+			t.Errorf("variant %d decodes as %s %dx%d, %v", i, format, cfg.Width, cfg.Height, err)
+		}
+	}
+}
+
+func TestProcessKeepsTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 32; x++ {
+			img.Set(x, y, red)
+		}
+	}
+	variants, err := Process(encodePNG(t, img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range variants {
+		if v.ContentType != "image/png" || v.Extension() != ".png" {
+			t.Errorf("%dpx variant is %s", v.Size, v.ContentType)
+			continue
+		}
+		if _, _, _, a := decode(t, v).At(v.Size-2, v.Size/2).RGBA(); a != 0 {
+			t.Errorf("%dpx variant lost its transparency", v.Size)
+		}
+	}
+}
+
+func TestProcessWebP(t *testing.T) {
+	src, err := webp.Decode(bytes.NewReader(tinyWebP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants, err := Process(tinyWebP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != len(Sizes) {
+		t.Fatalf("got %d variants, want %d", len(variants), len(Sizes))
+	}
+	for _, v := range variants {
+		img := decode(t, v)
+		if b := img.Bounds(); b.Dx() != v.Size || b.Dy() != v.Size {
+			t.Errorf("variant is %v, want %dpx", b, v.Size)
+		}
+		if c := img.At(v.Size/2, v.Size/2); !near(c, src.At(0, 0)) {
+			t.Errorf("%dpx variant is %v, want the colour of the pixel %v", v.Size, c, src.At(0, 0))
+		}
+	}
+}
+
+func TestProcessOrientation(t *testing.T) {
+	// red on the left, blue on the right; the EXIF orientation says where
+	// the camera had its left
+	plain := encodeJPEG(t, bands(64, 64, func(x, y int) int {
+		if x < 32 {
+			return 1
+		}
+		return 2
+	}))
+	tests := []struct {
+		orientation int
+		red, blue   image.Point // in the 64px variant
+	}{
+		{1, image.Pt(16, 32), image.Pt(48, 32)},
+		{3, image.Pt(48, 32), image.Pt(16, 32)},
+		{6, image.Pt(32, 16), image.Pt(32, 48)},
+		{8, image.Pt(32, 48), image.Pt(32, 16)},
+	}
+	for _, tt := range tests {
+		variants, err := Process(withOrientation(plain, tt.orientation))
+		if err != nil {
+			t.Fatal(err)
+		}
+		small := decode(t, variants[len(variants)-1])
+		if c := small.At(tt.red.X, tt.red.Y); !near(c, red) {
+			t.Errorf("orientation %d: %v at %v, want red", tt.orientation, c, tt.red)
+		}
+		if c := small.At(tt.blue.X, tt.blue.Y); !near(c, blue) {
+			t.Errorf("orientation %d: %v at %v, want blue", tt.orientation, c, tt.blue)
+		}
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	plain := encodeJPEG(t, bands(32, 32, func(x, y int) int { return 1 }))
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrUnsupported},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00"), ErrUnsupported},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), ErrUnsupported},
+		{"truncated jpeg", plain[:len(plain)/2], ErrInvalid},
+		{"jpeg header only", plain[:20], ErrInvalid},
+		{"png bomb", pngHeader(10000, 10000), ErrInvalid},
+		{"bad webp", append(bytes.Clone(tinyWebP[:16]), "garbage..."...), ErrInvalid},
+	}
+	for _, tt := range tests {
+		if _, err := Process(tt.data); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// bands paints an image with green (0), red (1) and blue (2) regions
+func bands(w, h int, band func(x, y int) int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	colours := []color.RGBA{green, red, blue}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, colours[band(x, y)])
+		}
+	}
+	return img
+}
+
+// samplePoints are the centre and points a tenth in from each corner
+func samplePoints(size int) []image.Point {
+	in, out := size/10, size-1-size/10
+	return []image.Point{{size / 2, size / 2}, {in, in}, {out, in}, {in, out}, {out, out}}
+}
+
+func near(a, b color.Color) bool {
+	r1, g1, b1, _ := a.RGBA()
+	r2, g2, b2, _ := b.RGBA()
+	d := func(x, y uint32) bool { return x-y < 0x2000 || y-x < 0x2000 }
+	return d(r1, r2) && d(g1, g2) && d(b1, b2)
+}
+
+func decode(t *testing.T, v Variant) image.Image {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(v.Data))
+	if err != nil {
+		t.Fatalf("decode %dpx variant: %v", v.Size, err)
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation puts an EXIF segment with the orientation after the SOI
+func withOrientation(plain []byte, orientation int) []byte {
+	tiff := []byte("MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00")
+	binary.BigEndian.PutUint16(tiff[18:], uint16(orientation))
+	body := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(body)+2))
+	out := append([]byte{0xff, 0xd8}, seg...)
+	out = append(out, body...)
+	return append(out, plain[2:]...)
+}
+
+// pngHeader is the start of a PNG declaring w x h pixels
+func pngHeader(w, h uint32) []byte {
+	ihdr := []byte("IHDR")
+	ihdr = binary.BigEndian.AppendUint32(ihdr, w)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, h)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = binary.BigEndian.AppendUint32(out, 13)
+	out = append(out, ihdr...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(ihdr))
+}
@@ -0,0 +1,312 @@
+package healthimport
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"profile_service/domain"
+)
+
+// appleTime is the layout of startDate and endDate in export.xml
+const appleTime = "2006-01-02 15:04:05 -0700"
+
+// record types of export.xml we import
+const (
+	appleBodyMass    = "HKQuantityTypeIdentifierBodyMass"
+	appleRestingHR   = "HKQuantityTypeIdentifierRestingHeartRate"
+	appleStepCount   = "HKQuantityTypeIdentifierStepCount"
+	appleWater       = "HKQuantityTypeIdentifierDietaryWater"
+	appleSleep       = "HKCategoryTypeIdentifierSleepAnalysis"
+	appleSystolic    = "HKQuantityTypeIdentifierBloodPressureSystolic"
+	appleDiastolic   = "HKQuantityTypeIdentifierBloodPressureDiastolic"
+	appleHeartRate   = "HKQuantityTypeIdentifierHeartRate"
+	appleActiveKcal  = "HKQuantityTypeIdentifierActiveEnergyBurned"
+	appleDistancePfx = "HKQuantityTypeIdentifierDistance"
+)
+
+// appleTypes are the records imported as measurements
+var appleTypes = map[string]bool{
+	appleBodyMass: true, appleRestingHR: true, appleStepCount: true, appleWater: true,
+	appleSleep: true, appleSystolic: true, appleDiastolic: true,
+}
+
+// appleWaterML converts the volume units of Apple Health to millilitres
+var appleWaterML = map[string]float64{
+	"mL": 1, "cL": 10, "dL": 100, "L": 1000,
+	"fl_oz_us": 29.5735295625, "fl_oz_imp": 28.4130625,
+	"cup_us": 236.5882365, "cup_imp": 284.130625,
+}
+
+// appleMeters converts distance units to metres
+var appleMeters = map[string]float64{
+	"m": 1, "km": 1000, "mi": 1609.344, "yd": 0.9144, "ft": 0.3048,
+}
+
+// appleActivities maps HKWorkoutActivityType names to feed activities;
+// everything else is "other"
+var appleActivities = map[string]string{
+	"Running":                     domain.ActivityRun,
+	"Walking":                     domain.ActivityWalk,
+	"Cycling":                     domain.ActivityRide,
+	"HandCycling":                 domain.ActivityRide,
+	"Swimming":                    domain.ActivitySwim,
+	"Hiking":                      domain.ActivityHike,
+	"TraditionalStrengthTraining": domain.ActivityStrength,
+	"FunctionalStrengthTraining":  domain.ActivityStrength,
+	"CrossTraining":               domain.ActivityStrength,
+	"Yoga":                        domain.ActivityYoga,
+}
+
+// appleEntry finds export.xml in an export.zip
+func appleEntry(zr *zip.Reader) *zip.File {
+	for _, f := range zr.File {
+		if path.Base(f.Name) == "export.xml" {
+			return f
+		}
+	}
+	return nil
+}
+
+func (p *parser) appleZip(zr *zip.Reader) error {
+	f := appleEntry(zr)
+	if f == nil {
+		return ErrUnsupported
+	}
+	p.progress.BytesTotal.Store(int64(f.UncompressedSize64))
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return p.apple(p.count(rc))
+}
+
+// apple walks export.xml one element at a time
+func (p *parser) apple(r io.Reader) error {
+	d := xml.NewDecoder(r)
+	bp := map[string]*bloodPressure{}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read export.xml: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "Record":
+			// most records are samples we skip, such as heart rate
+			if appleTypes[attr(se, "type")] {
+				err = p.appleRecord(attrs(se), bp)
+			}
+		case "Workout":
+			err = p.appleWorkout(d, se)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// bloodPressure pairs the two halves of a reading, which export.xml keeps
+// as separate records with the same start time
+type bloodPressure struct {
+	systolic, diastolic *float64
+}
+
+func (p *parser) appleRecord(a map[string]string, bp map[string]*bloodPressure) error {
+	typ := a["type"]
+	start, err := time.Parse(appleTime, a["startDate"])
+	if err != nil {
+		return nil
+	}
+	device := a["sourceName"]
+
+	// sleep is a category: the value names the stage, the length is the time
+	if typ == appleSleep {
+		end, err := time.Parse(appleTime, a["endDate"])
+		if err != nil || !strings.HasPrefix(a["value"], "HKCategoryValueSleepAnalysisAsleep") {
+			return nil
+		}
+		// a night counts towards the day it ends
+		p.add(domain.MetricSleep, device, end, end.Sub(start).Hours())
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(a["value"], 64)
+	if err != nil {
+		return nil
+	}
+	switch typ {
+	case appleBodyMass:
+		return p.reading(domain.MetricWeight, start, value, a["unit"], nil)
+	case appleRestingHR:
+		return p.reading(domain.MetricRestingHeartRate, start, value, "bpm", nil)
+	case appleStepCount:
+		p.add(domain.MetricSteps, device, start, value)
+	case appleWater:
+		if ml, ok := appleWaterML[a["unit"]]; ok {
+			p.add(domain.MetricWater, device, start, value*ml)
+		}
+	case appleSystolic, appleDiastolic:
+		key := a["startDate"]
+		r := bp[key]
+		if r == nil {
+			r = &bloodPressure{}
+			bp[key] = r
+		}
+		if typ == appleSystolic {
+			r.systolic = &value
+		} else {
+			r.diastolic = &value
+		}
+		if r.systolic != nil && r.diastolic != nil {
+			delete(bp, key)
+			return p.reading(domain.MetricBloodPressure, start, *r.systolic, "mmHg", r.diastolic)
+		}
+	}
+	return nil
+}
+
+// appleWorkout reads a Workout element and its statistics. Older exports
+// keep totals in attributes, newer ones in WorkoutStatistics children.
+func (p *parser) appleWorkout(d *xml.Decoder, se xml.StartElement) error {
+	a := attrs(se)
+	w := domain.WorkoutDetails{Activity: domain.ActivityOther}
+	if act, ok := appleActivities[strings.TrimPrefix(a["workoutActivityType"], "HKWorkoutActivityType")]; ok {
+		w.Activity = act
+	}
+	start, startErr := time.Parse(appleTime, a["startDate"])
+	end, endErr := time.Parse(appleTime, a["endDate"])
+
+	duration := appleDuration(a["duration"], a["durationUnit"])
+	if duration == 0 && startErr == nil && endErr == nil {
+		duration = end.Sub(start)
+	}
+	w.DurationSeconds = int(duration.Round(time.Second) / time.Second)
+	if m, ok := appleDistance(a["totalDistance"], a["totalDistanceUnit"]); ok {
+		w.DistanceMeters = &m
+	}
+	if kcal, ok := appleEnergy(a["totalEnergyBurned"], a["totalEnergyBurnedUnit"]); ok {
+		w.Calories = &kcal
+	}
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return fmt.Errorf("read export.xml: %w", err)
+		}
+		if ee, ok := tok.(xml.EndElement); ok && ee.Name.Local == "Workout" {
+			break
+		}
+		child, ok := tok.(xml.StartElement)
+		if !ok || child.Name.Local != "WorkoutStatistics" {
+			continue
+		}
+		s := attrs(child)
+		switch typ := s["type"]; {
+		case strings.HasPrefix(typ, appleDistancePfx) && w.DistanceMeters == nil:
+			if m, ok := appleDistance(s["sum"], s["unit"]); ok {
+				w.DistanceMeters = &m
+			}
+		case typ == appleActiveKcal && w.Calories == nil:
+			if kcal, ok := appleEnergy(s["sum"], s["unit"]); ok {
+				w.Calories = &kcal
+			}
+		case typ == appleHeartRate:
+			w.HeartRate = appleHeartRateSummary(s)
+		}
+	}
+
+	if startErr != nil || w.DurationSeconds <= 0 {
+		return nil
+	}
+	p.progress.Records.Add(1)
+	return p.sink.Workout(Workout{
+		Key:     fmt.Sprintf("%s/workout/%d", p.source, start.Unix()),
+		Start:   start,
+		Details: w,
+	})
+}
+
+func appleDuration(value, unit string) time.Duration {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v <= 0 {
+		return 0
+	}
+	switch unit {
+	case "s":
+		return time.Duration(v * float64(time.Second))
+	case "h", "hr":
+		return time.Duration(v * float64(time.Hour))
+	}
+	return time.Duration(v * float64(time.Minute))
+}
+
+func appleDistance(value, unit string) (float64, bool) {
+	v, err := strconv.ParseFloat(value, 64)
+	factor, ok := appleMeters[unit]
+	if err != nil || !ok || v <= 0 {
+		return 0, false
+	}
+	return v * factor, true
+}
+
+func appleEnergy(value, unit string) (int, bool) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	switch unit {
+	case "kcal", "Cal":
+	case "kJ":
+		v /= 4.184
+	default:
+		return 0, false
+	}
+	return int(v + 0.5), true
+}
+
+func appleHeartRateSummary(s map[string]string) *domain.HeartRateSummary {
+	avg, err := strconv.ParseFloat(s["average"], 64)
+	if err != nil {
+		return nil
+	}
+	hr := &domain.HeartRateSummary{Avg: int(avg + 0.5)}
+	if v, err := strconv.ParseFloat(s["minimum"], 64); err == nil {
+		n := int(v + 0.5)
+		hr.Min = &n
+	}
+	if v, err := strconv.ParseFloat(s["maximum"], 64); err == nil {
+		n := int(v + 0.5)
+		hr.Max = &n
+	}
+	return hr
+}
+
+func attr(se xml.StartElement, name string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func attrs(se xml.StartElement) map[string]string {
+	m := make(map[string]string, len(se.Attr))
+	for _, a := range se.Attr {
+		m[a.Name.Local] = a.Value
+	}
+	return m
+}
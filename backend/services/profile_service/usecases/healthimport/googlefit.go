@@ -0,0 +1,327 @@
+package healthimport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"profile_service/domain"
+)
+
+// data types of the Takeout "All Data" folder we import
+const (
+	fitWeight        = "com.google.weight"
+	fitSteps         = "com.google.step_count.delta"
+	fitHydration     = "com.google.hydration"
+	fitSleep         = "com.google.sleep.segment"
+	fitBloodPressure = "com.google.blood_pressure"
+)
+
+var fitTypes = []string{fitWeight, fitSteps, fitHydration, fitSleep, fitBloodPressure}
+
+// fitAsleep are the com.google.sleep.segment stages spent asleep: sleep,
+// light, deep and REM
+var fitAsleep = map[int64]bool{2: true, 4: true, 5: true, 6: true}
+
+// fitActivities maps session activities to feed activities; sessions that
+// are not exercise, such as sleep, are left out
+var fitActivities = map[string]string{
+	"running":             domain.ActivityRun,
+	"jogging":             domain.ActivityRun,
+	"running.treadmill":   domain.ActivityRun,
+	"walking":             domain.ActivityWalk,
+	"walking.fitness":     domain.ActivityWalk,
+	"walking.treadmill":   domain.ActivityWalk,
+	"biking":              domain.ActivityRide,
+	"biking.road":         domain.ActivityRide,
+	"biking.mountain":     domain.ActivityRide,
+	"biking.stationary":   domain.ActivityRide,
+	"swimming":            domain.ActivitySwim,
+	"swimming.pool":       domain.ActivitySwim,
+	"swimming.open_water": domain.ActivitySwim,
+	"hiking":              domain.ActivityHike,
+	"strength_training":   domain.ActivityStrength,
+	"weightlifting":       domain.ActivityStrength,
+	"crossfit":            domain.ActivityStrength,
+	"yoga":                domain.ActivityYoga,
+}
+
+var fitNotExercise = map[string]bool{
+	"sleep": true, "still": true, "in_vehicle": true, "tilting": true, "unknown": true, "on_foot": true,
+}
+
+// fitEntries picks the files of a Takeout to import. Takeout keeps both the
+// raw data of every app and Fit's merged view of it; for each data type
+// only the most processed files are read, so no record counts twice.
+func fitEntries(zr *zip.Reader) []*zip.File {
+	best := map[string]int{}
+	files := map[string][]*zip.File{}
+	var sessions []*zip.File
+	for _, f := range zr.File {
+		dir, name := path.Base(path.Dir(f.Name)), path.Base(f.Name)
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if strings.EqualFold(dir, "All Sessions") {
+			sessions = append(sessions, f)
+			continue
+		}
+		if !strings.EqualFold(dir, "All Data") {
+			continue
+		}
+		typ, rank := fitFileType(name)
+		if typ == "" {
+			continue
+		}
+		switch r, seen := best[typ]; {
+		case !seen || rank < r:
+			best[typ] = rank
+			files[typ] = []*zip.File{f}
+		case rank == r:
+			files[typ] = append(files[typ], f)
+		}
+	}
+	var out []*zip.File
+	for _, typ := range fitTypes {
+		out = append(out, files[typ]...)
+	}
+	return append(out, sessions...)
+}
+
+// fitFileType reads the data type from names such as
+// derived_com.google.weight_com.google.android.gms_merge_weight.json;
+// merged files rank first, then other derived ones, then raw ones
+func fitFileType(name string) (string, int) {
+	rest, rank := "", 0
+	switch {
+	case strings.HasPrefix(name, "derived_"):
+		rest = strings.TrimPrefix(name, "derived_")
+		if !strings.Contains(rest, "merge") {
+			rank = 1
+		}
+	case strings.HasPrefix(name, "raw_"):
+		rest, rank = strings.TrimPrefix(name, "raw_"), 2
+	default:
+		return "", 0
+	}
+	for _, typ := range fitTypes {
+		if strings.HasPrefix(rest, typ+"_") {
+			return typ, rank
+		}
+	}
+	return "", 0
+}
+
+func (p *parser) fitZip(zr *zip.Reader) error {
+	files := fitEntries(zr)
+	if len(files) == 0 {
+		return ErrUnsupported
+	}
+	var total int64
+	for _, f := range files {
+		total += int64(f.UncompressedSize64)
+	}
+	p.progress.BytesTotal.Store(total)
+	for _, f := range files {
+		if err := p.fitFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) fitFile(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return p.fitJSON(p.count(rc), path.Base(f.Name))
+}
+
+// fitPoint is an element of "Data Points"
+type fitPoint struct {
+	DataTypeName   string    `json:"dataTypeName"`
+	StartTimeNanos fitNanos  `json:"startTimeNanos"`
+	EndTimeNanos   fitNanos  `json:"endTimeNanos"`
+	FitValue       []fitItem `json:"fitValue"`
+}
+
+type fitItem struct {
+	Value struct {
+		IntVal *int64   `json:"intVal"`
+		FpVal  *float64 `json:"fpVal"`
+	} `json:"value"`
+}
+
+// number reads an item whichever field holds it
+func (i fitItem) number() (float64, bool) {
+	switch {
+	case i.Value.FpVal != nil:
+		return *i.Value.FpVal, true
+	case i.Value.IntVal != nil:
+		return float64(*i.Value.IntVal), true
+	}
+	return 0, false
+}
+
+// fitNanos is a Unix time in nanoseconds, written as a number or a string
+type fitNanos int64
+
+func (n *fitNanos) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = fitNanos(v)
+	return nil
+}
+
+func (n fitNanos) time() time.Time {
+	return time.Unix(0, int64(n))
+}
+
+// fitSession is a file of the "All Sessions" folder
+type fitSession struct {
+	FitnessActivity string `json:"fitnessActivity"`
+	StartTime       string `json:"startTime"`
+	EndTime         string `json:"endTime"`
+	Duration        string `json:"duration"`
+	Aggregate       []struct {
+		MetricName string   `json:"metricName"`
+		FloatValue *float64 `json:"floatValue"`
+	} `json:"aggregate"`
+}
+
+// fitJSON streams one Takeout file: a data file whose "Data Points" may
+// hold millions of entries, or a small session file
+func (p *parser) fitJSON(r io.Reader, name string) error {
+	d := json.NewDecoder(r)
+	if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("%s: not a Google Fit file", name)
+	}
+	var session fitSession
+	raw := map[string]json.RawMessage{}
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+		key, _ := tok.(string)
+		if key != "Data Points" {
+			var v json.RawMessage
+			if err := d.Decode(&v); err != nil {
+				return fmt.Errorf("read %s: %w", name, err)
+			}
+			raw[key] = v
+			continue
+		}
+		if tok, err := d.Token(); err != nil || tok != json.Delim('[') {
+			return fmt.Errorf("read %s: Data Points is not a list", name)
+		}
+		for d.More() {
+			var pt fitPoint
+			if err := d.Decode(&pt); err != nil {
+				return fmt.Errorf("read %s: %w", name, err)
+			}
+			if err := p.fitPoint(pt); err != nil {
+				return err
+			}
+		}
+		if _, err := d.Token(); err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+	if _, ok := raw["fitnessActivity"]; !ok {
+		return nil
+	}
+	// session files are small, so they are decoded whole
+	b, _ := json.Marshal(raw)
+	if err := json.Unmarshal(b, &session); err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	return p.fitWorkout(session)
+}
+
+func (p *parser) fitPoint(pt fitPoint) error {
+	if len(pt.FitValue) == 0 {
+		return nil
+	}
+	v, ok := pt.FitValue[0].number()
+	if !ok {
+		return nil
+	}
+	start, end := pt.StartTimeNanos.time().In(p.loc), pt.EndTimeNanos.time().In(p.loc)
+	switch pt.DataTypeName {
+	case fitWeight:
+		return p.reading(domain.MetricWeight, start, v, "kg", nil)
+	case fitSteps:
+		p.add(domain.MetricSteps, "", start, v)
+	case fitHydration:
+		p.add(domain.MetricWater, "", start, v*1000) // litres
+	case fitSleep:
+		if fitAsleep[int64(v)] {
+			p.add(domain.MetricSleep, "", end, end.Sub(start).Hours())
+		}
+	case fitBloodPressure:
+		if len(pt.FitValue) < 2 {
+			return nil
+		}
+		dia, ok := pt.FitValue[1].number()
+		if !ok {
+			return nil
+		}
+		return p.reading(domain.MetricBloodPressure, start, v, "mmHg", &dia)
+	}
+	return nil
+}
+
+func (p *parser) fitWorkout(s fitSession) error {
+	if fitNotExercise[s.FitnessActivity] {
+		return nil
+	}
+	start, err := time.Parse(time.RFC3339, s.StartTime)
+	if err != nil {
+		return nil
+	}
+	duration, err := time.ParseDuration(s.Duration)
+	if err != nil {
+		if end, endErr := time.Parse(time.RFC3339, s.EndTime); endErr == nil {
+			duration = end.Sub(start)
+		}
+	}
+	w := domain.WorkoutDetails{
+		Activity:        domain.ActivityOther,
+		DurationSeconds: int(duration.Round(time.Second) / time.Second),
+	}
+	if act, ok := fitActivities[s.FitnessActivity]; ok {
+		w.Activity = act
+	}
+	if w.DurationSeconds <= 0 {
+		return nil
+	}
+	for _, a := range s.Aggregate {
+		if a.FloatValue == nil || *a.FloatValue <= 0 {
+			continue
+		}
+		switch a.MetricName {
+		case "com.google.distance.delta":
+			m := *a.FloatValue
+			w.DistanceMeters = &m
+		case "com.google.calories.expended":
+			kcal := int(*a.FloatValue + 0.5)
+			w.Calories = &kcal
+		}
+	}
+	p.progress.Records.Add(1)
+	return p.sink.Workout(Workout{
+		Key:     fmt.Sprintf("%s/workout/%d", p.source, start.Unix()),
+		Start:   start,
+		Details: w,
+	})
+}
@@ -0,0 +1,277 @@
+// Package healthimport reads the data exports of phone health apps: the
+// export.zip of Apple Health and the Fit folder of a Google Takeout. Files
+// are streamed, so exports of several gigabytes need little memory.
+package healthimport
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"profile_service/domain"
+)
+
+// Sources, also stored as the source of imported measurements
+const (
+	SourceAppleHealth = "apple_health"
+	SourceGoogleFit   = "google_fit"
+)
+
+var ErrUnsupported = errors.New("not an Apple Health export or a Google Fit takeout")
+
+// Config bounds the imports a profile service runs
+type Config struct {
+	Dir     string // where uploads wait for their job
+	Workers int    // imports running at the same time
+}
+
+// Reading is a measurement found in an export. Key identifies it across
+// imports of the same app.
+type Reading struct {
+	Key     string
+	Request domain.MeasurementRequest
+}
+
+// Workout is a workout found in an export
+type Workout struct {
+	Key     string
+	Start   time.Time
+	Details domain.WorkoutDetails
+}
+
+// Sink receives what a parser finds; an error stops the import
+type Sink interface {
+	Reading(r Reading) error
+	Workout(w Workout) error
+}
+
+// Progress counts the bytes and records parsed so far; it may be read
+// while Parse runs
+type Progress struct {
+	BytesRead  atomic.Int64
+	BytesTotal atomic.Int64
+	Records    atomic.Int64
+}
+
+// Detect reports which app produced the file at path: a zipped export,
+// Apple's bare export.xml or a single Google Fit JSON file
+func Detect(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head, err := bufio.NewReader(f).Peek(512)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return "", ErrUnsupported
+		}
+		defer zr.Close()
+		if appleEntry(&zr.Reader) != nil {
+			return SourceAppleHealth, nil
+		}
+		if len(fitEntries(&zr.Reader)) > 0 {
+			return SourceGoogleFit, nil
+		}
+	case bytes.HasPrefix(head, []byte("<")) && bytes.Contains(head, []byte("HealthData")):
+		return SourceAppleHealth, nil
+	case bytes.HasPrefix(head, []byte("{")):
+		return SourceGoogleFit, nil
+	}
+	return "", ErrUnsupported
+}
+
+// Parse streams the export at path, as detected by Detect, into sink. Daily
+// totals such as steps are only complete at the end of the file, so they
+// reach the sink last. loc is the calendar used for those totals when the
+// export carries no time zones.
+func Parse(ctx context.Context, path, source string, loc *time.Location, sink Sink, progress *Progress) error {
+	p := &parser{ctx: ctx, source: source, loc: loc, sink: sink, progress: progress, totals: newTotals()}
+	zr, err := zip.OpenReader(path)
+	switch {
+	case err == nil:
+		defer zr.Close()
+		if source == SourceAppleHealth {
+			err = p.appleZip(&zr.Reader)
+		} else {
+			err = p.fitZip(&zr.Reader)
+		}
+	case errors.Is(err, zip.ErrFormat):
+		err = p.file(path)
+	}
+	if err != nil {
+		return err
+	}
+	return p.flushTotals()
+}
+
+// file parses an upload that is not zipped
+func (p *parser) file(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	p.progress.BytesTotal.Store(info.Size())
+	r := p.count(f)
+	if p.source == SourceAppleHealth {
+		return p.apple(r)
+	}
+	return p.fitJSON(r, "")
+}
+
+type parser struct {
+	ctx      context.Context
+	source   string
+	loc      *time.Location
+	sink     Sink
+	progress *Progress
+	totals   *totals
+}
+
+// reading hands a point-in-time measurement to the sink
+func (p *parser) reading(metric string, at time.Time, value float64, unit string, diastolic *float64) error {
+	p.progress.Records.Add(1)
+	at = at.UTC()
+	return p.sink.Reading(Reading{
+		Key: fmt.Sprintf("%s/%s/%d", p.source, metric, at.Unix()),
+		Request: domain.MeasurementRequest{
+			Metric:     metric,
+			Value:      &value,
+			Diastolic:  diastolic,
+			Unit:       unit,
+			MeasuredAt: &at,
+		},
+	})
+}
+
+// add counts a record towards the daily total of metric
+func (p *parser) add(metric, device string, at time.Time, value float64) {
+	p.progress.Records.Add(1)
+	p.totals.add(metric, device, at, value)
+}
+
+func (p *parser) flushTotals() error {
+	now := time.Now()
+	for _, t := range p.totals.sorted() {
+		value, noon := t.value(), t.noon
+		// today's total is dated no later than now
+		if noon.After(now) {
+			noon = now
+		}
+		err := p.sink.Reading(Reading{
+			Key: fmt.Sprintf("%s/%s/%s", p.source, t.metric, t.day),
+			Request: domain.MeasurementRequest{
+				Metric:     t.metric,
+				Value:      &value,
+				Unit:       domain.MetricUnit(t.metric),
+				MeasuredAt: &noon,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// count makes reads from r show up in the progress
+func (p *parser) count(r io.Reader) io.Reader {
+	return &countingReader{r: r, ctx: p.ctx, n: &p.progress.BytesRead}
+}
+
+type countingReader struct {
+	r   io.Reader
+	ctx context.Context
+	n   *atomic.Int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(b)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// totals sums records per metric, calendar day and recording device.
+// Several devices often count the same steps, so the day's value is the
+// largest device total rather than the sum; water is the exception, as
+// drinks logged in different apps add up.
+type totals struct {
+	days map[string]*dayTotal // metric + day
+}
+
+type dayTotal struct {
+	metric   string
+	day      string    // YYYY-MM-DD
+	noon     time.Time // the reading's timestamp, clear of zone shifts
+	byDevice map[string]float64
+}
+
+func newTotals() *totals {
+	return &totals{days: map[string]*dayTotal{}}
+}
+
+func (t *totals) add(metric, device string, at time.Time, value float64) {
+	day := at.Format(time.DateOnly)
+	key := metric + "/" + day
+	d, ok := t.days[key]
+	if !ok {
+		y, m, dd := at.Date()
+		d = &dayTotal{
+			metric:   metric,
+			day:      day,
+			noon:     time.Date(y, m, dd, 12, 0, 0, 0, at.Location()),
+			byDevice: map[string]float64{},
+		}
+		t.days[key] = d
+	}
+	d.byDevice[device] += value
+}
+
+func (t *totals) sorted() []*dayTotal {
+	out := make([]*dayTotal, 0, len(t.days))
+	for _, d := range t.days {
+		out = append(out, d)
+	}
+	slices.SortFunc(out, func(a, b *dayTotal) int {
+		if a.metric != b.metric {
+			return cmp.Compare(a.metric, b.metric)
+		}
+		return cmp.Compare(a.day, b.day)
+	})
+	return out
+}
+
+func (d *dayTotal) value() float64 {
+	var sum, best float64
+	for _, v := range d.byDevice {
+		sum += v
+		best = max(best, v)
+	}
+	if d.metric == domain.MetricWater {
+		return sum
+	}
+	return best
+}
@@ -0,0 +1,308 @@
+package healthimport
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"profile_service/domain"
+)
+
+// sink records what a parser hands over, in order
+type sink struct {
+	readings []Reading
+	workouts []Workout
+}
+
+func (s *sink) Reading(r Reading) error {
+	s.readings = append(s.readings, r)
+	return nil
+}
+
+func (s *sink) Workout(w Workout) error {
+	s.workouts = append(s.workouts, w)
+	return nil
+}
+
+type wantReading struct {
+	key       string
+	value     float64
+	diastolic float64
+	unit      string
+	at        time.Time
+}
+
+type wantWorkout struct {
+	key       string
+	activity  string
+	duration  int
+	distance  float64 // metres, 0 for none
+	calories  int     // 0 for none
+	heartRate string  // avg/min/max, "" for none
+}
+
+var berlin, _ = time.LoadLocation("Europe/Berlin")
+
+func TestParseApple(t *testing.T) {
+	appleZip := zipDir(t, "testdata", "apple_health_export/", func(name string) bool { return name == "export.xml" })
+	for _, path := range []string{filepath.Join("testdata", "export.xml"), appleZip} {
+		var got sink
+		var progress Progress
+		if err := Parse(context.Background(), path, SourceAppleHealth, time.UTC, &got, &progress); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		plus2 := time.FixedZone("", 2*3600)
+		at := func(s string) time.Time { return mustTime(t, appleTime, s) }
+		checkReadings(t, got.readings, []wantReading{
+			{key: appleKey("weight", "2024-05-01 07:00:00 +0200"), value: 165, unit: "lb", at: at("2024-05-01 07:00:00 +0200")},
+			{key: appleKey("resting_heart_rate", "2024-05-01 08:00:00 +0200"), value: 58, unit: "bpm", at: at("2024-05-01 08:00:00 +0200")},
+			{key: appleKey("blood_pressure", "2024-05-01 07:10:00 +0200"), value: 120, diastolic: 80, unit: "mmHg", at: at("2024-05-01 07:10:00 +0200")},
+			// the largest device total; a step past midnight counts on the
+			// day of its own zone
+			{key: "apple_health/sleep/2024-05-01", value: 8, unit: "h", at: time.Date(2024, 5, 1, 12, 0, 0, 0, plus2)},
+			{key: "apple_health/steps/2024-05-01", value: 6500, unit: "steps", at: time.Date(2024, 5, 1, 12, 0, 0, 0, plus2)},
+			{key: "apple_health/steps/2024-05-02", value: 300, unit: "steps", at: time.Date(2024, 5, 2, 12, 0, 0, 0, plus2)},
+			// water adds up across apps; gallons are not a unit of export.xml
+			{key: "apple_health/water/2024-05-01", value: 250 + 500 + 8*29.5735295625, unit: "ml", at: time.Date(2024, 5, 1, 12, 0, 0, 0, plus2)},
+		})
+		checkWorkouts(t, got.workouts, []wantWorkout{
+			{key: appleKey("workout", "2024-05-01 06:00:00 +0200"), activity: domain.ActivityRun, duration: 1800, distance: 5000, calories: 300},
+			{key: appleKey("workout", "2024-05-02 17:00:00 +0200"), activity: domain.ActivityRide, duration: 3600, distance: 20000, calories: 500, heartRate: "140/95/172"},
+			{key: appleKey("workout", "2024-05-03 07:00:00 +0200"), activity: domain.ActivityYoga, duration: 2700},
+		})
+		if r, n := progress.BytesRead.Load(), progress.BytesTotal.Load(); r == 0 || r != n {
+			t.Errorf("%s: read %d of %d bytes", path, r, n)
+		}
+	}
+}
+
+func TestParseGoogleFit(t *testing.T) {
+	fitZip := zipDir(t, filepath.Join("testdata", "Takeout"), "Takeout/", func(string) bool { return true })
+	var got sink
+	var progress Progress
+	if err := Parse(context.Background(), fitZip, SourceGoogleFit, berlin, &got, &progress); err != nil {
+		t.Fatal(err)
+	}
+	local := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	checkReadings(t, got.readings, []wantReading{
+		{key: fitKey("weight", local("2024-05-01 07:15")), value: 72.5, unit: "kg", at: local("2024-05-01 07:15")},
+		{key: fitKey("blood_pressure", local("2024-05-01 09:00")), value: 118, diastolic: 76, unit: "mmHg", at: local("2024-05-01 09:00")},
+		// light, deep and REM sleep, not the minutes awake
+		{key: "google_fit/sleep/2024-05-01", value: 7.5, unit: "h", at: local("2024-05-01 12:00")},
+		// only the merged step file counts, and 00:30 in Berlin is the
+		// next day even though it is still the 1st in UTC
+		{key: "google_fit/steps/2024-05-01", value: 7000, unit: "steps", at: local("2024-05-01 12:00")},
+		{key: "google_fit/steps/2024-05-02", value: 500, unit: "steps", at: local("2024-05-02 12:00")},
+		{key: "google_fit/water/2024-05-01", value: 750, unit: "ml", at: local("2024-05-01 12:00")},
+	})
+	checkWorkouts(t, got.workouts, []wantWorkout{
+		{key: fitKey("workout", local("2024-05-01 07:00")), activity: domain.ActivityRun, duration: 2400, distance: 6500.5, calories: 411},
+		{key: fitKey("workout", local("2024-05-01 19:00")), activity: domain.ActivityWalk, duration: 1800},
+	})
+	if r, n := progress.BytesRead.Load(), progress.BytesTotal.Load(); r == 0 || r != n {
+		t.Errorf("read %d of %d bytes", r, n)
+	}
+}
+
+func TestParseBareGoogleFitFile(t *testing.T) {
+	path := filepath.Join("testdata", "Takeout", "Fit", "All Data", "derived_com.google.step_count.delta_com.google.android.gms_merge_step_deltas.json")
+	var got sink
+	if err := Parse(context.Background(), path, SourceGoogleFit, time.UTC, &got, &Progress{}); err != nil {
+		t.Fatal(err)
+	}
+	// in UTC the step at 00:30 in Berlin is still on the 1st
+	checkReadings(t, got.readings, []wantReading{
+		{key: "google_fit/steps/2024-05-01", value: 7500, unit: "steps", at: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	})
+}
+
+func TestFitFileRanking(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  string
+		rank int
+	}{
+		{"derived_com.google.weight_com.google.android.gms_merge_weight.json", fitWeight, 0},
+		{"derived_com.google.hydration_com.google.android.gms_merged.json", fitHydration, 0},
+		{"derived_com.google.step_count.delta_com.google.android.gms_estimated_steps.json", fitSteps, 1},
+		{"raw_com.google.step_count.delta_com.google.android.apps.fitness.json", fitSteps, 2},
+		{"derived_com.google.heart_rate.bpm_com.google.android.gms_merge_heart_rate_bpm.json", "", 0},
+		{"com.google.weight.json", "", 0},
+	}
+	for _, tt := range tests {
+		if typ, rank := fitFileType(tt.name); typ != tt.typ || rank != tt.rank {
+			t.Errorf("fitFileType(%s) = %q, %d; want %q, %d", tt.name, typ, rank, tt.typ, tt.rank)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join("testdata", "export.xml"), SourceAppleHealth},
+		{write("bom.xml", "\xef\xbb\xbf\n<?xml version=\"1.0\"?><HealthData/>"), SourceAppleHealth},
+		{zipDir(t, "testdata", "", func(name string) bool { return name == "export.xml" }), SourceAppleHealth},
+		{filepath.Join("testdata", "Takeout", "Fit", "All Sessions", "2024-05-01T07_00_00+02_00_RUNNING.json"), SourceGoogleFit},
+		{zipDir(t, filepath.Join("testdata", "Takeout"), "Takeout/", func(string) bool { return true }), SourceGoogleFit},
+		{write("other.xml", "<?xml version=\"1.0\"?><gpx/>"), ""},
+		{write("notes.txt", "not an export"), ""},
+		{write("empty", ""), ""},
+		{write("broken.zip", "PK\x03\x04 and then nothing"), ""},
+		{zipDir(t, filepath.Join("testdata", "Takeout", "Fit", "All Data"), "photos/", func(string) bool { return true }), ""},
+	}
+	for _, tt := range tests {
+		got, err := Detect(tt.path)
+		if tt.want == "" {
+			if !errors.Is(err, ErrUnsupported) {
+				t.Errorf("Detect(%s) = %q, %v; want ErrUnsupported", tt.path, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Detect(%s) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Parse(ctx, filepath.Join("testdata", "export.xml"), SourceAppleHealth, time.UTC, &sink{}, &Progress{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+// checkReadings wants point readings first in file order, then the daily
+// totals sorted by metric and day
+func checkReadings(t *testing.T, got []Reading, want []wantReading) {
+	t.Helper()
+	if len(got) != len(want) {
+		var keys []string
+		for _, r := range got {
+			keys = append(keys, r.Key)
+		}
+		t.Fatalf("got %d readings %q, want %d", len(got), keys, len(want))
+	}
+	for i, w := range want {
+		r, req := got[i], got[i].Request
+		if r.Key != w.key || req.Metric != strings.Split(w.key, "/")[1] || req.Unit != w.unit ||
+			req.Value == nil || math.Abs(*req.Value-w.value) > 1e-9 ||
+			req.MeasuredAt == nil || !req.MeasuredAt.Equal(w.at) {
+			t.Errorf("reading %d = %s %s %v %s at %v; want %s %v %s at %v",
+				i, r.Key, req.Metric, deref(req.Value), req.Unit, req.MeasuredAt, w.key, w.value, w.unit, w.at)
+		}
+		if (req.Diastolic != nil) != (w.diastolic != 0) || req.Diastolic != nil && *req.Diastolic != w.diastolic {
+			t.Errorf("reading %s diastolic = %v, want %v", r.Key, deref(req.Diastolic), w.diastolic)
+		}
+	}
+}
+
+func checkWorkouts(t *testing.T, got []Workout, want []wantWorkout) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d workouts %+v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		g, d := got[i], got[i].Details
+		hr := ""
+		if h := d.HeartRate; h != nil {
+			hr = strings.Join([]string{strconv.Itoa(h.Avg), strconv.Itoa(deref(h.Min)), strconv.Itoa(deref(h.Max))}, "/")
+		}
+		if g.Key != w.key || d.Activity != w.activity || d.DurationSeconds != w.duration ||
+			math.Abs(deref(d.DistanceMeters)-w.distance) > 1e-9 || deref(d.Calories) != w.calories || hr != w.heartRate {
+			t.Errorf("workout %d = %s %s %ds %vm %dkcal hr %q; want %+v",
+				i, g.Key, d.Activity, d.DurationSeconds, deref(d.DistanceMeters), deref(d.Calories), hr, w)
+		}
+	}
+}
+
+// zipDir zips the files under dir accepted by keep, their names prefixed
+// with prefix
+func zipDir(t *testing.T, dir, prefix string, keep func(name string) bool) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "export-*.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	err = filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() {
+			return err
+		}
+		name, _ := filepath.Rel(dir, path)
+		if !keep(filepath.ToSlash(name)) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(prefix + filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+// appleKey is the key of a point reading or workout at an export.xml date
+func appleKey(metric, at string) string {
+	tm, _ := time.Parse(appleTime, at)
+	return fmt.Sprintf("%s/%s/%d", SourceAppleHealth, metric, tm.Unix())
+}
+
+func fitKey(metric string, at time.Time) string {
+	return fmt.Sprintf("%s/%s/%d", SourceGoogleFit, metric, at.Unix())
+}
+
+func mustTime(t *testing.T, layout, s string) time.Time {
+	t.Helper()
+	tm, err := time.Parse(layout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
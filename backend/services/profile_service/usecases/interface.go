@@ -2,12 +2,26 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"profile_service/domain"
 	"profile_service/repository"
+	"profile_service/usecases/healthimport"
 )
 
-var ErrNotFound = repository.ErrNotFound
+var (
+	ErrNotFound       = repository.ErrNotFound
+	ErrAvatarNotFound = errors.New("avatar not found")
+	ErrBadAvatar      = errors.New("unsupported or unreadable image")
+	ErrAvatarTooLarge = errors.New("image is too large")
+
+	ErrMeasurementNotFound = repository.ErrMeasurementNotFound
+
+	ErrImportNotFound    = repository.ErrImportNotFound
+	ErrImportInProgress  = errors.New("an import is already running")
+	ErrUnsupportedImport = healthimport.ErrUnsupported
+)
 
 type ProfileService interface {
 	Health(ctx context.Context) error
@@ -16,4 +30,28 @@ type ProfileService interface {
 	ListProfiles(ctx context.Context) ([]domain.ProfileResponse, error)
 	UpdateProfile(ctx context.Context, userID string, req domain.ProfileRequest) (domain.ProfileResponse, error)
 	DeleteProfile(ctx context.Context, userID string) error
+
+	// UploadAvatar replaces the avatar with square thumbnails of a JPEG,
+	// PNG or WebP picture
+	UploadAvatar(ctx context.Context, userID string, file io.Reader) (domain.ProfileResponse, error)
+	DeleteAvatar(ctx context.Context, userID string) (domain.ProfileResponse, error)
+	// OpenAvatar returns a stored thumbnail by file name; the caller closes it
+	OpenAvatar(ctx context.Context, name string) (string, io.ReadCloser, error)
+
+	// LogMeasurement stores a reading converted to the metric's unit
+	LogMeasurement(ctx context.Context, userID string, req domain.MeasurementRequest) (domain.MeasurementResponse, error)
+	GetMeasurement(ctx context.Context, measurementID string) (domain.MeasurementResponse, error)
+	ListMeasurements(ctx context.Context, userID string, q domain.MeasurementQuery, page domain.PageRequest) (domain.Page[domain.MeasurementResponse], error)
+	// AggregateMeasurements downsamples a metric to daily or weekly statistics
+	AggregateMeasurements(ctx context.Context, userID string, q domain.AggregateQuery) (domain.MeasurementSeries, error)
+	DeleteMeasurement(ctx context.Context, measurementID string) error
+
+	// StartImport saves an Apple Health or Google Fit export and imports it
+	// in the background; tz is the calendar of daily totals for exports
+	// without time zones
+	StartImport(ctx context.Context, userID, tz string, file io.Reader) (domain.ImportJobResponse, error)
+	GetImport(ctx context.Context, importID string) (domain.ImportJobResponse, error)
+	ListImports(ctx context.Context, userID string) ([]domain.ImportJobResponse, error)
+	// RecoverImports fails the jobs a restart interrupted
+	RecoverImports(ctx context.Context) error
 }
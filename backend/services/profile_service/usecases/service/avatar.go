@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+
+	"profile_service/domain"
+	"profile_service/repository"
+	"profile_service/usecases"
+	"profile_service/usecases/avatar"
+)
+
+// avatarFile matches the names avatars are stored and served under:
+// <avatar id>_<size>.<ext>
+var avatarFile = regexp.MustCompile(`^[0-9a-f-]{36}_[0-9]+\.(jpg|png)$`)
+
+// avatarKey is where one thumbnail is kept in the blob store
+func avatarKey(avatarID string, size int, ext string) string {
+	return "avatars/" + avatarID + "_" + strconv.Itoa(size) + ext
+}
+
+// toResponse converts a profile, pointing an uploaded avatar at its
+// thumbnails
+func (s *profileService) toResponse(p *domain.Profile) domain.ProfileResponse {
+	resp := p.ToResponse()
+	if p.AvatarID == "" {
+		return resp
+	}
+	resp.Avatars = make(map[string]string, len(avatar.Sizes))
+	for _, size := range avatar.Sizes {
+		resp.Avatars[strconv.Itoa(size)] = s.avatarCfg.URLPrefix + "/" + p.AvatarID + "_" + strconv.Itoa(size) + p.AvatarExt
+	}
+	resp.Avatar = resp.Avatars[strconv.Itoa(avatar.Sizes[0])]
+	return resp
+}
+
+// UploadAvatar replaces the user's avatar with an uploaded picture,
+// stored as square thumbnails of every size in avatar.Sizes
+func (s *profileService) UploadAvatar(ctx context.Context, userID string, file io.Reader) (domain.ProfileResponse, error) {
+	p, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return domain.ProfileResponse{}, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, s.avatarCfg.MaxBytes+1))
+	if err != nil {
+		return domain.ProfileResponse{}, err
+	}
+	if int64(len(data)) > s.avatarCfg.MaxBytes {
+		return domain.ProfileResponse{}, fmt.Errorf("%w: avatars are limited to %d MB", usecases.ErrAvatarTooLarge, s.avatarCfg.MaxBytes>>20)
+	}
+	variants, err := avatar.Process(data)
+	if err != nil {
+		return domain.ProfileResponse{}, fmt.Errorf("%w: %v", usecases.ErrBadAvatar, err)
+	}
+
+	avatarID := domain.NewUUID()
+	ext := variants[0].Extension()
+	for i, v := range variants {
+		if err := s.blobs.Put(ctx, avatarKey(avatarID, v.Size, ext), v.ContentType, bytes.NewReader(v.Data), int64(len(v.Data))); err != nil {
+			s.deleteAvatarFiles(ctx, avatarID, ext, variants[:i])
+			return domain.ProfileResponse{}, err
+		}
+	}
+
+	if err := s.setAvatar(ctx, p, avatarID, ext, ""); err != nil {
+		s.deleteAvatarFiles(ctx, avatarID, ext, variants)
+		return domain.ProfileResponse{}, err
+	}
+	return s.toResponse(p), nil
+}
+
+// DeleteAvatar removes the user's avatar, uploaded or linked
+func (s *profileService) DeleteAvatar(ctx context.Context, userID string) (domain.ProfileResponse, error) {
+	p, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return domain.ProfileResponse{}, err
+	}
+	if err := s.setAvatar(ctx, p, "", "", ""); err != nil {
+		return domain.ProfileResponse{}, err
+	}
+	return s.toResponse(p), nil
+}
+
+// OpenAvatar returns the content type and contents of a stored thumbnail;
+// the caller closes the reader
+func (s *profileService) OpenAvatar(ctx context.Context, name string) (string, io.ReadCloser, error) {
+	if !avatarFile.MatchString(name) {
+		return "", nil, usecases.ErrAvatarNotFound
+	}
+	body, err := s.blobs.Get(ctx, "avatars/"+name)
+	if errors.Is(err, repository.ErrBlobNotFound) {
+		return "", nil, usecases.ErrAvatarNotFound
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	contentType := "image/jpeg"
+	if name[len(name)-4:] == ".png" {
+		contentType = "image/png"
+	}
+	return contentType, body, nil
+}
+
+// setAvatar points p at a new avatar, uploaded or linked, and deletes the
+// upload it replaces. When another request changed the avatar since p was
+// read, it reads the profile again and replaces that avatar instead, so
+// every replaced upload is deleted by the request that replaced it.
+func (s *profileService) setAvatar(ctx context.Context, p *domain.Profile, avatarID, ext, link string) error {
+	for {
+		oldID, oldExt := p.AvatarID, p.AvatarExt
+		ok, err := s.repo.ReplaceAvatar(p.UserID, oldID, avatarID, ext, link)
+		if err != nil {
+			return err
+		}
+		if ok {
+			p.AvatarID, p.AvatarExt, p.Avatar = avatarID, ext, link
+			s.deleteAvatar(ctx, oldID, oldExt)
+			return nil
+		}
+		fresh, err := s.repo.GetByUserID(p.UserID)
+		if err != nil {
+			return err
+		}
+		p.AvatarID, p.AvatarExt, p.Avatar = fresh.AvatarID, fresh.AvatarExt, fresh.Avatar
+	}
+}
+
+// deleteAvatar removes every thumbnail of an uploaded avatar, if any
+func (s *profileService) deleteAvatar(ctx context.Context, avatarID, ext string) {
+	if avatarID == "" {
+		return
+	}
+	for _, size := range avatar.Sizes {
+		s.deleteBlob(ctx, avatarKey(avatarID, size, ext))
+	}
+}
+
+// deleteAvatarFiles removes the thumbnails of a failed upload
+func (s *profileService) deleteAvatarFiles(ctx context.Context, avatarID, ext string, variants []avatar.Variant) {
+	for _, v := range variants {
+		s.deleteBlob(ctx, avatarKey(avatarID, v.Size, ext))
+	}
+}
+
+// deleteBlob only logs failures: an orphaned file is harmless and nothing
+// points at it any more
+func (s *profileService) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		log.Printf("deleteBlob: %s: %v", key, err)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"io"
+	"sync"
+	"testing"
+
+	"profile_service/domain"
+	"profile_service/repository"
+	"profile_service/usecases/avatar"
+	"profile_service/usecases/healthimport"
+)
+
+// fakeProfiles holds one profile. Until readers calls to GetByUserID have
+// arrived, each one waits for the others, so that many requests start from
+// the same avatar.
+type fakeProfiles struct {
+	repository.ProfileRepository
+	mu      sync.Mutex
+	p       domain.Profile
+	readers int
+	arrived sync.WaitGroup
+}
+
+func (r *fakeProfiles) GetByUserID(userID string) (*domain.Profile, error) {
+	r.mu.Lock()
+	wait := r.readers > 0
+	if wait {
+		r.readers--
+	}
+	p := r.p
+	r.mu.Unlock()
+	if wait {
+		r.arrived.Done()
+		r.arrived.Wait()
+	}
+	return &p, nil
+}
+
+func (r *fakeProfiles) Update(p *domain.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.p.Name, r.p.Bio = p.Name, p.Bio
+	return nil
+}
+
+func (r *fakeProfiles) ReplaceAvatar(userID, oldID, avatarID, ext, link string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.p.AvatarID != oldID {
+		return false, nil
+	}
+	r.p.AvatarID, r.p.AvatarExt, r.p.Avatar = avatarID, ext, link
+	return true, nil
+}
+
+type fakeBlobs struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (b *fakeBlobs) Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blobs[key] = data
+	return err
+}
+
+func (b *fakeBlobs) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.blobs[key]
+	if !ok {
+		return nil, repository.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *fakeBlobs) Delete(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.blobs, key)
+	return nil
+}
+
+const oldAvatar = "00000000-0000-4000-8000-000000000000"
+
+func newAvatarService(t *testing.T, readers int) (*profileService, *fakeProfiles, *fakeBlobs) {
+	t.Helper()
+	repo := &fakeProfiles{p: domain.Profile{UserID: "u1", AvatarID: oldAvatar, AvatarExt: ".jpg"}, readers: readers}
+	repo.arrived.Add(readers)
+	blobs := &fakeBlobs{blobs: map[string][]byte{}}
+	for _, size := range avatar.Sizes {
+		blobs.blobs[avatarKey(oldAvatar, size, ".jpg")] = []byte("old")
+	}
+	s := NewProfileService(repo, nil, nil, "", "", "", blobs, avatar.Config{MaxBytes: 1 << 20}, healthimport.Config{})
+	return s.(*profileService), repo, blobs
+}
+
+// onlyAvatar checks that the blob store holds the thumbnails of the
+// profile's avatar and nothing else
+func onlyAvatar(t *testing.T, repo *fakeProfiles, blobs *fakeBlobs) {
+	t.Helper()
+	want := map[string]bool{}
+	if repo.p.AvatarID != "" {
+		for _, size := range avatar.Sizes {
+			want[avatarKey(repo.p.AvatarID, size, repo.p.AvatarExt)] = true
+		}
+	}
+	for key := range blobs.blobs {
+		if !want[key] {
+			t.Errorf("orphaned blob %s", key)
+		}
+		delete(want, key)
+	}
+	for key := range want {
+		t.Errorf("missing blob %s", key)
+	}
+}
+
+func TestConcurrentAvatarUploads(t *testing.T) {
+	const uploads = 5
+	s, repo, blobs := newAvatarService(t, uploads)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < uploads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.UploadAvatar(context.Background(), "u1", bytes.NewReader(buf.Bytes())); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if repo.p.AvatarID == oldAvatar || repo.p.AvatarID == "" {
+		t.Fatalf("avatar is %q after the uploads", repo.p.AvatarID)
+	}
+	onlyAvatar(t, repo, blobs)
+}
+
+func TestLinkedAvatarRacingAnUpload(t *testing.T) {
+	// both requests read the old avatar; whichever lands second wins and
+	// the upload's thumbnails go if the link wins
+	s, repo, blobs := newAvatarService(t, 2)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	link := "https://example.com/me.png"
+	name := "Sam"
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := s.UploadAvatar(context.Background(), "u1", bytes.NewReader(buf.Bytes())); err != nil {
+			t.Error(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if _, err := s.UpdateProfile(context.Background(), "u1", domain.ProfileRequest{Name: &name, Avatar: &link}); err != nil {
+			t.Error(err)
+		}
+	}()
+	wg.Wait()
+	if repo.p.Name != name {
+		t.Errorf("name = %q, want %q", repo.p.Name, name)
+	}
+	if repo.p.AvatarID == "" && repo.p.Avatar != link || repo.p.AvatarID != "" && repo.p.Avatar != "" {
+		t.Errorf("avatar = %q, linked %q", repo.p.AvatarID, repo.p.Avatar)
+	}
+	onlyAvatar(t, repo, blobs)
+}
+
+func TestDeleteAvatar(t *testing.T) {
+	s, repo, blobs := newAvatarService(t, 0)
+	resp, err := s.DeleteAvatar(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Avatar != "" || len(resp.Avatars) != 0 || repo.p.AvatarID != "" {
+		t.Errorf("avatar left after delete: %+v, %+v", resp, repo.p)
+	}
+	onlyAvatar(t, repo, blobs)
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"profile_service/domain"
+	"profile_service/usecases"
+	"profile_service/usecases/healthimport"
+)
+
+const (
+	// importBatch is how many readings are written at once
+	importBatch = 500
+	// importSaveEvery spaces out progress writes of a running job
+	importSaveEvery = 2 * time.Second
+	// maxImportsListed bounds GET /profile/imports
+	maxImportsListed = 20
+)
+
+// workoutTitles name imported workouts by activity
+var workoutTitles = map[string]string{
+	domain.ActivityRun:      "Run",
+	domain.ActivityWalk:     "Walk",
+	domain.ActivityRide:     "Ride",
+	domain.ActivitySwim:     "Swim",
+	domain.ActivityHike:     "Hike",
+	domain.ActivityStrength: "Strength training",
+	domain.ActivityYoga:     "Yoga",
+	domain.ActivityOther:    "Workout",
+}
+
+var sourceNames = map[string]string{
+	healthimport.SourceAppleHealth: "Apple Health",
+	healthimport.SourceGoogleFit:   "Google Fit",
+}
+
+func (s *profileService) StartImport(ctx context.Context, userID, tz string, file io.Reader) (domain.ImportJobResponse, error) {
+	p, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return domain.ImportJobResponse{}, err
+	}
+	// one import per user at a time; DeleteProfile cancels it
+	jobCtx, cancel := context.WithCancel(context.Background())
+	if _, busy := s.importCancels.LoadOrStore(userID, cancel); busy {
+		cancel()
+		return domain.ImportJobResponse{}, usecases.ErrImportInProgress
+	}
+	started := false
+	defer func() {
+		if !started {
+			s.importCancels.Delete(userID)
+			cancel()
+		}
+	}()
+
+	path, err := s.saveUpload(file)
+	if err != nil {
+		return domain.ImportJobResponse{}, err
+	}
+	source, err := healthimport.Detect(path)
+	if err != nil {
+		os.Remove(path)
+		return domain.ImportJobResponse{}, err
+	}
+	job := &domain.ImportJob{
+		ImportID: domain.NewUUID(),
+		UserID:   userID,
+		Source:   source,
+		Status:   domain.ImportQueued,
+		TZ:       tz,
+		FilePath: path,
+	}
+	if err := s.imports.Create(ctx, job); err != nil {
+		os.Remove(path)
+		return domain.ImportJobResponse{}, err
+	}
+	started = true
+	go s.runImport(jobCtx, cancel, job, p.Name)
+	return job.ToResponse(), nil
+}
+
+// saveUpload copies the export to disk: zip archives need random access,
+// and the job outlives the request
+func (s *profileService) saveUpload(file io.Reader) (string, error) {
+	if err := os.MkdirAll(s.importCfg.Dir, 0o750); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(s.importCfg.Dir, "import-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, file)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (s *profileService) GetImport(ctx context.Context, importID string) (domain.ImportJobResponse, error) {
+	job, err := s.imports.Get(ctx, importID)
+	if err != nil {
+		return domain.ImportJobResponse{}, err
+	}
+	return job.ToResponse(), nil
+}
+
+func (s *profileService) ListImports(ctx context.Context, userID string) ([]domain.ImportJobResponse, error) {
+	jobs, err := s.imports.List(ctx, userID, maxImportsListed)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.ImportJobResponse, len(jobs))
+	for i := range jobs {
+		out[i] = jobs[i].ToResponse()
+	}
+	return out, nil
+}
+
+func (s *profileService) RecoverImports(ctx context.Context) error {
+	jobs, err := s.imports.Unfinished(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range jobs {
+		job := &jobs[i]
+		os.Remove(job.FilePath)
+		job.Status = domain.ImportFailed
+		job.Error = "interrupted by a restart; please upload the export again"
+		job.FinishedAt = &now
+		if err := s.imports.Update(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runImport parses the upload once a worker slot is free. Progress is
+// saved every few seconds so clients can poll the job.
+func (s *profileService) runImport(ctx context.Context, cancel context.CancelFunc, job *domain.ImportJob, name string) {
+	defer s.importCancels.Delete(job.UserID)
+	defer cancel()
+	defer os.Remove(job.FilePath)
+
+	select {
+	case s.importSlots <- struct{}{}:
+		defer func() { <-s.importSlots }()
+	case <-ctx.Done():
+		return
+	}
+
+	// job rows are written with a context of their own, so the final
+	// status is saved even when the import is cancelled
+	save := func(j *domain.ImportJob) {
+		if err := s.imports.Update(context.Background(), j); err != nil {
+			log.Printf("import %s: save status failed: %v", j.ImportID, err)
+		}
+	}
+	now := time.Now()
+	job.Status, job.StartedAt = domain.ImportRunning, &now
+	save(job)
+
+	run := &importRun{s: s, ctx: ctx, job: job, name: name}
+	done := make(chan struct{})
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		ticker := time.NewTicker(importSaveEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				snapshot := *job
+				run.fill(&snapshot)
+				save(&snapshot)
+			}
+		}
+	}()
+
+	loc, err := domain.LoadZone(job.TZ)
+	if err == nil {
+		err = healthimport.Parse(ctx, job.FilePath, job.Source, loc, run, &run.progress)
+	}
+	if err == nil {
+		err = run.flush()
+	}
+	close(done)
+	<-saved
+
+	// a cancelled import belongs to a deleted profile
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	// badges are evaluated once for the whole import, not for each of
+	// what may be years of workouts
+	if run.workouts.Load() > 0 {
+		run.evaluateBadges()
+	}
+	run.fill(job)
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = domain.ImportDone
+	if err != nil {
+		log.Printf("import %s failed: %v", job.ImportID, err)
+		job.Status = domain.ImportFailed
+		job.Error = truncate(err.Error(), 500)
+	}
+	save(job)
+}
+
+// importRun receives what the parser finds and stores it
+type importRun struct {
+	s        *profileService
+	ctx      context.Context
+	job      *domain.ImportJob
+	name     string
+	batch    []domain.Measurement
+	progress healthimport.Progress
+
+	measurements atomic.Int64
+	workouts     atomic.Int64
+	duplicates   atomic.Int64
+	skipped      atomic.Int64
+}
+
+// fill copies the counters into a job
+func (r *importRun) fill(job *domain.ImportJob) {
+	job.BytesRead = r.progress.BytesRead.Load()
+	job.BytesTotal = r.progress.BytesTotal.Load()
+	job.Records = r.progress.Records.Load()
+	job.Measurements = r.measurements.Load()
+	job.Workouts = r.workouts.Load()
+	job.Duplicates = r.duplicates.Load()
+	job.Skipped = r.skipped.Load()
+}
+
+func (r *importRun) Reading(rd healthimport.Reading) error {
+	m, err := rd.Request.ToMeasurement(r.job.UserID, time.Now())
+	if err != nil {
+		r.skipped.Add(1)
+		return nil
+	}
+	key := rd.Key
+	m.Source, m.ExternalID = r.job.Source, &key
+	r.batch = append(r.batch, m)
+	if len(r.batch) >= importBatch {
+		return r.flush()
+	}
+	return nil
+}
+
+// flush writes the pending readings. A key may repeat within a batch,
+// e.g. a blood pressure listed twice in export.xml; the last one wins.
+func (r *importRun) flush() error {
+	if len(r.batch) == 0 {
+		return nil
+	}
+	seen := make(map[string]int, len(r.batch))
+	unique := r.batch[:0]
+	for _, m := range r.batch {
+		if i, ok := seen[*m.ExternalID]; ok {
+			unique[i] = m
+			r.duplicates.Add(1)
+			continue
+		}
+		seen[*m.ExternalID] = len(unique)
+		unique = append(unique, m)
+	}
+	n, err := r.s.measurements.Upsert(r.ctx, unique)
+	if err != nil {
+		return err
+	}
+	r.measurements.Add(n)
+	r.duplicates.Add(int64(len(unique)) - n)
+	r.batch = r.batch[:0]
+	return nil
+}
+
+// Workout posts a workout to feed_service, which keeps imported workouts
+// private and posts each one once
+func (r *importRun) Workout(w healthimport.Workout) error {
+	body, err := json.Marshal(domain.ImportedWorkout{
+		ExternalID: w.Key,
+		Name:       r.name,
+		Title:      fmt.Sprintf("%s from %s", workoutTitles[w.Details.Activity], sourceNames[r.job.Source]),
+		StartedAt:  w.Start.UTC(),
+		Workout:    w.Details,
+	})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/feed/internal/users/%s/workouts", r.s.feedUrl, url.PathEscape(r.job.UserID))
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Service-Token", r.s.profileServiceAuthToken)
+	res, err := r.s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post workout to feed service: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusCreated:
+		r.workouts.Add(1)
+	case http.StatusOK:
+		r.duplicates.Add(1)
+	case http.StatusBadRequest:
+		// out of feed_service's bounds, e.g. a two-day workout
+		r.skipped.Add(1)
+	default:
+		return fmt.Errorf("feed service returned %d for a workout", res.StatusCode)
+	}
+	return nil
+}
+
+// evaluateBadges asks feed_service to award the badges the new workouts
+// earned. Badges are a bonus, so a failure is only logged.
+func (r *importRun) evaluateBadges() {
+	endpoint := fmt.Sprintf("%s/feed/internal/users/%s/badges/evaluate", r.s.feedUrl, url.PathEscape(r.job.UserID))
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		log.Printf("import %s: evaluate badges: %v", r.job.ImportID, err)
+		return
+	}
+	req.Header.Set("X-Service-Token", r.s.profileServiceAuthToken)
+	res, err := r.s.httpClient.Do(req)
+	if err != nil {
+		log.Printf("import %s: evaluate badges: %v", r.job.ImportID, err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		log.Printf("import %s: evaluate badges: feed service returned %d", r.job.ImportID, res.StatusCode)
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
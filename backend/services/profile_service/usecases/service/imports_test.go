@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"profile_service/domain"
+	"profile_service/repository"
+	"profile_service/usecases/avatar"
+	"profile_service/usecases/healthimport"
+)
+
+type fakeImports struct {
+	repository.ImportRepository
+	mu   sync.Mutex
+	last domain.ImportJob
+}
+
+func (r *fakeImports) Update(ctx context.Context, job *domain.ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = *job
+	return nil
+}
+
+type fakeReadings struct {
+	repository.MeasurementRepository
+}
+
+func (r *fakeReadings) Upsert(ctx context.Context, ms []domain.Measurement) (int64, error) {
+	return int64(len(ms)), nil
+}
+
+func TestImportEvaluatesBadgesOnce(t *testing.T) {
+	// the feed records the requests it gets, in order
+	var mu sync.Mutex
+	var calls []string
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.URL.Path)
+		mu.Unlock()
+		if r.Header.Get("X-Service-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/feed/internal/users/u1/workouts" {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte(`{"badges":[]}`))
+	}))
+	defer feed.Close()
+
+	upload := filepath.Join(t.TempDir(), "import-1")
+	export, err := os.ReadFile(filepath.Join("..", "healthimport", "testdata", "export.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(upload, export, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	imports := &fakeImports{}
+	s := NewProfileService(nil, &fakeReadings{}, imports, "", feed.URL, "secret", nil, avatar.Config{}, healthimport.Config{}).(*profileService)
+	job := &domain.ImportJob{ImportID: "i1", UserID: "u1", Source: healthimport.SourceAppleHealth, TZ: "UTC", FilePath: upload}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.runImport(ctx, cancel, job, "Sam")
+
+	if imports.last.Status != domain.ImportDone || imports.last.Workouts != 3 {
+		t.Fatalf("job finished as %s with %d workouts: %s", imports.last.Status, imports.last.Workouts, imports.last.Error)
+	}
+	want := []string{
+		"/feed/internal/users/u1/workouts",
+		"/feed/internal/users/u1/workouts",
+		"/feed/internal/users/u1/workouts",
+		"/feed/internal/users/u1/badges/evaluate",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("feed got %q, want %q", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("feed got %q, want %q", calls, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"profile_service/domain"
+)
+
+func (s *profileService) LogMeasurement(ctx context.Context, userID string, req domain.MeasurementRequest) (domain.MeasurementResponse, error) {
+	// readings belong to a profile and go away with it
+	if _, err := s.repo.GetByUserID(userID); err != nil {
+		return domain.MeasurementResponse{}, err
+	}
+	m, err := req.ToMeasurement(userID, time.Now())
+	if err != nil {
+		return domain.MeasurementResponse{}, err
+	}
+	if err := s.measurements.Create(ctx, &m); err != nil {
+		return domain.MeasurementResponse{}, err
+	}
+	return m.ToResponse(), nil
+}
+
+func (s *profileService) GetMeasurement(ctx context.Context, measurementID string) (domain.MeasurementResponse, error) {
+	m, err := s.measurements.Get(ctx, measurementID)
+	if err != nil {
+		return domain.MeasurementResponse{}, err
+	}
+	return m.ToResponse(), nil
+}
+
+func (s *profileService) ListMeasurements(ctx context.Context, userID string, q domain.MeasurementQuery, page domain.PageRequest) (domain.Page[domain.MeasurementResponse], error) {
+	// one extra row tells whether another page follows
+	rows, err := s.measurements.List(ctx, userID, q, domain.PageRequest{Limit: page.Limit + 1, After: page.After})
+	if err != nil {
+		return domain.Page[domain.MeasurementResponse]{}, err
+	}
+	out := domain.Page[domain.MeasurementResponse]{Items: make([]domain.MeasurementResponse, 0, len(rows))}
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		out.NextCursor = domain.Cursor{At: last.MeasuredAt, ID: last.ID}.Encode()
+	}
+	for i := range rows {
+		out.Items = append(out.Items, rows[i].ToResponse())
+	}
+	return out, nil
+}
+
+func (s *profileService) AggregateMeasurements(ctx context.Context, userID string, q domain.AggregateQuery) (domain.MeasurementSeries, error) {
+	buckets, err := s.measurements.Aggregate(ctx, userID, q)
+	if err != nil {
+		return domain.MeasurementSeries{}, err
+	}
+	out := domain.MeasurementSeries{
+		Metric:   q.Metric,
+		Unit:     domain.MetricUnit(q.Metric),
+		Interval: q.Interval,
+		TZ:       q.TZ,
+		Buckets:  make([]domain.MeasurementBucketResponse, len(buckets)),
+	}
+	for i := range buckets {
+		out.Buckets[i] = buckets[i].ToResponse()
+	}
+	return out, nil
+}
+
+func (s *profileService) DeleteMeasurement(ctx context.Context, measurementID string) error {
+	return s.measurements.Delete(ctx, measurementID)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"profile_service/domain"
+	"profile_service/repository"
+	"profile_service/usecases/avatar"
+	"profile_service/usecases/healthimport"
+)
+
+// fakeMeasurements pages like the database: newest first by
+// (measured_at, id), strictly after the cursor
+type fakeMeasurements struct {
+	repository.MeasurementRepository
+	rows []domain.Measurement
+}
+
+func (r *fakeMeasurements) List(ctx context.Context, userID string, q domain.MeasurementQuery, page domain.PageRequest) ([]domain.Measurement, error) {
+	rows := slices.Clone(r.rows)
+	slices.SortFunc(rows, func(a, b domain.Measurement) int {
+		if c := b.MeasuredAt.Compare(a.MeasuredAt); c != 0 {
+			return c
+		}
+		return int(b.ID) - int(a.ID)
+	})
+	var out []domain.Measurement
+	for _, m := range rows {
+		if a := page.After; a != nil && !(m.MeasuredAt.Before(a.At) || m.MeasuredAt.Equal(a.At) && m.ID < a.ID) {
+			continue
+		}
+		if len(out) == page.Limit {
+			break
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func TestListMeasurementsPages(t *testing.T) {
+	base := time.Date(2024, 5, 15, 7, 0, 0, 0, time.UTC)
+	var rows []domain.Measurement
+	// ids 1-3 share an instant, as readings of one import batch can
+	for id, at := range map[uint]time.Time{1: base, 2: base, 3: base, 4: base.Add(-time.Hour), 5: base.Add(time.Hour)} {
+		m := domain.Measurement{MeasuredAt: at, Metric: domain.MetricSteps}
+		m.ID = id
+		m.MeasurementID = string(rune('a' + id))
+		rows = append(rows, m)
+	}
+	s := NewProfileService(nil, &fakeMeasurements{rows: rows}, nil, "", "", "", nil, avatar.Config{}, healthimport.Config{})
+
+	tests := []struct {
+		limit int
+		want  [][]string
+	}{
+		{2, [][]string{{"f", "d"}, {"c", "b"}, {"e"}}},
+		{5, [][]string{{"f", "d", "c", "b", "e"}}},
+		{1, [][]string{{"f"}, {"d"}, {"c"}, {"b"}, {"e"}}},
+		{10, [][]string{{"f", "d", "c", "b", "e"}}},
+	}
+	for _, tt := range tests {
+		var got [][]string
+		page := domain.PageRequest{Limit: tt.limit}
+		for {
+			out, err := s.ListMeasurements(context.Background(), "u1", domain.MeasurementQuery{}, page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, m := range out.Items {
+				ids = append(ids, m.MeasurementID)
+			}
+			got = append(got, ids)
+			if out.NextCursor == "" || len(got) > len(rows) {
+				break
+			}
+			if page, err = domain.NewPageRequest("", out.NextCursor); err != nil {
+				t.Fatal(err)
+			}
+			page.Limit = tt.limit
+		}
+		if !slices.EqualFunc(got, tt.want, slices.Equal[[]string]) {
+			t.Errorf("limit %d: pages %q, want %q", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -6,28 +6,45 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sync"
 
 	"profile_service/domain"
 	"profile_service/repository"
 	"profile_service/usecases"
+	"profile_service/usecases/avatar"
+	"profile_service/usecases/healthimport"
 )
 
 // profileService
 type profileService struct {
 	repo                    repository.ProfileRepository
+	measurements            repository.MeasurementRepository
+	imports                 repository.ImportRepository
 	authUrl                 string
 	feedUrl                 string
 	profileServiceAuthToken string
+	blobs                   repository.BlobStore
+	avatarCfg               avatar.Config
+	importCfg               healthimport.Config
+	importSlots             chan struct{}
+	importCancels           sync.Map // user ID -> context.CancelFunc of the running import
 	httpClient              *http.Client
 }
 
 // NewProfileService
-func NewProfileService(repo repository.ProfileRepository, authUrl string, feedUrl string, profileServiceAuthToken string) usecases.ProfileService {
+func NewProfileService(repo repository.ProfileRepository, measurements repository.MeasurementRepository, imports repository.ImportRepository, authUrl string, feedUrl string, profileServiceAuthToken string, blobs repository.BlobStore, avatarCfg avatar.Config, importCfg healthimport.Config) usecases.ProfileService {
 	return &profileService{
 		repo:                    repo,
+		measurements:            measurements,
+		imports:                 imports,
 		authUrl:                 authUrl,
 		feedUrl:                 feedUrl,
 		profileServiceAuthToken: profileServiceAuthToken,
+		blobs:                   blobs,
+		avatarCfg:               avatarCfg,
+		importCfg:               importCfg,
+		importSlots:             make(chan struct{}, max(importCfg.Workers, 1)),
 		httpClient:              http.DefaultClient,
 	}
 }
@@ -59,7 +76,7 @@ func (s *profileService) CreateProfile(ctx context.Context, userID string, req d
 	if err := s.repo.Create(&p); err != nil {
 		return domain.ProfileResponse{}, err
 	}
-	return p.ToResponse(), nil
+	return s.toResponse(&p), nil
 }
 
 func (s *profileService) GetProfile(ctx context.Context, userID string) (domain.ProfileResponse, error) {
@@ -67,7 +84,7 @@ func (s *profileService) GetProfile(ctx context.Context, userID string) (domain.
 	if err != nil {
 		return domain.ProfileResponse{}, err
 	}
-	resp := p.ToResponse()
+	resp := s.toResponse(p)
 	feedURL := fmt.Sprintf("%s/feed/user/publications", s.feedUrl)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
@@ -84,27 +101,66 @@ func (s *profileService) GetProfile(ctx context.Context, userID string) (domain.
 	defer res.Body.Close()
 
 	if res.StatusCode == http.StatusOK {
-		var posts []domain.PublicationResponse
-		if err := json.NewDecoder(res.Body).Decode(&posts); err != nil {
+		var page struct {
+			Items      []domain.PublicationResponse `json:"items"`
+			NextCursor string                       `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
 			log.Printf("GetProfile: decode feed response failed: %v", err)
 		} else {
-			resp.Posts = posts
+			resp.Posts = page.Items
+			resp.PostsNextCursor = page.NextCursor
 		}
 	} else {
 		log.Printf("GetProfile: feed service returned %d", res.StatusCode)
 	}
 
+	resp.Badges = s.fetchBadges(ctx, userID)
 	return resp, nil
 }
 
+// fetchBadges loads the user's badges from feed_service; like the posts
+// they are optional, so failures leave the list empty
+func (s *profileService) fetchBadges(ctx context.Context, userID string) []domain.BadgeResponse {
+	badges := []domain.BadgeResponse{}
+	badgesURL := fmt.Sprintf("%s/feed/users/%s/badges", s.feedUrl, url.PathEscape(userID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, badgesURL, nil)
+	if err != nil {
+		log.Printf("GetProfile: build badges request failed: %v", err)
+		return badges
+	}
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		log.Printf("GetProfile: badges request failed: %v", err)
+		return badges
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		log.Printf("GetProfile: badges request returned %d", res.StatusCode)
+		return badges
+	}
+
+	var body struct {
+		Badges []domain.BadgeResponse `json:"badges"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		log.Printf("GetProfile: decode badges failed: %v", err)
+		return badges
+	}
+	if body.Badges != nil {
+		badges = body.Badges
+	}
+	return badges
+}
+
 func (s *profileService) ListProfiles(ctx context.Context) ([]domain.ProfileResponse, error) {
 	profiles, err := s.repo.List()
 	if err != nil {
 		return nil, err
 	}
 	out := make([]domain.ProfileResponse, len(profiles))
-	for i, p := range profiles {
-		out[i] = p.ToResponse()
+	for i := range profiles {
+		out[i] = s.toResponse(&profiles[i])
 	}
 	return out, nil
 }
@@ -120,19 +176,36 @@ func (s *profileService) UpdateProfile(ctx context.Context, userID string, req d
 	if req.Bio != nil {
 		p.Bio = *req.Bio
 	}
-	if req.Avatar != nil {
-		p.Avatar = *req.Avatar
-	}
 	if err := s.repo.Update(p); err != nil {
 		return domain.ProfileResponse{}, err
 	}
-	return p.ToResponse(), nil
+	// a linked avatar replaces an uploaded one
+	if req.Avatar != nil {
+		if err := s.setAvatar(ctx, p, "", "", *req.Avatar); err != nil {
+			return domain.ProfileResponse{}, err
+		}
+	}
+	return s.toResponse(p), nil
 }
 
 func (s *profileService) DeleteProfile(ctx context.Context, userID string) error {
+	p, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if cancel, ok := s.importCancels.Load(userID); ok {
+		cancel.(context.CancelFunc)()
+	}
+	if err := s.imports.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.measurements.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
 	if err := s.repo.Delete(userID); err != nil {
 		return err
 	}
+	s.deleteAvatar(ctx, p.AvatarID, p.AvatarExt)
 	// DELETE к Auth-сервису
 	// route DELETE /auth/users/:id
 	authEndpoint := fmt.Sprintf("%s/auth/user/%s", s.authUrl, userID)
@@ -113,7 +113,7 @@ class ApiService {
     );
 
     if (response.statusCode == 200) {
-      final List<dynamic> data = jsonDecode(response.body);
+      final List<dynamic> data = jsonDecode(response.body)['items'];
       return data.map((json) => Post.fromJson(json)).toList();
     } else {
       throw Exception('Failed to load publications');
@@ -179,7 +179,7 @@ class ApiService {
     );
 
     if (response.statusCode == 200) {
-      final List<dynamic> data = jsonDecode(response.body);
+      final List<dynamic> data = jsonDecode(response.body)['items'];
       return data.map((json) => Post.fromJson(json)).toList();
     } else {
       throw Exception('Failed to load user publications');
@@ -207,7 +207,7 @@ class ApiService {
     );
 
     if (response.statusCode == 200) {
-      final List<dynamic> data = jsonDecode(response.body);
+      final List<dynamic> data = jsonDecode(response.body)['items'];
       return data.map((json) => Comment.fromJson(json)).toList();
     } else {
       throw Exception('Failed to load comments');